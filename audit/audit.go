@@ -0,0 +1,164 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package audit
+
+// ****************************************************************************
+// audit records the destructive actions, one JSON object per line, apart
+// from the debug lines of gosh.log
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"encoding/json"
+	"gosh/logger"
+	"gosh/ui"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Action string
+
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Session string    `json:"session"`
+	User    string    `json:"user"`
+	Screen  string    `json:"screen"`
+	Action  Action    `json:"action"`
+	Target  string    `json:"target"`
+	Result  string    `json:"result"` // RESULT_OK or the error
+	Sudo    bool      `json:"sudo,omitempty"`
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	ACTION_DELETE   Action = "delete"
+	ACTION_RENAME   Action = "rename"
+	ACTION_TRASH    Action = "trash"
+	ACTION_RESTORE  Action = "restore"
+	ACTION_KILL     Action = "kill"
+	ACTION_RENICE   Action = "renice"
+	ACTION_SIGNAL   Action = "signal"
+	ACTION_SQL_EXEC Action = "SQL exec"
+	ACTION_SAVE     Action = "file save"
+	RESULT_OK              = "OK"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	auditFile *os.File
+	fileName  string
+	userName  string
+	mutex     sync.Mutex
+)
+
+// ****************************************************************************
+// Open()
+// Open appends the next entries to fName
+// ****************************************************************************
+func Open(fName string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	f, err := os.OpenFile(fName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	auditFile = f
+	fileName = fName
+	if u, err := user.Current(); err == nil {
+		userName = u.Username
+	}
+	return nil
+}
+
+// ****************************************************************************
+// Close()
+// ****************************************************************************
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if auditFile != nil {
+		auditFile.Close()
+		auditFile = nil
+	}
+}
+
+// ****************************************************************************
+// Service()
+// Service returns the action of systemctl verb, like "service start"
+// ****************************************************************************
+func Service(verb string) Action {
+	return Action("service " + verb)
+}
+
+// ****************************************************************************
+// Log()
+// Log records an action done on target, err being its result
+// ****************************************************************************
+func Log(action Action, target string, err error) {
+	write(action, target, err, false)
+}
+
+// ****************************************************************************
+// LogElevated()
+// LogElevated records an action done with sudo
+// ****************************************************************************
+func LogElevated(action Action, target string, err error) {
+	write(action, target, err, true)
+}
+
+// ****************************************************************************
+// write()
+// ****************************************************************************
+func write(action Action, target string, err error, elevated bool) {
+	e := Entry{
+		Time:    time.Now(),
+		Session: ui.SessionID,
+		User:    userName,
+		Screen:  currentScreen(),
+		Action:  action,
+		Target:  target,
+		Result:  RESULT_OK,
+		Sudo:    elevated,
+	}
+	if err != nil {
+		e.Result = err.Error()
+	}
+	b, _ := json.Marshal(e)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if auditFile == nil {
+		return
+	}
+	if _, err := auditFile.Write(append(b, '\n')); err != nil {
+		logger.Error("audit.go: %v", err)
+	}
+}
+
+// ****************************************************************************
+// currentScreen()
+// ****************************************************************************
+func currentScreen() string {
+	if ui.IdxScreens < 0 || ui.IdxScreens >= len(ui.ArrScreens) {
+		return ""
+	}
+	return ui.ArrScreens[ui.IdxScreens].Title
+}
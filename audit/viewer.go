@@ -0,0 +1,188 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package audit
+
+// ****************************************************************************
+// viewer is the Audit screen, the entries matching the filter typed in the
+// prompt being shown from the newest one
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"gosh/theme"
+	"gosh/ui"
+	"os"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	filter  string
+	columns = []string{"Time", "Session", "User", "Screen", "Action", "Target", "Result"}
+)
+
+// ****************************************************************************
+// SelfInit()
+// ****************************************************************************
+func SelfInit(a any) {
+	Refresh()
+	ui.App.SetFocus(ui.TblAudit)
+}
+
+// ****************************************************************************
+// SetFilter()
+// SetFilter shows the entries matching all the words of text, a word like
+// field:value matching one column only (result:failed for the errors)
+// ****************************************************************************
+func SetFilter(text string) {
+	filter = strings.TrimSpace(text)
+	ui.TxtPrompt.SetText("", false)
+	n := Refresh()
+	if filter == "" {
+		ui.SetStatus(fmt.Sprintf("%d entries", n))
+	} else {
+		ui.SetStatus(fmt.Sprintf("%d entries matching %s", n, filter))
+	}
+}
+
+// ****************************************************************************
+// ApplyTheme()
+// ApplyTheme renders the entries again in the colors of the current theme,
+// when an Audit screen shows them
+// ****************************************************************************
+func ApplyTheme() {
+	if ui.TblAudit.GetRowCount() > 0 {
+		Refresh()
+	}
+}
+
+// ****************************************************************************
+// Refresh()
+// Refresh reads the audit log again and returns the number of entries shown
+// ****************************************************************************
+func Refresh() int {
+	entries, err := readEntries()
+	if err != nil {
+		ui.SetStatus(err.Error())
+	}
+	ui.TblAudit.Clear()
+	for i, c := range columns {
+		ui.TblAudit.SetCell(0, i, tview.NewTableCell(c).
+			SetTextColor(theme.SecondaryText).
+			SetSelectable(false))
+	}
+	row := 1
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !e.matches(filter) {
+			continue
+		}
+		color := theme.Text
+		if e.Result != RESULT_OK {
+			color = theme.Error
+		}
+		for col, text := range e.fields() {
+			ui.TblAudit.SetCell(row, col, tview.NewTableCell(tview.Escape(text)).
+				SetTextColor(color).
+				SetExpansion(expansion(col)))
+		}
+		row++
+	}
+	ui.TblAudit.SetTitle(fmt.Sprintf("Audit Log [%d]", row-1))
+	ui.TblAudit.Select(1, 0).ScrollToBeginning()
+	return row - 1
+}
+
+// ****************************************************************************
+// readEntries()
+// ****************************************************************************
+func readEntries() ([]Entry, error) {
+	mutex.Lock()
+	fName := fileName
+	mutex.Unlock()
+	f, err := os.Open(fName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// ****************************************************************************
+// fields() Entry
+// ****************************************************************************
+func (e Entry) fields() []string {
+	action := string(e.Action)
+	if e.Sudo {
+		action += " (sudo)"
+	}
+	return []string{e.Time.Format(ui.MyConfig.FormatDate + " " + ui.MyConfig.FormatTime), e.Session, e.User, e.Screen, action, e.Target, e.Result}
+}
+
+// ****************************************************************************
+// matches() Entry
+// ****************************************************************************
+func (e Entry) matches(filter string) bool {
+	fields := e.fields()
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		name, value, found := strings.Cut(word, ":")
+		col := -1
+		if found {
+			for i, c := range columns {
+				if strings.ToLower(c) == name {
+					col = i
+				}
+			}
+		}
+		switch {
+		case col < 0:
+			if !strings.Contains(strings.ToLower(strings.Join(fields, "\t")), word) {
+				return false
+			}
+		case name == "result" && value == "failed":
+			if e.Result == RESULT_OK {
+				return false
+			}
+		case !strings.Contains(strings.ToLower(fields[col]), value):
+			return false
+		}
+	}
+	return true
+}
+
+// ****************************************************************************
+// expansion()
+// expansion gives the room left to the Target and Result columns
+// ****************************************************************************
+func expansion(col int) int {
+	if col >= 5 {
+		return 1
+	}
+	return 0
+}
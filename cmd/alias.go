@@ -0,0 +1,494 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// alias manages the aliases and the functions defined by the user, in the
+// prompt or in the aliases file of the app folder
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gosh/logger"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type function struct {
+	name string
+	body string // Lines of commands, $1... are the arguments
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	FUNCTION_MAX_DEPTH = 100
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	defsMutex   sync.Mutex
+	aliases     = map[string]string{}
+	functions   = map[string]*function{}
+	reFuncStart = regexp.MustCompile(`^\s*(?:function\s+[A-Za-z_][A-Za-z0-9_.-]*\s*(?:\(\s*\))?|[A-Za-z_][A-Za-z0-9_.-]*\s*\(\s*\))\s*(?:\{|$)`)
+	reFuncDef   = regexp.MustCompile(`(?s)^\s*(?:function\s+([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:\(\s*\))?|([A-Za-z_][A-Za-z0-9_.-]*)\s*\(\s*\))\s*\{(.*)\}\s*$`)
+)
+
+// ****************************************************************************
+// LoadAliases()
+// LoadAliases reads the alias commands and the functions of a file
+// ****************************************************************************
+func LoadAliases(fName string) error {
+	f, err := os.Open(fName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	lines, err := scriptLines(f)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if isFunctionDefinition(line) {
+			if err := defineFunction(line); err != nil {
+				logger.Warning("alias.go: %s: %s", fName, err.Error())
+			}
+			continue
+		}
+		lists, err := parseLine(line)
+		if err != nil || len(lists) != 1 || len(lists[0].pipes) != 1 || len(lists[0].pipes[0].commands) != 1 {
+			logger.Warning("alias.go: %s: line ignored: %s", fName, line)
+			continue
+		}
+		args := expandArgs(lists[0].pipes[0].commands[0].args)
+		if len(args) == 0 || args[0] != "alias" {
+			logger.Warning("alias.go: %s: line ignored: %s", fName, line)
+			continue
+		}
+		doAlias(args, io.Discard, logger.Writer(logger.LEVEL_WARNING))
+	}
+	return nil
+}
+
+// ****************************************************************************
+// scriptLines()
+// scriptLines reads the logical lines of a script : the comments and the
+// empty lines are skipped, a line ended by \ or by an operator goes on, and
+// a function definition is a single line
+// ****************************************************************************
+func scriptLines(r io.Reader) ([]string, error) {
+	var lines []string
+	var current []string
+	inFunction := false
+	opened := false
+	depth := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if len(current) == 0 {
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			inFunction = reFuncStart.MatchString(line)
+			opened = false
+			depth = 0
+		}
+		if inFunction {
+			// The body keeps its lines, up to the closing brace
+			current = append(current, line)
+			depth += braceDepth(line)
+			opened = opened || strings.Contains(line, "{")
+			if !opened || depth > 0 {
+				continue
+			}
+			lines = append(lines, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			current = append(current, strings.TrimSuffix(trimmed, "\\"))
+			continue
+		}
+		current = append(current, trimmed)
+		if strings.HasSuffix(trimmed, "&&") || strings.HasSuffix(trimmed, "||") || strings.HasSuffix(trimmed, "|") {
+			continue
+		}
+		lines = append(lines, strings.Join(current, " "))
+		current = nil
+	}
+	if len(current) > 0 {
+		if inFunction {
+			return lines, errors.New("unexpected end of file: missing }")
+		}
+		lines = append(lines, strings.Join(current, " "))
+	}
+	return lines, scanner.Err()
+}
+
+// ****************************************************************************
+// braceDepth()
+// braceDepth returns the number of { minus the number of } out of quotes
+// ****************************************************************************
+func braceDepth(line string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\\':
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return depth
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// ****************************************************************************
+// isFunctionDefinition()
+// ****************************************************************************
+func isFunctionDefinition(line string) bool {
+	return reFuncStart.MatchString(line)
+}
+
+// ****************************************************************************
+// defineFunction()
+// defineFunction records a function written as name() { ... } or as
+// function name { ... }
+// ****************************************************************************
+func defineFunction(text string) error {
+	m := reFuncDef.FindStringSubmatch(text)
+	if m == nil {
+		return errors.New("syntax error in function definition")
+	}
+	name := m[1] + m[2]
+	if _, ok := builtins[name]; ok {
+		return fmt.Errorf("%s: is a shell builtin", name)
+	}
+	body := strings.TrimSpace(m[3])
+	body = strings.TrimSpace(strings.TrimSuffix(body, ";"))
+	lines, err := scriptLines(strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err.Error())
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("%s: empty function", name)
+	}
+	for _, line := range lines {
+		if _, err := parseLine(line); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+	defsMutex.Lock()
+	defer defsMutex.Unlock()
+	functions[name] = &function{name: name, body: strings.Join(lines, "\n")}
+	return nil
+}
+
+// ****************************************************************************
+// lookupFunction()
+// ****************************************************************************
+func lookupFunction(name string) *function {
+	defsMutex.Lock()
+	defer defsMutex.Unlock()
+	return functions[name]
+}
+
+// ****************************************************************************
+// runFunction()
+// runFunction runs the commands of a function with its arguments
+// ****************************************************************************
+func runFunction(j *job, f *function, args []string, stdout io.Writer, stderr io.Writer, tty *os.File) int {
+	if j.depth >= FUNCTION_MAX_DEPTH {
+		fmt.Fprintf(stderr, "gosh: %s: maximum function nesting level exceeded (%d)\n", f.name, FUNCTION_MAX_DEPTH)
+		return 1
+	}
+	j.depth++
+	defer func() { j.depth-- }()
+
+	lines, _ := scriptLines(strings.NewReader(substituteArgs(f.body, args)))
+	rc := 0
+	for _, line := range lines {
+		if j.isStopped() {
+			break
+		}
+		rc = xeqLine(j, f.name, line, stdout, stderr, tty)
+		j.setLastRC(rc)
+	}
+	return rc
+}
+
+// ****************************************************************************
+// substituteArgs()
+// substituteArgs replaces $0 to $9, ${n}, $#, $@ and $* by the arguments,
+// quoted so that they are not expanded again
+// ****************************************************************************
+func substituteArgs(body string, args []string) string {
+	arg := func(n int) string {
+		if n < len(args) {
+			return args[n]
+		}
+		return ""
+	}
+	var b strings.Builder
+	quote := byte(0)
+	// The value of a variable is inserted inside or outside double quotes
+	insert := func(value string) {
+		if quote == '"' {
+			b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value))
+		} else if value != "" {
+			b.WriteString("'" + strings.ReplaceAll(value, "'", `'\''`) + "'")
+		}
+	}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\' && i+1 < len(body):
+			b.WriteByte(c)
+			i++
+			c = body[i]
+		case c == '\'' && quote == 0:
+			quote = c
+		case c == '"':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '$' && i+1 < len(body):
+			next := body[i+1]
+			switch {
+			case next == '$':
+				b.WriteString("$$")
+				i++
+				continue
+			case next >= '0' && next <= '9':
+				insert(arg(int(next - '0')))
+				i++
+				continue
+			case next == '{' && i+2 < len(body) && isDigits(body[i+2:i+2+strings.IndexByte(body[i+2:]+"}", '}')]):
+				end := i + 2 + strings.IndexByte(body[i+2:], '}')
+				n, _ := strconv.Atoi(body[i+2 : end])
+				insert(arg(n))
+				i = end
+				continue
+			case next == '#':
+				b.WriteString(strconv.Itoa(len(args) - 1))
+				i++
+				continue
+			case next == '@' || next == '*':
+				if quote == '"' && next == '@' {
+					// "$@" gives a word for each argument
+					var quoted []string
+					for _, a := range args[1:] {
+						quoted = append(quoted, strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(a))
+					}
+					b.WriteString(strings.Join(quoted, `" "`))
+				} else if quote == '"' {
+					insert(strings.Join(args[1:], " "))
+				} else {
+					for k, a := range args[1:] {
+						if k > 0 {
+							b.WriteByte(' ')
+						}
+						insert(a)
+					}
+				}
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// ****************************************************************************
+// expandAliases()
+// expandAliases replaces the first word of the commands by their alias, an
+// alias is not expanded again inside itself
+// ****************************************************************************
+func expandAliases(tokens []token, seen map[string]bool) ([]token, error) {
+	var out []token
+	commandStart := true
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.kind {
+		case TOKEN_WORD:
+			if commandStart && len(t.word) == 1 && t.word[0].quote == QUOTE_NONE && !seen[t.word[0].text] {
+				defsMutex.Lock()
+				value, ok := aliases[t.word[0].text]
+				defsMutex.Unlock()
+				if ok {
+					sub, err := tokenize(value)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %s", t.word[0].text, err.Error())
+					}
+					inner := map[string]bool{t.word[0].text: true}
+					for name := range seen {
+						inner[name] = true
+					}
+					if sub, err = expandAliases(sub, inner); err != nil {
+						return nil, err
+					}
+					out = append(out, sub...)
+					commandStart = false
+					continue
+				}
+			}
+			commandStart = false
+		case TOKEN_PIPE, TOKEN_AND, TOKEN_OR, TOKEN_SEMI, TOKEN_AMP:
+			commandStart = true
+		case TOKEN_REDIR_IN, TOKEN_REDIR_OUT, TOKEN_REDIR_APPEND, TOKEN_REDIR_ALL, TOKEN_REDIR_ALL_APPEND:
+			// The file name is not a command
+			out = append(out, t)
+			if i+1 < len(tokens) {
+				i++
+				out = append(out, tokens[i])
+			}
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// ****************************************************************************
+// doAlias()
+// ****************************************************************************
+func doAlias(args []string, stdout io.Writer, stderr io.Writer) int {
+	defsMutex.Lock()
+	defer defsMutex.Unlock()
+	printAlias := func(name string) {
+		fmt.Fprintf(stdout, "alias %s='%s'\n", name, strings.ReplaceAll(aliases[name], "'", `'\''`))
+	}
+	if len(args) == 1 || (len(args) == 2 && args[1] == "-p") {
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			printAlias(name)
+		}
+		return 0
+	}
+	rc := 0
+	for _, arg := range args[1:] {
+		name, value, found := strings.Cut(arg, "=")
+		if !found {
+			if _, ok := aliases[name]; ok {
+				printAlias(name)
+			} else {
+				fmt.Fprintf(stderr, "gosh: alias: %s: not found\n", name)
+				rc = 1
+			}
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, " \t'\"\\|;&<>$=/") {
+			fmt.Fprintf(stderr, "gosh: alias: `%s': invalid alias name\n", name)
+			rc = 1
+			continue
+		}
+		aliases[name] = value
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doUnalias()
+// ****************************************************************************
+func doUnalias(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 1 {
+		fmt.Fprintln(stderr, "unalias: usage: unalias [-a] name [name ...]")
+		return 2
+	}
+	defsMutex.Lock()
+	defer defsMutex.Unlock()
+	rc := 0
+	for _, name := range args[1:] {
+		if name == "-a" {
+			aliases = map[string]string{}
+			continue
+		}
+		if _, ok := aliases[name]; !ok {
+			fmt.Fprintf(stderr, "gosh: unalias: %s: not found\n", name)
+			rc = 1
+			continue
+		}
+		delete(aliases, name)
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doFunctions()
+// doFunctions lists the functions, or shows their definition
+// ****************************************************************************
+func doFunctions(args []string, stdout io.Writer, stderr io.Writer) int {
+	defsMutex.Lock()
+	defer defsMutex.Unlock()
+	names := args[1:]
+	if len(names) == 0 {
+		for name := range functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	rc := 0
+	for _, name := range names {
+		f, ok := functions[name]
+		if !ok {
+			fmt.Fprintf(stderr, "gosh: functions: %s: not found\n", name)
+			rc = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s() {\n", f.name)
+		for _, line := range strings.Split(f.body, "\n") {
+			fmt.Fprintf(stdout, "    %s\n", strings.TrimSpace(line))
+		}
+		fmt.Fprintln(stdout, "}")
+	}
+	return rc
+}
@@ -0,0 +1,48 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"reflect"
+	"testing"
+)
+
+// ****************************************************************************
+// TestSubstituteArgs()
+// ****************************************************************************
+func TestSubstituteArgs(t *testing.T) {
+	args := []string{"f", "a b", "it's", "$HOME"}
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{`echo $1`, []string{"echo", "a b"}},
+		{`echo "$1"`, []string{"echo", "a b"}},
+		{`echo $2 ${3}`, []string{"echo", "it's", "$HOME"}},
+		{`echo "$2-$3"`, []string{"echo", "it's-$HOME"}},
+		{`echo '$1'`, []string{"echo", "$1"}},
+		{`echo \$1`, []string{"echo", "$1"}},
+		{`echo $#`, []string{"echo", "3"}},
+		{`echo $0`, []string{"echo", "f"}},
+		{`echo x$9y`, []string{"echo", "xy"}},
+		{`echo "$@"`, []string{"echo", "a b", "it's", "$HOME"}},
+		{`echo "$*"`, []string{"echo", "a b it's $HOME"}},
+	}
+	for _, test := range tests {
+		line := substituteArgs(test.body, args)
+		if got := fields(t, line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %s gives %q, want %q", test.body, line, got, test.want)
+		}
+	}
+}
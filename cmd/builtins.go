@@ -0,0 +1,234 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// builtins are the commands run by the shell itself
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"gosh/fm"
+	"gosh/ui"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	oldCwd   string   // Previous folder, for "cd -"
+	dirStack []string // Folders saved by pushd, most recent first
+)
+
+// ****************************************************************************
+// doCls()
+// ****************************************************************************
+func doCls(args []string, stdout io.Writer, stderr io.Writer) int {
+	updateUI(func() {
+		ui.ClearConsole()
+	})
+	return 0
+}
+
+// ****************************************************************************
+// changeDir()
+// changeDir makes dir the current folder of the shell and the File Manager
+// ****************************************************************************
+func changeDir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(conf.Cwd, dir)
+	}
+	dir = filepath.Clean(dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("%s: No such file or directory", dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s: Not a directory", dir)
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("%s: Permission denied", dir)
+	}
+	f.Close()
+	previous := conf.Cwd
+	if Batch {
+		conf.Cwd = dir
+	} else {
+		// QueueUpdateDraw waits for the update, so the next commands run in dir
+		ui.App.QueueUpdateDraw(func() {
+			fm.SetCwd(dir)
+		})
+	}
+	oldCwd = previous
+	os.Setenv("OLDPWD", previous)
+	return nil
+}
+
+// ****************************************************************************
+// tildeName()
+// tildeName shortens a path located in the home folder with a ~
+// ****************************************************************************
+func tildeName(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || home == "/" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if strings.HasPrefix(dir, home+string(os.PathSeparator)) {
+		return "~" + dir[len(home):]
+	}
+	return dir
+}
+
+// ****************************************************************************
+// printDirs()
+// ****************************************************************************
+func printDirs(stdout io.Writer, verbose bool, long bool) {
+	dirs := append([]string{conf.Cwd}, dirStack...)
+	for i, d := range dirs {
+		if !long {
+			d = tildeName(d)
+		}
+		if verbose {
+			fmt.Fprintf(stdout, "%2d  %s\n", i, d)
+		} else {
+			if i > 0 {
+				fmt.Fprint(stdout, " ")
+			}
+			fmt.Fprint(stdout, d)
+		}
+	}
+	if !verbose {
+		fmt.Fprintln(stdout)
+	}
+}
+
+// ****************************************************************************
+// doCd()
+// ****************************************************************************
+func doCd(args []string, stdout io.Writer, stderr io.Writer) int {
+	var dir string
+	switch len(args) {
+	case 1:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(stderr, "gosh: cd: %s\n", err.Error())
+			return 1
+		}
+		dir = home
+	case 2:
+		dir = args[1]
+		if dir == "-" {
+			if oldCwd == "" {
+				fmt.Fprintln(stderr, "gosh: cd: OLDPWD not set")
+				return 1
+			}
+			dir = oldCwd
+			fmt.Fprintln(stdout, dir)
+		}
+	default:
+		fmt.Fprintln(stderr, "gosh: cd: too many arguments")
+		return 1
+	}
+	if err := changeDir(dir); err != nil {
+		fmt.Fprintf(stderr, "gosh: cd: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// ****************************************************************************
+// doPushd()
+// ****************************************************************************
+func doPushd(args []string, stdout io.Writer, stderr io.Writer) int {
+	current := conf.Cwd
+	switch len(args) {
+	case 1:
+		// Without argument, exchange the two top folders
+		if len(dirStack) == 0 {
+			fmt.Fprintln(stderr, "gosh: pushd: no other directory")
+			return 1
+		}
+		if err := changeDir(dirStack[0]); err != nil {
+			fmt.Fprintf(stderr, "gosh: pushd: %s\n", err.Error())
+			return 1
+		}
+		dirStack[0] = current
+	case 2:
+		if err := changeDir(args[1]); err != nil {
+			fmt.Fprintf(stderr, "gosh: pushd: %s\n", err.Error())
+			return 1
+		}
+		dirStack = append([]string{current}, dirStack...)
+	default:
+		fmt.Fprintln(stderr, "gosh: pushd: too many arguments")
+		return 1
+	}
+	printDirs(stdout, false, false)
+	return 0
+}
+
+// ****************************************************************************
+// doPopd()
+// ****************************************************************************
+func doPopd(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprintln(stderr, "gosh: popd: too many arguments")
+		return 1
+	}
+	if len(dirStack) == 0 {
+		fmt.Fprintln(stderr, "gosh: popd: directory stack empty")
+		return 1
+	}
+	if err := changeDir(dirStack[0]); err != nil {
+		fmt.Fprintf(stderr, "gosh: popd: %s\n", err.Error())
+		return 1
+	}
+	dirStack = dirStack[1:]
+	printDirs(stdout, false, false)
+	return 0
+}
+
+// ****************************************************************************
+// doDirs()
+// ****************************************************************************
+func doDirs(args []string, stdout io.Writer, stderr io.Writer) int {
+	verbose := false
+	long := false
+	for _, arg := range args[1:] {
+		switch arg {
+		case "-c":
+			dirStack = nil
+			return 0
+		case "-v":
+			verbose = true
+		case "-l":
+			long = true
+		default:
+			fmt.Fprintf(stderr, "gosh: dirs: %s: invalid option\n", arg)
+			fmt.Fprintln(stderr, "dirs: usage: dirs [-c] [-l] [-v]")
+			return 1
+		}
+	}
+	printDirs(stdout, verbose, long)
+	return 0
+}
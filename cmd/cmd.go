@@ -13,43 +13,82 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"gosh/audit"
 	"gosh/conf"
 	"gosh/edit"
 	"gosh/fm"
 	"gosh/help"
 	"gosh/hexedit"
+	"gosh/logger"
 	"gosh/pm"
+	"gosh/settings"
 	"gosh/sq3"
+	"gosh/theme"
 	"gosh/ui"
+	"io"
+	"os"
+	"os/exec"
 	"strings"
 	"sync"
 	syscall "syscall"
 	"time"
 
-	"github.com/go-cmd/cmd"
 	"github.com/rivo/tview"
 )
 
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type builtin func(args []string, stdout io.Writer, stderr io.Writer) int
+
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
 var (
-	ACmd        []string
-	ICmd        int
 	CurrentUser string
-	currentCmd  *cmd.Cmd
-	currentCmdPGID int
+	Batch       bool // The commands run without the user interface
+	builtins    map[string]builtin
 )
 
-// StopCurrentCommand stops the currently running command.
+// ****************************************************************************
+// init()
+// ****************************************************************************
+func init() {
+	builtins = map[string]builtin{
+		"cls":       doCls,
+		"cd":        doCd,
+		"pushd":     doPushd,
+		"popd":      doPopd,
+		"dirs":      doDirs,
+		"export":    doExport,
+		"unset":     doUnset,
+		"env":       doEnv,
+		"jobs":      doJobs,
+		"fg":        doFg,
+		"wait":      doWait,
+		"kill":      doKill,
+		"history":   doHistory,
+		"alias":     doAlias,
+		"unalias":   doUnalias,
+		"functions": doFunctions,
+	}
+}
+
+// StopCurrentCommand stops the commands running in the foreground.
 func StopCurrentCommand() {
-	if currentCmdPGID != 0 {
+	fg := foregroundJobs()
+	if len(fg) != 0 {
 		ui.SetStatus("Attempting to interrupt command...")
-		ui.SetStatus(fmt.Sprintf("Sending SIGINT to PGID %d", currentCmdPGID))
-        err := syscall.Kill(-currentCmdPGID, syscall.SIGINT) // Send to process group
-        if err != nil {
-            conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Error sending SIGINT to PGID %d: %v\n", currentCmdPGID, err))
-        }
-        time.Sleep(100 * time.Millisecond) // Give process time to react
-        currentCmdPGID = 0
+		for _, j := range fg {
+			ui.SetStatus(fmt.Sprintf("Sending SIGINT to job %d", j.id))
+			j.signal(syscall.SIGINT)
+		}
+		time.Sleep(100 * time.Millisecond) // Give process time to react
 		ui.SetStatus("Command interrupted.")
 	} else {
 		ui.SetStatus("No active command to interrupt.")
@@ -89,142 +128,588 @@ func (rw *output) Lines() []string {
 	return rw.lines
 }
 
+// ****************************************************************************
+// consoleWriter
+// consoleWriter streams the output of the commands into the console, line by line
+// ****************************************************************************
+type consoleWriter struct {
+	mutex  sync.Mutex
+	buf    []byte
+	stderr bool
+}
+
+func newConsoleWriter(stderr bool) *consoleWriter {
+	return &consoleWriter{stderr: stderr}
+}
+
+func (cw *consoleWriter) Write(p []byte) (int, error) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	cw.buf = append(cw.buf, p...)
+	for {
+		i := bytes.IndexByte(cw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		cw.emit(string(cw.buf[:i]))
+		cw.buf = cw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes what remains of an unterminated last line
+func (cw *consoleWriter) Flush() {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	if len(cw.buf) > 0 {
+		cw.emit(string(cw.buf))
+		cw.buf = nil
+	}
+}
+
+func (cw *consoleWriter) emit(line string) {
+	line = tview.TranslateANSI(strings.TrimRight(line, "\r"))
+	ui.App.QueueUpdateDraw(func() { // Update UI on main thread
+		ui.WriteConsole(line, cw.stderr)
+	})
+}
+
 // ****************************************************************************
 // xeq()
 // ****************************************************************************
 func Xeq(c string) {
-	sCmd := strings.Fields(c)
-	ACmd = append(ACmd, c)
-	ICmd++
-	if sCmd[0][0] == '!' {
-		xCmd := sCmd[0] + "     "
-		xCmd = xCmd[:5]
-		xCmd = strings.TrimSpace(xCmd)
-		switch xCmd {
-		case "!quit", "!exit", "!bye":
-			ui.PgsApp.SwitchToPage("dlgQuit")
-		case "!shel":
-			// SwitchToShell()
-			ui.AddNewScreen(ui.ModeShell, nil, nil)
-		case "!file":
-			// SwitchToFiles()
-			ui.AddNewScreen(ui.ModeFiles, fm.SelfInit, nil)
-		case "!proc":
-			// SwitchToProcess()
-			ui.AddNewScreen(ui.ModeProcess, pm.SelfInit, CurrentUser)
-		case "!edit":
-			// SwitchToEditor()
-			ui.AddNewScreen(ui.ModeTextEdit, edit.SelfInit, nil)
-		case "!sql":
-			// SwitchToSQLite3()
-			ui.AddNewScreen(ui.ModeSQLite3, sq3.SelfInit, nil)
-		case "!help":
-			// SwitchToHelp()
-			ui.AddNewScreen(ui.ModeHelp, help.SelfInit, nil)
-		case "!hex":
-			// SwitchToHexEdit()
-			ui.AddNewScreen(ui.ModeHexEdit, hexedit.SelfInit, nil)
-		default:
-			ui.SetStatus(fmt.Sprintf("Invalid command %s", sCmd[0]))
+	c = strings.TrimSpace(c)
+	if c == "" {
+		ui.TxtPrompt.SetText("", false)
+		return
+	}
+	c, err := expandHistory(c)
+	if err != nil {
+		ui.OutConsole("[yellow]gosh: " + tview.Escape(err.Error()) + "[-]")
+		ui.SetStatus(err.Error())
+		ui.TxtPrompt.SetText("", false)
+		return
+	}
+	h := addHistory(c)
+	if r := currentRemote(); r != nil && c[0] != '!' {
+		xeqRemote(r, c, h)
+	} else if c[0] == '!' {
+		if screenKey(c) == "!sour" {
+			// The script runs like a command
+			ui.HeaderConsole(c)
+			ui.PleaseWait()
+			go runSourceJob(c, h)
+			ui.TxtPrompt.SetText("", false)
+			return
+		}
+		if !screenCommand(c) {
+			ui.SetStatus(fmt.Sprintf("Invalid command %s", strings.Fields(c)[0]))
 		}
+		go finishHistory(h, 0, 0)
 	} else {
-		switch sCmd[0] {
-		case "cls":
-			ui.TxtConsole.SetText("")
-		default:
+		ui.HeaderConsole(c)
+		var lists []*list
+		if isFunctionDefinition(c) {
+			err = defineFunction(c)
+		} else {
+			lists, err = parseLine(c)
+		}
+		if err != nil {
+			ui.OutConsole("[yellow]gosh: " + tview.Escape(err.Error()) + "[-]")
+			ui.SetStatus(err.Error())
+			// Like the other shells, a syntax error returns 2
+			lastRC.Store(2)
+			ui.EndBlock(2, 0, 0)
+			go finishHistory(h, 2, 0)
+		} else if lists == nil {
+			lastRC.Store(0)
+			ui.EndBlock(0, 0, 0)
+			go finishHistory(h, 0, 0)
+		} else {
 			ui.PleaseWait()
-			ui.HeaderConsole(c)
+			_, _, cols, rows := ui.TxtConsole.GetInnerRect()
+			// Run command in a goroutine to prevent blocking the UI
+			go runForeground(c, lists, cols, rows, h)
+		}
+	}
+	ShowPrompt()
+	ui.TxtPrompt.SetText("", false)
+}
 
-			cmdOptions := cmd.Options{
-				Buffered:  false,
-				Streaming: true,
+// ****************************************************************************
+// doTheme()
+// doTheme switches to the theme given to !theme, or lists the themes
+// ****************************************************************************
+func doTheme(c string) {
+	args := strings.Fields(c)
+	if len(args) < 2 {
+		for _, name := range theme.Names() {
+			mark := "  "
+			if name == theme.Current() {
+				mark = "✓ "
 			}
+			ui.OutConsole(mark + name)
+		}
+		return
+	}
+	if err := settings.UseTheme(args[1]); err != nil {
+		ui.OutConsole("[yellow]gosh: !theme: " + tview.Escape(err.Error()) + "[-]")
+		return
+	}
+	ui.SetStatus("Theme " + args[1])
+}
 
-			xCmd := cmd.NewCmdOptions(cmdOptions, sCmd[0], sCmd[1:]...)
-			xCmd.Dir = conf.Cwd
+// ****************************************************************************
+// screenKey()
+// screenKey returns the 5 first characters of a ! command, enough to know it
+// ****************************************************************************
+func screenKey(c string) string {
+	xCmd := strings.Fields(c)[0] + "     "
+	return strings.TrimSpace(xCmd[:5])
+}
 
-			// Run command in a goroutine to prevent blocking the UI
-            go func() {
-                conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Starting command goroutine for: %s\n", c))
-                currentCmd = xCmd // Store the command for interruption
-
-                statusChan := xCmd.Start() // Start the command
-
-                time.Sleep(50 * time.Millisecond) // Give process time to start
-                status := xCmd.Status()
-                currentCmdPGID = status.PID // Use PID as PGID for now
-                conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Command started, PID: %d, PGID: %d (initial from status)\n", status.PID, currentCmdPGID))
-
-                // Goroutine to handle output streaming
-                doneChan := make(chan struct{})
-                go func() {
-                    defer close(doneChan)
-
-                    for {
-                        select {
-                        case line, open := <-xCmd.Stdout:
-                            if !open {
-                                xCmd.Stdout = nil
-                                conf.LogFile.WriteString("cmd.go: Stdout channel closed.\n")
-                                continue
-                            }
-                            conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Stdout line: %s\n", line))
-                            ui.App.QueueUpdateDraw(func() { // Update UI on main thread
-                                ui.LblPID.SetText(fmt.Sprintf("PID=%d", currentCmdPGID)) // Use currentCmdPGID for display
-                                ui.TxtConsole.Write([]byte(tview.TranslateANSI(line) + "\n"))
-                                ui.TxtConsole.ScrollToEnd()
-                            })
-
-                        case line, open := <-xCmd.Stderr:
-                            if !open {
-                                xCmd.Stderr = nil
-                                conf.LogFile.WriteString("cmd.go: Stderr channel closed.\n")
-                                continue
-                            }
-                            conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Stderr line: %s\n", line))
-                            ui.App.QueueUpdateDraw(func() { // Update UI on main thread
-                                ui.LblPID.SetText(fmt.Sprintf("PID=%d", currentCmdPGID)) // Use currentCmdPGID for display
-                                ui.TxtConsole.Write([]byte("[yellow]" + tview.TranslateANSI(line) + "[white]\n"))
-                                ui.TxtConsole.ScrollToEnd()
-                            })
-                        case <-time.After(100 * time.Millisecond): // Periodically check if statusChan is closed
-                            if statusChan == nil {
-                                break // Exit loop if statusChan is closed
-                            }
-                        }
-                        if xCmd.Stdout == nil && xCmd.Stderr == nil && statusChan == nil {
-                            break // All channels closed, exit loop
-                        }
-                    }
-                    conf.LogFile.WriteString("cmd.go: Output streaming goroutine finished.\n")
-                }()
-
-                // Wait for output streaming to finish
-                <-doneChan
-
-                // Wait for the command to finish and get its final status
-                finalStatus := <-statusChan // This will get the final status
-                conf.LogFile.WriteString("cmd.go: doneChan closed, processing final status.\n")
-
-                // Job's done !
-                conf.LogFile.WriteString("cmd.go: Executing UI update for summary.\n")
-                ui.TxtPath.SetText(conf.Cwd)
-                rc := finalStatus.Exit
-                summary := fmt.Sprintf("\n[yellow]Runtime for PID %d is %f seconds. Return Code: %d[white]\n", finalStatus.PID, finalStatus.Runtime, rc)
-                conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Writing summary to console: %s\n", summary))
-                ui.TxtConsole.Write([]byte(summary))
-                if rc != 0 {
-                    ui.LblRC.SetText(fmt.Sprintf("[#FF0000]RC=%d", rc))
-                } else {
-                    ui.LblRC.SetText(fmt.Sprintf("[#F5DEB3]RC=%d", rc))
-                }
-                ui.LblPID.SetText(fmt.Sprintf("%.2fs", finalStatus.Runtime))
-                ui.JobsDone()
-                ui.App.Draw() // Force redraw
-                time.Sleep(50 * time.Millisecond) // Give UI time to update
-                conf.LogFile.WriteString(fmt.Sprintf("cmd.go: Command goroutine finished for: %s\n", c))
-            }()
+// ****************************************************************************
+// screenCommand()
+// screenCommand opens the screen asked by a ! command, on the UI thread
+// ****************************************************************************
+func screenCommand(c string) bool {
+	switch screenKey(c) {
+	case "!quit", "!exit", "!bye":
+		ui.PgsApp.SwitchToPage("dlgQuit")
+	case "!shel":
+		// SwitchToShell()
+		ui.AddNewScreen(ui.ModeShell, nil, nil)
+	case "!file":
+		// SwitchToFiles()
+		ui.AddNewScreen(ui.ModeFiles, fm.SelfInit, nil)
+	case "!proc":
+		// SwitchToProcess()
+		ui.AddNewScreen(ui.ModeProcess, pm.SelfInit, CurrentUser)
+	case "!edit":
+		// SwitchToEditor()
+		ui.AddNewScreen(ui.ModeTextEdit, edit.SelfInit, nil)
+	case "!sql":
+		// SwitchToSQLite3()
+		ui.AddNewScreen(ui.ModeSQLite3, sq3.SelfInit, nil)
+	case "!help":
+		// SwitchToHelp()
+		ui.AddNewScreen(ui.ModeHelp, help.SelfInit, nil)
+	case "!hex":
+		// SwitchToHexEdit()
+		ui.AddNewScreen(ui.ModeHexEdit, hexedit.SelfInit, nil)
+	case "!ssh":
+		doSSH(c)
+	case "!audi":
+		// SwitchToAudit()
+		ui.AddNewScreen(ui.ModeAudit, audit.SelfInit, nil)
+	case "!sett":
+		// SwitchToSettings()
+		ui.AddNewScreen(ui.ModeSettings, settings.SelfInit, nil)
+	case "!them":
+		doTheme(c)
+	default:
+		return false
+	}
+	return true
+}
+
+// ****************************************************************************
+// updateUI()
+// updateUI runs f on the UI thread and waits for it, nothing is displayed in
+// batch mode
+// ****************************************************************************
+func updateUI(f func()) {
+	if Batch {
+		return
+	}
+	ui.App.QueueUpdateDraw(f)
+}
+
+// ****************************************************************************
+// runForeground()
+// runForeground runs a command line as a foreground job, its lists ended by
+// & are started as background jobs, h records the result in the history
+// ****************************************************************************
+func runForeground(c string, lists []*list, cols int, rows int, h *HistoryEntry) {
+	logger.Debug("cmd.go: Starting command goroutine for: %s", c)
+	j := newJob(c, false)
+	stdout := newConsoleWriter(false)
+	stderr := newConsoleWriter(true)
+	var p *ptyJob
+	rc, pid := 0, 0
+	start := time.Now()
+	for _, l := range lists {
+		if j.isStopped() {
+			break
+		}
+		if l.background {
+			bg := startJob(l)
+			fmt.Fprintf(stdout, "[%d] %s\n", bg.id, bg.cmdLine)
+			continue
+		}
+		// The commands run in a terminal, like in any other shell
+		if p == nil {
+			var err error
+			if p, err = startPty(c, cols, rows); err != nil {
+				logger.Warning("cmd.go: Can't open a terminal: %v", err)
+			}
 		}
+		var tty *os.File
+		if p != nil {
+			tty = p.tty
+		}
+		rc, pid = runList(j, l.pipes, stdout, stderr, tty)
+		lastRC.Store(int32(rc))
 	}
-	ui.TxtPrompt.SetText("", false)
+	elapsed := time.Since(start)
+	runtime := elapsed.Seconds()
+	stdout.Flush()
+	stderr.Flush()
+	output := ""
+	if p != nil {
+		output = p.close()
+	}
+	j.finish(rc)
+	finishHistory(h, rc, elapsed)
+	showResult(rc, pid, runtime, output)
+	logger.Debug("cmd.go: Command goroutine finished for: %s", c)
+}
+
+// ****************************************************************************
+// showResult()
+// showResult displays the end of a foreground job
+// ****************************************************************************
+func showResult(rc int, pid int, runtime float64, output string) {
+	// Job's done !
+	updateUI(func() {
+		ui.TxtPath.SetText(conf.Cwd)
+		if output != "" {
+			ui.OutConsole(output)
+		}
+		ui.EndBlock(rc, pid, runtime)
+		if rc != 0 {
+			ui.LblRC.SetText(fmt.Sprintf("[#FF0000]RC=%d", rc))
+		} else {
+			ui.LblRC.SetText(fmt.Sprintf("[#F5DEB3]RC=%d", rc))
+		}
+		ui.LblPID.SetText(fmt.Sprintf("%.2fs", runtime))
+		ui.JobsDone()
+		lastRuntime = runtime
+		ShowPrompt()
+	})
+}
+
+// ****************************************************************************
+// runList()
+// runList runs the pipelines in sequence according to the ; && || operators,
+// it returns the last return code and the PID of the last process started.
+// When tty is set, the commands use it instead of stdout and stderr.
+// ****************************************************************************
+func runList(j *job, pipes []*pipeline, stdout io.Writer, stderr io.Writer, tty *os.File) (int, int) {
+	rc := 0
+	lastPID := 0
+	for _, p := range pipes {
+		if j.isStopped() {
+			break
+		}
+		if p.op == TOKEN_AND && rc != 0 {
+			continue
+		}
+		if p.op == TOKEN_OR && rc == 0 {
+			continue
+		}
+		var pid int
+		rc, pid = runPipeline(j, p, stdout, stderr, tty)
+		j.setLastRC(rc)
+		if pid != 0 {
+			lastPID = pid
+		}
+	}
+	return rc, lastPID
+}
+
+// ****************************************************************************
+// runPipeline()
+// runPipeline starts all the stages of a pipeline, connected by pipes, in a
+// single process group and waits for them.
+// With a terminal, the last stage using it owns it in a new session, so that
+// it can read the keyboard, the other stages share another process group.
+// ****************************************************************************
+func runPipeline(j *job, p *pipeline, stdout io.Writer, stderr io.Writer, tty *os.File) (int, int) {
+	// A function or a builtin alone is run inside gosh itself
+	if len(p.commands) == 1 {
+		args := expandArgs(p.commands[0].args)
+		if firstArg(args) == "exit" {
+			return doExit(j, args, stderr), 0
+		}
+		if f := lookupFunction(firstArg(args)); f != nil {
+			var files []*os.File
+			defer func() { closeFiles(files) }()
+			s := streams{stdout: stdout, stderr: stderr}
+			if err := s.apply(p.commands[0].redirects, &files); err != nil {
+				fmt.Fprintf(stderr, "gosh: %s\n", err.Error())
+				return 1, 0
+			}
+			// The redirected output doesn't go to the terminal
+			if len(p.commands[0].redirects) > 0 {
+				tty = nil
+			}
+			return runFunction(j, f, args, s.stdout, s.stderr, tty), 0
+		}
+		// env followed by a command is the system one
+		if len(args) > 0 && !(args[0] == "env" && len(args) > 1) {
+			if fn, ok := builtins[args[0]]; ok {
+				var files []*os.File
+				defer func() { closeFiles(files) }()
+				s := streams{stdout: stdout, stderr: stderr}
+				if err := s.apply(p.commands[0].redirects, &files); err != nil {
+					fmt.Fprintf(stderr, "gosh: %s\n", err.Error())
+					return 1, 0
+				}
+				return fn(args, s.stdout, s.stderr), 0
+			}
+		}
+	}
+
+	var stages []*streams
+	var stagesArgs [][]string
+	var files []*os.File
+	var stdin io.Reader
+	rc := 0
+	for i, c := range p.commands {
+		s := &streams{stdin: stdin, stdout: stdout, stderr: stderr}
+		if Batch && i == 0 && !j.isBackground() {
+			s.stdin = os.Stdin
+		}
+		if tty != nil {
+			if i == 0 {
+				s.stdin = tty
+			}
+			s.stdout = tty
+			s.stderr = tty
+		}
+		stdin = nil
+		if i < len(p.commands)-1 {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				fmt.Fprintf(stderr, "gosh: %s\n", err.Error())
+				rc = 1
+				break
+			}
+			files = append(files, pr, pw)
+			s.stdout = pw
+			stdin = pr
+		}
+		if err := s.apply(c.redirects, &files); err != nil {
+			fmt.Fprintf(s.stderr, "gosh: %s\n", err.Error())
+			rc = 1
+			stages = append(stages, nil)
+			stagesArgs = append(stagesArgs, nil)
+			continue
+		}
+		args := expandArgs(c.args)
+		if lookupFunction(firstArg(args)) != nil {
+			fmt.Fprintf(s.stderr, "gosh: %s: a function can't be used in a pipeline\n", args[0])
+			rc = 1
+			stages = append(stages, nil)
+			stagesArgs = append(stagesArgs, nil)
+			continue
+		}
+		stages = append(stages, s)
+		stagesArgs = append(stagesArgs, args)
+	}
+
+	// Which stage gets the terminal as its controlling terminal ?
+	owner := -1
+	if tty != nil {
+		for i, s := range stages {
+			if s != nil && len(stagesArgs[i]) > 0 && (s.stdin == tty || s.stdout == tty || s.stderr == tty) {
+				owner = i
+			}
+		}
+	}
+
+	var procs []*exec.Cmd
+	var last *exec.Cmd
+	var pgids []int
+	pgid := 0
+	lastPID := 0
+	for i, s := range stages {
+		args := stagesArgs[i]
+		if s == nil || len(args) == 0 {
+			// Only redirections, the files have been created
+			continue
+		}
+		xCmd := exec.Command(args[0], args[1:]...)
+		xCmd.Dir = conf.Cwd
+		xCmd.Env = environ()
+		xCmd.Stdin = s.stdin
+		xCmd.Stdout = s.stdout
+		xCmd.Stderr = s.stderr
+		if i == owner {
+			xCmd.Env = append(xCmd.Env, "TERM="+PTY_TERM)
+			xCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: s.ttyFd(tty)}
+		} else if !Batch {
+			xCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: pgid}
+		}
+		if err := xCmd.Start(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				fmt.Fprintf(s.stderr, "gosh: %s: command not found\n", args[0])
+				rc = 127
+			} else {
+				fmt.Fprintf(s.stderr, "gosh: %s\n", err.Error())
+				rc = 126
+			}
+			continue
+		}
+		if i == owner {
+			pgids = append(pgids, xCmd.Process.Pid)
+		} else if pgid == 0 {
+			pgid = xCmd.Process.Pid
+			pgids = append(pgids, pgid)
+		}
+		lastPID = xCmd.Process.Pid
+		j.setPGIDs(pgids, lastPID)
+		if !j.isBackground() {
+			pid := lastPID
+			updateUI(func() {
+				ui.LblPID.SetText(fmt.Sprintf("PID=%d", pid))
+			})
+		}
+		if i == len(p.commands)-1 {
+			last = xCmd
+		}
+		logger.Debug("cmd.go: Command started, PID: %d, PGID: %v", lastPID, pgids)
+		procs = append(procs, xCmd)
+	}
+	// The children own their copies of the pipes now
+	closeFiles(files)
+
+	for _, xCmd := range procs {
+		err := xCmd.Wait()
+		if xCmd == last {
+			rc = exitCode(err)
+		}
+	}
+	j.setPGIDs(nil, 0)
+	return rc, lastPID
+}
+
+// ****************************************************************************
+// ttyFd()
+// ttyFd returns the standard file descriptor of a command using the terminal
+// ****************************************************************************
+func (s *streams) ttyFd(tty *os.File) int {
+	switch {
+	case s.stdin == tty:
+		return 0
+	case s.stdout == tty:
+		return 1
+	}
+	return 2
+}
+
+// ****************************************************************************
+// apply()
+// apply sets up the redirections of a command on its standard streams
+// ****************************************************************************
+func (s *streams) apply(redirects []redirect, files *[]*os.File) error {
+	for _, r := range redirects {
+		if r.kind == TOKEN_REDIR_DUP {
+			var w io.Writer
+			switch r.dupFd {
+			case 1:
+				w = s.stdout
+			case 2:
+				w = s.stderr
+			default:
+				return fmt.Errorf("%d: bad file descriptor", r.dupFd)
+			}
+			switch r.fd {
+			case -1, 1:
+				s.stdout = w
+			case 2:
+				s.stderr = w
+			default:
+				return fmt.Errorf("%d: bad file descriptor", r.fd)
+			}
+			continue
+		}
+
+		fName, err := expandTarget(r.target)
+		if err != nil {
+			return err
+		}
+		var f *os.File
+		switch r.kind {
+		case TOKEN_REDIR_IN:
+			f, err = os.Open(fName)
+		case TOKEN_REDIR_OUT, TOKEN_REDIR_ALL:
+			f, err = os.OpenFile(fName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		case TOKEN_REDIR_APPEND, TOKEN_REDIR_ALL_APPEND:
+			f, err = os.OpenFile(fName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		}
+		if err != nil {
+			return err
+		}
+		*files = append(*files, f)
+
+		switch r.kind {
+		case TOKEN_REDIR_IN:
+			if r.fd != -1 && r.fd != 0 {
+				return fmt.Errorf("%d: bad file descriptor", r.fd)
+			}
+			s.stdin = f
+		case TOKEN_REDIR_ALL, TOKEN_REDIR_ALL_APPEND:
+			s.stdout = f
+			s.stderr = f
+		default:
+			switch r.fd {
+			case -1, 1:
+				s.stdout = f
+			case 2:
+				s.stderr = f
+			default:
+				return fmt.Errorf("%d: bad file descriptor", r.fd)
+			}
+		}
+	}
+	return nil
+}
+
+// ****************************************************************************
+// closeFiles()
+// ****************************************************************************
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// ****************************************************************************
+// firstArg()
+// ****************************************************************************
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// ****************************************************************************
+// exitCode()
+// exitCode converts the result of a command into a shell return code
+// ****************************************************************************
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitError.ExitCode()
+	}
+	return 1
 }
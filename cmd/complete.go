@@ -0,0 +1,307 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// complete finds the candidates for the completion of the word typed in the
+// prompt of the shell
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/conf"
+	"gosh/theme"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	ESCAPED_CHARS = " \t'\"\\|;&<>$*?[#"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	screenCommands = []string{"!audit", "!bye", "!edit", "!exit", "!files", "!help", "!hex", "!proc", "!quit", "!settings", "!shell", "!source", "!sql", "!ssh", "!theme"}
+)
+
+// ****************************************************************************
+// Complete()
+// Complete returns the candidates for the word before the cursor, each one
+// replacing line[start:cursor]. The folders end with a /
+// ****************************************************************************
+func Complete(line string, cursor int) (start int, candidates []string) {
+	line = line[:cursor]
+	start, text, command := currentWord(line)
+	raw := line[start:]
+	switch {
+	case command && strings.HasPrefix(raw, "!") && strings.TrimSpace(line[:start]) == "":
+		candidates = matchPrefix(screenCommands, raw)
+	case strings.TrimSpace(line[:start]) == "!theme":
+		candidates = matchPrefix(theme.Names(), raw)
+	case strings.HasPrefix(raw, "$") && (len(raw) == 1 || isName(raw[1:])):
+		candidates = completeVars(raw[1:])
+	case command && !strings.Contains(text, "/"):
+		candidates = completeCommands(text)
+	default:
+		candidates = completePaths(text)
+	}
+	return start, uniqueSorted(candidates)
+}
+
+// ****************************************************************************
+// currentWord()
+// currentWord returns the position and the unquoted text of the last word of
+// line, and tells if this word is a command name
+// ****************************************************************************
+func currentWord(line string) (start int, text string, command bool) {
+	var b strings.Builder
+	start = -1
+	expectCmd := true
+	redirect := false
+	quote := byte(0)
+	beginWord := func(i int) {
+		if start < 0 {
+			start = i
+			command = expectCmd && !redirect
+		}
+	}
+	endWord := func() {
+		if start >= 0 {
+			if !redirect {
+				expectCmd = false
+			}
+			redirect = false
+			start = -1
+			b.Reset()
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			switch {
+			case c == quote:
+				quote = 0
+			case quote == '"' && c == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0:
+				i++
+				b.WriteByte(line[i])
+			default:
+				b.WriteByte(c)
+			}
+			continue
+		}
+		switch {
+		case c == '\\':
+			beginWord(i)
+			if i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+			}
+		case c == '\'' || c == '"':
+			beginWord(i)
+			quote = c
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '&' && ((i+1 < len(line) && line[i+1] == '>') || (i > 0 && line[i-1] == '>')):
+			// &> and >& are redirections
+			endWord()
+			redirect = true
+		case c == '|' || c == ';' || c == '&':
+			endWord()
+			expectCmd = true
+			redirect = false
+		case c == '<' || c == '>':
+			endWord()
+			redirect = true
+		default:
+			beginWord(i)
+			b.WriteByte(c)
+		}
+	}
+	if start < 0 {
+		return len(line), "", expectCmd && !redirect
+	}
+	return start, b.String(), command
+}
+
+// ****************************************************************************
+// completeCommands()
+// ****************************************************************************
+func completeCommands(prefix string) []string {
+	var candidates []string
+	for name := range builtins {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, escapeWord(name))
+		}
+	}
+	defsMutex.Lock()
+	for name := range aliases {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, escapeWord(name))
+		}
+	}
+	for name := range functions {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, escapeWord(name))
+		}
+	}
+	defsMutex.Unlock()
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), prefix) || entry.IsDir() {
+				continue
+			}
+			// Follow the links to check that the target is an executable
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+				candidates = append(candidates, escapeWord(entry.Name()))
+			}
+		}
+	}
+	return candidates
+}
+
+// ****************************************************************************
+// completePaths()
+// completePaths returns the files and folders matching text, relative to the
+// current folder
+// ****************************************************************************
+func completePaths(text string) []string {
+	dir, base := "", text
+	if i := strings.LastIndex(text, "/"); i >= 0 {
+		dir, base = text[:i+1], text[i+1:]
+	}
+	folder := completionFolder(dir)
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+	// The ~ is kept as typed, it is expanded when the command runs
+	prefix := escapeWord(dir)
+	if strings.HasPrefix(dir, "~") {
+		home, rest, _ := strings.Cut(dir, "/")
+		prefix = home + "/" + escapeWord(rest)
+	}
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := prefix + escapeWord(name)
+		if info, err := os.Stat(filepath.Join(folder, name)); err == nil && info.IsDir() {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// ****************************************************************************
+// completionFolder()
+// completionFolder returns the folder to read for the folder part of a path
+// ****************************************************************************
+func completionFolder(dir string) string {
+	if strings.HasPrefix(dir, "~") {
+		name, rest, _ := strings.Cut(dir[1:], "/")
+		var home string
+		if name == "" {
+			home, _ = os.UserHomeDir()
+		} else if u, err := user.Lookup(name); err == nil {
+			home = u.HomeDir
+		}
+		if home != "" {
+			dir = filepath.Join(home, rest)
+		}
+	}
+	if dir == "" {
+		return conf.Cwd
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(conf.Cwd, dir)
+	}
+	return dir
+}
+
+// ****************************************************************************
+// completeVars()
+// ****************************************************************************
+func completeVars(prefix string) []string {
+	var candidates []string
+	for _, v := range environ() {
+		name, _, _ := strings.Cut(v, "=")
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, "$"+name)
+		}
+	}
+	return candidates
+}
+
+// ****************************************************************************
+// escapeWord()
+// escapeWord protects the special characters of s with backslashes
+// ****************************************************************************
+func escapeWord(s string) string {
+	if !strings.ContainsAny(s, ESCAPED_CHARS) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(ESCAPED_CHARS, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ****************************************************************************
+// matchPrefix()
+// ****************************************************************************
+func matchPrefix(words []string, prefix string) []string {
+	var matches []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
+
+// ****************************************************************************
+// uniqueSorted()
+// ****************************************************************************
+func uniqueSorted(words []string) []string {
+	sort.Strings(words)
+	var unique []string
+	for i, w := range words {
+		if i == 0 || w != words[i-1] {
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
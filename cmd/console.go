@@ -0,0 +1,208 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// console is the context menu of the console, sending the output of the
+// current block to the other screens
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"gosh/dialog"
+	"gosh/edit"
+	"gosh/fm"
+	"gosh/menu"
+	"gosh/sq3"
+	"gosh/ui"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	MnuConsole   *menu.Menu
+	DlgSaveBlock *dialog.Dialog
+	DlgImport    *dialog.Dialog
+	importFormat sq3.ImportFormat
+)
+
+// ****************************************************************************
+// ShowConsoleMenu()
+// ShowConsoleMenu opens the actions on the output of the current block
+// ****************************************************************************
+func ShowConsoleMenu() {
+	b := ui.CurrentBlock()
+	hasOutput := b != nil && strings.TrimSpace(b.Text()) != ""
+	MnuConsole = MnuConsole.New("Output", ui.GetCurrentScreen(), ui.TxtConsole)
+	MnuConsole.AddItem("mnuEdit", "Open in the Editor", DoEditOutput, nil, hasOutput, false)
+	MnuConsole.AddItem("mnuImportColumns", "Import as table (columns)", DoImportOutput, sq3.IMPORT_COLUMNS, hasOutput, false)
+	MnuConsole.AddItem("mnuImportCSV", "Import as table (CSV)", DoImportOutput, sq3.IMPORT_CSV, hasOutput, false)
+	MnuConsole.AddItem("mnuImportJSON", "Import as table (JSON)", DoImportOutput, sq3.IMPORT_JSON, hasOutput, false)
+	MnuConsole.AddItem("mnuSelectPaths", "Select in the File Manager", DoSelectOutput, nil, hasOutput && outputPaths(b.Text()) != nil, false)
+	MnuConsole.AddSeparator()
+	MnuConsole.AddItem("mnuCopy", "Copy to the clipboard", func(p any) { ui.CopyBlock() }, nil, hasOutput, false)
+	MnuConsole.AddItem("mnuSave", "Save to a file", SaveConsoleBlock, nil, hasOutput, false)
+	MnuConsole.AddItem("mnuFold", "Fold / Unfold", func(p any) { ui.ToggleBlock() }, nil, b != nil, false)
+	ui.PgsApp.AddPage("dlgConsoleAction", MnuConsole.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConsoleAction")
+}
+
+// ****************************************************************************
+// DoEditOutput()
+// DoEditOutput opens the output of the current block in a temporary file of
+// the Editor
+// ****************************************************************************
+func DoEditOutput(p any) {
+	edit.OpenText(ui.CurrentBlock().Text())
+}
+
+// ****************************************************************************
+// DoImportOutput()
+// DoImportOutput asks for the name of the table where the output of the
+// current block is imported
+// ****************************************************************************
+func DoImportOutput(p any) {
+	importFormat = p.(sq3.ImportFormat)
+	DlgImport = DlgImport.Input("Import into SQLite3", // Title
+		fmt.Sprintf("Please, enter the name of the table in %s :", sq3.CurrentDatabaseName), // Message
+		sq3.FreeTableName("output"),
+		importOutput,
+		0,
+		ui.GetCurrentScreen(), ui.TxtConsole) // Focus return
+	ui.PgsApp.AddPage("dlgImportOutput", DlgImport.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgImportOutput")
+}
+
+// ****************************************************************************
+// importOutput()
+// ****************************************************************************
+func importOutput(button dialog.DlgButton, idx int) {
+	if button == dialog.BUTTON_OK {
+		name := strings.TrimSpace(DlgImport.Value)
+		if name == "" {
+			ui.SetStatus("No table name")
+			return
+		}
+		n, err := sq3.ImportTable(name, ui.CurrentBlock().Text(), importFormat)
+		if err != nil {
+			ui.SetStatus(err.Error())
+			return
+		}
+		sq3.ShowTable(name)
+		ui.SetStatus(fmt.Sprintf("%d rows imported into %s", n, name))
+	}
+}
+
+// ****************************************************************************
+// DoSelectOutput()
+// DoSelectOutput shows the paths written by the command as the selection of
+// a File Manager
+// ****************************************************************************
+func DoSelectOutput(p any) {
+	paths := outputPaths(ui.CurrentBlock().Text())
+	ui.AddNewScreen(ui.ModeFiles, fm.SelfInit, nil)
+	n := fm.SetSelection(paths)
+	ui.SetStatus(fmt.Sprintf("%d files and folders selected", n))
+}
+
+// ****************************************************************************
+// outputPaths()
+// outputPaths returns the absolute paths when each line of text is the path
+// of an existing file or folder, nil otherwise
+// ****************************************************************************
+func outputPaths(text string) []string {
+	var paths []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if home, err := os.UserHomeDir(); err == nil && (line == "~" || strings.HasPrefix(line, "~/")) {
+			line = home + line[1:]
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(conf.Cwd, line)
+		}
+		if _, err := os.Lstat(line); err != nil {
+			return nil
+		}
+		paths = append(paths, filepath.Clean(line))
+	}
+	return paths
+}
+
+// ****************************************************************************
+// SaveConsoleBlock()
+// SaveConsoleBlock asks for the file where the output of the current block of
+// the console is saved
+// ****************************************************************************
+func SaveConsoleBlock(p any) {
+	if ui.CurrentBlock() == nil {
+		ui.SetStatus("Nothing to save")
+		return
+	}
+	DlgSaveBlock = DlgSaveBlock.Input("Save Output", // Title
+		"Please, enter the name of the file :", // Message
+		"output.txt",
+		saveBlock,
+		0,
+		ui.GetCurrentScreen(), ui.TxtConsole) // Focus return
+	ui.PgsApp.AddPage("dlgSaveBlock", DlgSaveBlock.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgSaveBlock")
+}
+
+// ****************************************************************************
+// saveBlock()
+// ****************************************************************************
+func saveBlock(button dialog.DlgButton, idx int) {
+	if button == dialog.BUTTON_OK {
+		fName := DlgSaveBlock.Value
+		if !filepath.IsAbs(fName) {
+			fName = filepath.Join(conf.Cwd, fName)
+		}
+		if _, err := os.Lstat(fName); err != nil {
+			writeBlock(fName)
+			return
+		}
+		DlgSaveBlock = DlgSaveBlock.YesNo("Conflict", // Title
+			fmt.Sprintf("%s already exists, overwrite it ?", fName), // Message
+			func(button dialog.DlgButton, idx int) {
+				if button == dialog.BUTTON_YES {
+					writeBlock(fName)
+				} else {
+					ui.SetStatus("Cancelling saving of the output")
+				}
+			},
+			0,
+			ui.GetCurrentScreen(), ui.TxtConsole) // Focus return
+		ui.PgsApp.AddPage("dlgConfirmSaveBlock", DlgSaveBlock.Popup(), true, false)
+		ui.PgsApp.ShowPage("dlgConfirmSaveBlock")
+	}
+}
+
+// ****************************************************************************
+// writeBlock()
+// ****************************************************************************
+func writeBlock(fName string) {
+	if err := ui.SaveBlock(fName); err != nil {
+		ui.SetStatus(err.Error())
+	} else {
+		ui.SetStatus(fmt.Sprintf("Output saved to %s", fName))
+	}
+}
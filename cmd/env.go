@@ -0,0 +1,307 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// env manages the environment of the session and the expansion of variables
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type segment struct {
+	text     string
+	expanded bool // Result of a variable expansion, subject to field splitting
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	envMutex    sync.Mutex
+	sessionVars = map[string]string{} // Variables exported from the prompt
+	lastRC      atomic.Int32          // $? of the commands run in the foreground
+)
+
+// ****************************************************************************
+// Getenv()
+// ****************************************************************************
+func Getenv(name string) (string, bool) {
+	if name == "PWD" {
+		return conf.Cwd, true
+	}
+	return os.LookupEnv(name)
+}
+
+// ****************************************************************************
+// Setenv()
+// Setenv sets a variable of the session, it will be passed to every command
+// ****************************************************************************
+func Setenv(name string, value string) {
+	envMutex.Lock()
+	defer envMutex.Unlock()
+	os.Setenv(name, value)
+	sessionVars[name] = value
+}
+
+// ****************************************************************************
+// Unsetenv()
+// ****************************************************************************
+func Unsetenv(name string) {
+	envMutex.Lock()
+	defer envMutex.Unlock()
+	os.Unsetenv(name)
+	delete(sessionVars, name)
+}
+
+// ****************************************************************************
+// SessionVars()
+// SessionVars returns a copy of the variables exported from the prompt
+// ****************************************************************************
+func SessionVars() map[string]string {
+	envMutex.Lock()
+	defer envMutex.Unlock()
+	vars := make(map[string]string, len(sessionVars))
+	for name, value := range sessionVars {
+		vars[name] = value
+	}
+	return vars
+}
+
+// ****************************************************************************
+// environ()
+// environ returns the sorted environment to pass to the commands
+// ****************************************************************************
+func environ() []string {
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "PWD=") {
+			env = append(env, v)
+		}
+	}
+	env = append(env, "PWD="+conf.Cwd)
+	sort.Strings(env)
+	return env
+}
+
+// ****************************************************************************
+// isName()
+// isName checks if s is a valid variable name
+// ****************************************************************************
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isNameChar(s[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// ****************************************************************************
+// isNameChar()
+// ****************************************************************************
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// ****************************************************************************
+// expandVars()
+// expandVars replaces the $NAME, ${NAME} and ${NAME:-default} references
+// ****************************************************************************
+func expandVars(s string) []segment {
+	var segs []segment
+	var lit strings.Builder
+	flushLiteral := func() {
+		if lit.Len() > 0 {
+			segs = append(segs, segment{text: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			lit.WriteByte(s[i])
+			continue
+		}
+		var value string
+		next := s[i+1]
+		switch {
+		case next == '?':
+			value = strconv.Itoa(int(lastRC.Load()))
+			i++
+		case next == '$':
+			value = strconv.Itoa(os.Getpid())
+			i++
+		case next == '{':
+			end := closingBrace(s[i:])
+			if end < 0 {
+				lit.WriteByte(s[i])
+				continue
+			}
+			value = expandBraces(s[i+2 : i+end])
+			i += end
+		case isNameChar(next, true):
+			j := i + 2
+			for j < len(s) && isNameChar(s[j], false) {
+				j++
+			}
+			value, _ = Getenv(s[i+1 : j])
+			i = j - 1
+		default:
+			lit.WriteByte(s[i])
+			continue
+		}
+		flushLiteral()
+		segs = append(segs, segment{text: value, expanded: true})
+	}
+	flushLiteral()
+	return segs
+}
+
+// ****************************************************************************
+// closingBrace()
+// closingBrace returns the index of the } ending the ${ starting s
+// ****************************************************************************
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ****************************************************************************
+// expandBraces()
+// expandBraces returns the value of the content of ${...}
+// ****************************************************************************
+func expandBraces(s string) string {
+	name := s
+	op := ""
+	def := ""
+	if i := strings.IndexAny(s, ":-"); i > 0 {
+		name = s[:i]
+		if strings.HasPrefix(s[i:], ":-") {
+			op, def = ":-", s[i+2:]
+		} else if s[i] == '-' {
+			op, def = "-", s[i+1:]
+		}
+	}
+	var value string
+	var set bool
+	if name == "?" {
+		value, set = strconv.Itoa(int(lastRC.Load())), true
+	} else {
+		value, set = Getenv(name)
+	}
+	if (op == ":-" && value == "") || (op == "-" && !set) {
+		var b strings.Builder
+		for _, seg := range expandVars(def) {
+			b.WriteString(seg.text)
+		}
+		return b.String()
+	}
+	return value
+}
+
+// ****************************************************************************
+// doExport()
+// ****************************************************************************
+func doExport(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 1 || (len(args) == 2 && args[1] == "-p") {
+		for _, v := range environ() {
+			name, value, _ := strings.Cut(v, "=")
+			fmt.Fprintf(stdout, "export %s=%s\n", name, strconv.Quote(value))
+		}
+		return 0
+	}
+	rc := 0
+	for _, arg := range args[1:] {
+		name, value, found := strings.Cut(arg, "=")
+		if !isName(name) {
+			fmt.Fprintf(stderr, "gosh: export: `%s': not a valid identifier\n", arg)
+			rc = 1
+			continue
+		}
+		if !found {
+			value, _ = Getenv(name)
+		}
+		Setenv(name, value)
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doUnset()
+// ****************************************************************************
+func doUnset(args []string, stdout io.Writer, stderr io.Writer) int {
+	rc := 0
+	names := args[1:]
+	if len(names) > 0 && names[0] == "-f" {
+		// Remove functions
+		defsMutex.Lock()
+		defer defsMutex.Unlock()
+		for _, name := range names[1:] {
+			delete(functions, name)
+		}
+		return 0
+	}
+	if len(names) > 0 && names[0] == "-v" {
+		names = names[1:]
+	}
+	for _, name := range names {
+		if !isName(name) {
+			fmt.Fprintf(stderr, "gosh: unset: `%s': not a valid identifier\n", name)
+			rc = 1
+			continue
+		}
+		Unsetenv(name)
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doEnv()
+// ****************************************************************************
+func doEnv(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 1 {
+		for _, v := range environ() {
+			fmt.Fprintln(stdout, v)
+		}
+		return 0
+	}
+	fmt.Fprintln(stderr, "gosh: env: too many arguments")
+	return 1
+}
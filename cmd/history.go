@@ -0,0 +1,503 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// history keeps the commands typed in the prompt. The history file holds one
+// JSON entry per line, appended under a lock when the command ends, so that
+// several sessions can share it
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"gosh/logger"
+	"gosh/theme"
+	"gosh/ui"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type HistoryEntry struct {
+	Time     time.Time     `json:"time"`
+	Cwd      string        `json:"cwd"`
+	Cmd      string        `json:"cmd"`
+	RC       int           `json:"rc"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	historyMutex sync.Mutex
+	history      []*HistoryEntry // Oldest first, without duplicates
+	historyIdx   int             // Position of the Up and Down keys
+	historyFile  string
+	historyMax   = conf.HISTORY_MAX_SIZE
+)
+
+// ****************************************************************************
+// SetHistorySize()
+// SetHistorySize sets the number of entries kept, applied when the history
+// is saved
+// ****************************************************************************
+func SetHistorySize(max int) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	if max > 0 {
+		historyMax = max
+	}
+}
+
+// ****************************************************************************
+// LoadHistory()
+// LoadHistory reads the history file, trimmed to max entries
+// ****************************************************************************
+func LoadHistory(fName string, max int) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	historyFile = fName
+	if max > 0 {
+		historyMax = max
+	}
+	entries, err := compactHistoryFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("history.go: %v", err)
+	}
+	history = entries
+	historyIdx = len(history)
+}
+
+// ****************************************************************************
+// SaveHistory()
+// SaveHistory trims the history file, the entries are already written
+// ****************************************************************************
+func SaveHistory() {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	if _, err := compactHistoryFile(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("history.go: %v", err)
+	}
+}
+
+// ****************************************************************************
+// compactHistoryFile()
+// compactHistoryFile rewrites the history file without the duplicates and
+// the oldest entries, and returns its content. The file is rewritten in place
+// under the lock, the other sessions open it again for each entry they append
+// ****************************************************************************
+func compactHistoryFile() ([]*HistoryEntry, error) {
+	if historyFile == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(historyFile, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	var entries []*HistoryEntry
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines++
+		if e := parseHistoryLine(scanner.Text()); e != nil {
+			entries = appendHistory(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) > historyMax {
+		entries = entries[len(entries)-historyMax:]
+	}
+	if lines == len(entries) {
+		return entries, nil
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		b, _ := json.Marshal(e)
+		buf.Write(append(b, '\n'))
+	}
+	if err := f.Truncate(0); err != nil {
+		return entries, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return entries, err
+	}
+	_, err = f.Write(buf.Bytes())
+	return entries, err
+}
+
+// ****************************************************************************
+// parseHistoryLine()
+// parseHistoryLine reads an entry of the history file, the lines of the
+// previous versions only hold the command
+// ****************************************************************************
+func parseHistoryLine(line string) *HistoryEntry {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if !strings.HasPrefix(line, "{") {
+		return &HistoryEntry{Cmd: line}
+	}
+	var e HistoryEntry
+	// A line cut by a crash is ignored
+	if err := json.Unmarshal([]byte(line), &e); err != nil || e.Cmd == "" {
+		return nil
+	}
+	return &e
+}
+
+// ****************************************************************************
+// appendHistory()
+// appendHistory adds e at the end of entries, removing the older duplicate
+// ****************************************************************************
+func appendHistory(entries []*HistoryEntry, e *HistoryEntry) []*HistoryEntry {
+	for i, old := range entries {
+		if old.Cmd == e.Cmd {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	return append(entries, e)
+}
+
+// ****************************************************************************
+// addHistory()
+// addHistory records a command when it starts
+// ****************************************************************************
+func addHistory(c string) *HistoryEntry {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	e := &HistoryEntry{Time: time.Now(), Cwd: conf.Cwd, Cmd: c}
+	history = appendHistory(history, e)
+	if len(history) > historyMax {
+		history = history[len(history)-historyMax:]
+	}
+	historyIdx = len(history)
+	return e
+}
+
+// ****************************************************************************
+// finishHistory()
+// finishHistory records the result of a command and writes it to the file
+// ****************************************************************************
+func finishHistory(e *HistoryEntry, rc int, duration time.Duration) {
+	historyMutex.Lock()
+	e.RC = rc
+	e.Duration = duration
+	b, _ := json.Marshal(e)
+	historyMutex.Unlock()
+	if historyFile == "" {
+		return
+	}
+	if err := appendHistoryFile(append(b, '\n')); err != nil {
+		logger.Error("history.go: %v", err)
+	}
+}
+
+// ****************************************************************************
+// appendHistoryFile()
+// appendHistoryFile writes a line at the end of the history file in one call
+// ****************************************************************************
+func appendHistoryFile(line []byte) error {
+	f, err := os.OpenFile(historyFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	_, err = f.Write(line)
+	return err
+}
+
+// ****************************************************************************
+// PreviousCommand()
+// PreviousCommand returns the command before the one displayed by the Up
+// and Down keys
+// ****************************************************************************
+func PreviousCommand() (string, bool) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	if historyIdx <= 0 || len(history) == 0 {
+		return "", false
+	}
+	if historyIdx > len(history) {
+		historyIdx = len(history)
+	}
+	historyIdx--
+	return history[historyIdx].Cmd, true
+}
+
+// ****************************************************************************
+// NextCommand()
+// NextCommand returns the command after the one displayed, or an empty
+// string after the last one
+// ****************************************************************************
+func NextCommand() (string, bool) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	if historyIdx >= len(history) {
+		return "", false
+	}
+	historyIdx++
+	if historyIdx == len(history) {
+		return "", true
+	}
+	return history[historyIdx].Cmd, true
+}
+
+// ****************************************************************************
+// expandHistory()
+// expandHistory replaces !! by the last command, !n by the command number n
+// and !-n by the nth previous command
+// ****************************************************************************
+func expandHistory(line string) (string, error) {
+	if !strings.Contains(line, "!") {
+		return line, nil
+	}
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	var b strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\' && i+1 < len(line):
+			b.WriteByte(c)
+			i++
+			c = line[i]
+		case c == '\'' && quote == 0:
+			quote = c
+		case c == '"':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '!' && i+1 < len(line):
+			j := i + 1
+			n := 0
+			switch {
+			case line[j] == '!':
+				j++
+				n = len(history)
+			case line[j] == '-' || (line[j] >= '0' && line[j] <= '9'):
+				k := j
+				if line[k] == '-' {
+					k++
+				}
+				for k < len(line) && line[k] >= '0' && line[k] <= '9' {
+					k++
+				}
+				number, err := strconv.Atoi(line[j:k])
+				if err != nil {
+					b.WriteByte(c)
+					continue
+				}
+				j = k
+				n = number
+				if n < 0 {
+					n = len(history) + 1 + n
+				}
+			default:
+				b.WriteByte(c)
+				continue
+			}
+			if n < 1 || n > len(history) {
+				return "", fmt.Errorf("%s: event not found", line[i:j])
+			}
+			b.WriteString(history[n-1].Cmd)
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// ****************************************************************************
+// doHistory()
+// ****************************************************************************
+func doHistory(args []string, stdout io.Writer, stderr io.Writer) int {
+	verbose := false
+	count := -1
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "-c":
+			historyMutex.Lock()
+			history = nil
+			historyIdx = 0
+			historyMutex.Unlock()
+			if historyFile != "" {
+				if err := os.Truncate(historyFile, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+					fmt.Fprintf(stderr, "gosh: history: %s\n", err.Error())
+					return 1
+				}
+			}
+			return 0
+		case arg == "-v":
+			verbose = true
+		case isDigits(arg):
+			count, _ = strconv.Atoi(arg)
+		default:
+			fmt.Fprintf(stderr, "gosh: history: %s: invalid option\n", arg)
+			fmt.Fprintln(stderr, "history: usage: history [-c] [-v] [n]")
+			return 1
+		}
+	}
+	historyMutex.Lock()
+	entries := make([]HistoryEntry, len(history))
+	for i, e := range history {
+		entries[i] = *e
+	}
+	historyMutex.Unlock()
+	first := 0
+	if count >= 0 && count < len(entries) {
+		first = len(entries) - count
+	}
+	for i := first; i < len(entries); i++ {
+		e := entries[i]
+		when := strings.Repeat(" ", len(ui.MyConfig.FormatDate+ui.MyConfig.FormatTime)+1)
+		result := strings.Repeat(" ", 14)
+		if !e.Time.IsZero() {
+			when = e.Time.Format(ui.MyConfig.FormatDate + " " + ui.MyConfig.FormatTime)
+			result = fmt.Sprintf("%3d %9.2fs", e.RC, e.Duration.Seconds())
+		}
+		if verbose {
+			fmt.Fprintf(stdout, "%5d  %s  %s  %s  %s\n", i+1, when, result, tildeName(e.Cwd), e.Cmd)
+		} else {
+			fmt.Fprintf(stdout, "%5d  %s  %s  %s\n", i+1, when, result, e.Cmd)
+		}
+	}
+	return 0
+}
+
+// ****************************************************************************
+// ShowHistorySearch()
+// ShowHistorySearch opens a popup searching the history as the text is typed,
+// the command chosen is copied into the prompt
+// ****************************************************************************
+func ShowHistorySearch() {
+	historyMutex.Lock()
+	commands := make([]string, len(history))
+	for i, e := range history {
+		commands[i] = e.Cmd
+	}
+	historyMutex.Unlock()
+
+	input := tview.NewInputField().
+		SetLabel("(reverse-i-search) ").
+		SetLabelColor(theme.SecondaryText).
+		SetFieldBackgroundColor(theme.Background).
+		SetFieldTextColor(theme.Text)
+	input.SetBackgroundColor(theme.Menu)
+	lst := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	lst.SetBackgroundColor(theme.Menu)
+	frame := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(lst, 0, 1, false)
+	frame.SetBorder(true)
+	frame.SetBackgroundColor(theme.Menu)
+
+	var matches []string
+	refresh := func(text string) {
+		lst.Clear()
+		matches = nil
+		text = strings.ToLower(text)
+		// Most recent first
+		for i := len(commands) - 1; i >= 0; i-- {
+			if strings.Contains(strings.ToLower(commands[i]), text) {
+				matches = append(matches, commands[i])
+				lst.AddItem(fmt.Sprintf("[gray]%5d[-]  %s", i+1, tview.Escape(commands[i])), "", 0, nil)
+			}
+		}
+		frame.SetTitle(fmt.Sprintf(" History (%d) ", len(matches)))
+	}
+	closePopup := func() {
+		ui.PgsApp.RemovePage("dlgHistory")
+		ui.App.SetFocus(ui.TxtPrompt)
+	}
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlR, tcell.KeyDown:
+			if lst.GetCurrentItem() < lst.GetItemCount()-1 {
+				lst.SetCurrentItem(lst.GetCurrentItem() + 1)
+			}
+			return nil
+		case tcell.KeyUp:
+			if lst.GetCurrentItem() > 0 {
+				lst.SetCurrentItem(lst.GetCurrentItem() - 1)
+			}
+			return nil
+		case tcell.KeyPgDn, tcell.KeyPgUp:
+			lst.InputHandler()(event, nil)
+			return nil
+		case tcell.KeyEnter:
+			if len(matches) > 0 {
+				ui.TxtPrompt.SetText(matches[lst.GetCurrentItem()], true)
+			}
+			closePopup()
+			return nil
+		case tcell.KeyEsc:
+			closePopup()
+			return nil
+		}
+		return event
+	})
+	input.SetText(ui.TxtPrompt.GetText())
+	refresh(input.GetText())
+	input.SetChangedFunc(refresh)
+
+	popup := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(frame, 0, 3, true).
+			AddItem(nil, 0, 1, false), 0, 3, true).
+		AddItem(nil, 0, 1, false)
+	ui.PgsApp.AddPage("dlgHistory", popup, true, true)
+	ui.App.SetFocus(input)
+}
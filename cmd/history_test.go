@@ -0,0 +1,59 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"testing"
+)
+
+// ****************************************************************************
+// TestExpandHistory()
+// ****************************************************************************
+func TestExpandHistory(t *testing.T) {
+	saved := history
+	defer func() { history = saved }()
+	history = []*HistoryEntry{{Cmd: "ls -l"}, {Cmd: "cd /tmp"}, {Cmd: "echo hi"}}
+
+	tests := []struct {
+		line string
+		want string
+		err  bool
+	}{
+		{`no bang`, `no bang`, false},
+		{`!!`, `echo hi`, false},
+		{`sudo !!`, `sudo echo hi`, false},
+		{`!1`, `ls -l`, false},
+		{`!2 && !3`, `cd /tmp && echo hi`, false},
+		{`!-1`, `echo hi`, false},
+		{`!-3`, `ls -l`, false},
+		{`echo !`, `echo !`, false},
+		{`echo !x`, `echo !x`, false},
+		{`echo '!!'`, `echo '!!'`, false},
+		{`echo "!!"`, `echo "echo hi"`, false},
+		{`echo \!!`, `echo \!!`, false},
+		{`!4`, ``, true},
+		{`!0`, ``, true},
+		{`!-4`, ``, true},
+	}
+	for _, test := range tests {
+		got, err := expandHistory(test.line)
+		if (err != nil) != test.err {
+			t.Errorf("%s: error %v", test.line, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.line, got, test.want)
+		}
+	}
+}
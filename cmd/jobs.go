@@ -0,0 +1,550 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// jobs keeps track of the commands running in the foreground and in the
+// background
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"fmt"
+	"gosh/logger"
+	"gosh/ui"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rivo/tview"
+	"golang.org/x/crypto/ssh"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type job struct {
+	id         int
+	cmdLine    string
+	background bool
+	async      bool // Started with &, even once brought to the foreground
+	start      time.Time
+	mutex      sync.Mutex
+	pgids      []int // Process groups of the running pipeline
+	pid        int   // Last process started
+	end        time.Time
+	rc         int
+	running    bool
+	stopped    bool // Interrupted by the user
+	done       chan struct{}
+	outMutex   sync.Mutex   // Never held by the UI thread
+	output     bytes.Buffer // Output of a background job
+	attached   io.Writer    // Where the output is copied while in foreground
+	depth      int          // Functions calls in progress
+	scripts    int          // Scripts being sourced
+	exited     bool         // exit was called by a script
+	exitRC     int
+	session    *ssh.Session // Command running on a remote host
+}
+
+// jobWriter captures the output of a background job
+type jobWriter struct {
+	j *job
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	JOB_MAX_OUTPUT   = 1 << 20 // Bytes of output kept for each job
+	JOB_MAX_FINISHED = 20      // Finished background jobs kept for replay
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	jobsMutex sync.Mutex
+	jobs      []*job
+	lastJobID int
+	signals   = map[string]syscall.Signal{
+		"HUP":  syscall.SIGHUP,
+		"INT":  syscall.SIGINT,
+		"QUIT": syscall.SIGQUIT,
+		"KILL": syscall.SIGKILL,
+		"USR1": syscall.SIGUSR1,
+		"USR2": syscall.SIGUSR2,
+		"TERM": syscall.SIGTERM,
+		"CONT": syscall.SIGCONT,
+		"STOP": syscall.SIGSTOP,
+		"TSTP": syscall.SIGTSTP,
+	}
+)
+
+// ****************************************************************************
+// newJob()
+// newJob adds a job to the table
+// ****************************************************************************
+func newJob(cmdLine string, background bool) *job {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	lastJobID++
+	j := &job{
+		id:         lastJobID,
+		cmdLine:    cmdLine,
+		background: background,
+		async:      background,
+		start:      time.Now(),
+		running:    true,
+		done:       make(chan struct{}),
+	}
+	jobs = append(jobs, j)
+	return j
+}
+
+// ****************************************************************************
+// startJob()
+// startJob runs a list of pipelines in the background
+// ****************************************************************************
+func startJob(l *list) *job {
+	j := newJob(l.String(), true)
+	if Batch {
+		j.attached = os.Stdout
+	}
+	showJobsCount()
+	go func() {
+		w := jobWriter{j: j}
+		rc, _ := runList(j, l.pipes, w, w, nil)
+		j.finish(rc)
+		showJobsCount()
+		updateUI(func() {
+			msg := fmt.Sprintf("[%d] Done (RC=%d) %s", j.id, rc, j.cmdLine)
+			ui.OutConsole("[yellow]" + tview.Escape(msg) + "[-]")
+			ui.SetStatus(msg)
+		})
+	}()
+	return j
+}
+
+// ****************************************************************************
+// setLastRC()
+// setLastRC gives rc to $?, unless the job was started in the background
+// ****************************************************************************
+func (j *job) setLastRC(rc int) {
+	if !j.async {
+		lastRC.Store(int32(rc))
+	}
+}
+
+// ****************************************************************************
+// finish()
+// ****************************************************************************
+func (j *job) finish(rc int) {
+	j.mutex.Lock()
+	j.rc = rc
+	j.running = false
+	j.end = time.Now()
+	j.pgids = nil
+	background := j.background
+	j.mutex.Unlock()
+	close(j.done)
+	checkLongJob(j)
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	// The output of a foreground job is already in the console
+	if !background {
+		removeJob(j)
+	}
+	finished := 0
+	for i := len(jobs) - 1; i >= 0; i-- {
+		jobs[i].mutex.Lock()
+		running := jobs[i].running
+		jobs[i].mutex.Unlock()
+		if !running {
+			finished++
+			if finished > JOB_MAX_FINISHED {
+				removeJob(jobs[i])
+			}
+		}
+	}
+}
+
+// ****************************************************************************
+// removeJob()
+// removeJob removes a job from the table, jobsMutex must be held
+// ****************************************************************************
+func removeJob(j *job) {
+	for i := range jobs {
+		if jobs[i] == j {
+			jobs = append(jobs[:i], jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// ****************************************************************************
+// setPGIDs()
+// ****************************************************************************
+func (j *job) setPGIDs(pgids []int, pid int) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.pgids = pgids
+	if pid != 0 {
+		j.pid = pid
+	}
+}
+
+// ****************************************************************************
+// isStopped()
+// ****************************************************************************
+func (j *job) isStopped() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.stopped || j.exited
+}
+
+// ****************************************************************************
+// isBackground()
+// ****************************************************************************
+func (j *job) isBackground() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.background
+}
+
+// ****************************************************************************
+// signal()
+// signal sends a signal to all the processes of the job
+// ****************************************************************************
+func (j *job) signal(sig syscall.Signal) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == syscall.SIGKILL {
+		j.stopped = true
+	}
+	var err error
+	if j.session != nil {
+		if s, ok := sshSignals[sig]; ok {
+			err = j.session.Signal(s)
+		}
+		// Not all the servers handle the signals
+		if j.stopped {
+			j.session.Close()
+		}
+	}
+	for _, pgid := range j.pgids {
+		if e := syscall.Kill(-pgid, sig); e != nil {
+			logger.Warning("jobs.go: Error sending %v to PGID %d: %v", sig, pgid, e)
+			err = e
+		}
+		if sig != syscall.SIGSTOP && sig != syscall.SIGTSTP {
+			// A suspended program must resume to handle the signal
+			syscall.Kill(-pgid, syscall.SIGCONT)
+		}
+	}
+	return err
+}
+
+// ****************************************************************************
+// runtime()
+// ****************************************************************************
+func (j *job) runtime() time.Duration {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.running {
+		return time.Since(j.start)
+	}
+	return j.end.Sub(j.start)
+}
+
+// ****************************************************************************
+// Write() jobWriter
+// ****************************************************************************
+func (w jobWriter) Write(p []byte) (int, error) {
+	w.j.outMutex.Lock()
+	defer w.j.outMutex.Unlock()
+	w.j.output.Write(p)
+	if extra := w.j.output.Len() - JOB_MAX_OUTPUT; extra > 0 {
+		w.j.output.Next(extra)
+	}
+	if w.j.attached != nil {
+		w.j.attached.Write(p)
+	}
+	return len(p), nil
+}
+
+// ****************************************************************************
+// foregroundJobs()
+// ****************************************************************************
+func foregroundJobs() []*job {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	var fg []*job
+	for _, j := range jobs {
+		j.mutex.Lock()
+		if !j.background && j.running {
+			fg = append(fg, j)
+		}
+		j.mutex.Unlock()
+	}
+	return fg
+}
+
+// ****************************************************************************
+// showJobsCount()
+// showJobsCount updates the indicator of running background jobs
+// ****************************************************************************
+func showJobsCount() {
+	n := RunningJobs()
+	updateUI(func() {
+		if n > 0 {
+			ui.LblJobs.SetText(fmt.Sprintf("⚙%d", n))
+		} else {
+			ui.LblJobs.SetText("")
+		}
+		ShowPrompt()
+	})
+}
+
+// ****************************************************************************
+// RunningJobs()
+// RunningJobs returns the number of background jobs still running
+// ****************************************************************************
+func RunningJobs() int {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	n := 0
+	for _, j := range jobs {
+		j.mutex.Lock()
+		if j.background && j.running {
+			n++
+		}
+		j.mutex.Unlock()
+	}
+	return n
+}
+
+// ****************************************************************************
+// findJob()
+// findJob returns the job matching %n, %%, %+, %string or a PID
+// ****************************************************************************
+func findJob(spec string) (*job, error) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	var candidates []*job
+	for _, j := range jobs {
+		j.mutex.Lock()
+		if j.background {
+			candidates = append(candidates, j)
+		}
+		j.mutex.Unlock()
+	}
+	if spec == "" || spec == "%%" || spec == "%+" {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%s: no current job", spec)
+		}
+		return candidates[len(candidates)-1], nil
+	}
+	if strings.HasPrefix(spec, "%") {
+		if id, err := strconv.Atoi(spec[1:]); err == nil {
+			for _, j := range candidates {
+				if j.id == id {
+					return j, nil
+				}
+			}
+		} else {
+			for i := len(candidates) - 1; i >= 0; i-- {
+				if strings.HasPrefix(candidates[i].cmdLine, spec[1:]) {
+					return candidates[i], nil
+				}
+			}
+		}
+	} else if pid, err := strconv.Atoi(spec); err == nil {
+		for _, j := range candidates {
+			if j.pid == pid {
+				return j, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: no such job", spec)
+}
+
+// ****************************************************************************
+// doJobs()
+// ****************************************************************************
+func doJobs(args []string, stdout io.Writer, stderr io.Writer) int {
+	jobsMutex.Lock()
+	list := append([]*job{}, jobs...)
+	jobsMutex.Unlock()
+	for _, j := range list {
+		runtime := j.runtime()
+		j.mutex.Lock()
+		if !j.background {
+			j.mutex.Unlock()
+			continue
+		}
+		status := "Running"
+		if !j.running {
+			status = fmt.Sprintf("Done(%d)", j.rc)
+		}
+		fmt.Fprintf(stdout, "[%d]  %-10s PID %-8d %9.2fs  %s\n", j.id, status, j.pid, runtime.Seconds(), j.cmdLine)
+		j.mutex.Unlock()
+	}
+	return 0
+}
+
+// ****************************************************************************
+// doFg()
+// doFg replays the output of a job and follows it until its end
+// ****************************************************************************
+func doFg(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 2 {
+		fmt.Fprintln(stderr, "gosh: fg: too many arguments")
+		return 1
+	}
+	spec := ""
+	if len(args) == 2 {
+		spec = args[1]
+	}
+	j, err := findJob(spec)
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: fg: %s\n", err.Error())
+		return 1
+	}
+	j.outMutex.Lock()
+	fmt.Fprintln(stdout, j.cmdLine)
+	stdout.Write(j.output.Bytes())
+	j.attached = stdout
+	j.outMutex.Unlock()
+	// Now F4 interrupts it
+	j.mutex.Lock()
+	j.background = false
+	j.mutex.Unlock()
+	showJobsCount()
+
+	<-j.done
+	j.outMutex.Lock()
+	j.attached = nil
+	j.outMutex.Unlock()
+	j.mutex.Lock()
+	j.background = true
+	rc := j.rc
+	j.mutex.Unlock()
+	return rc
+}
+
+// ****************************************************************************
+// doWait()
+// ****************************************************************************
+func doWait(args []string, stdout io.Writer, stderr io.Writer) int {
+	var waited []*job
+	if len(args) == 1 {
+		jobsMutex.Lock()
+		for _, j := range jobs {
+			j.mutex.Lock()
+			if j.background {
+				waited = append(waited, j)
+			}
+			j.mutex.Unlock()
+		}
+		jobsMutex.Unlock()
+	} else {
+		for _, spec := range args[1:] {
+			j, err := findJob(spec)
+			if err != nil {
+				fmt.Fprintf(stderr, "gosh: wait: %s\n", err.Error())
+				return 127
+			}
+			waited = append(waited, j)
+		}
+	}
+	rc := 0
+	for _, j := range waited {
+		<-j.done
+		if len(args) > 1 {
+			rc = j.rc
+		}
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doKill()
+// doKill sends a signal to jobs (%n) or processes (PID)
+// ****************************************************************************
+func doKill(args []string, stdout io.Writer, stderr io.Writer) int {
+	sig := syscall.SIGTERM
+	targets := args[1:]
+	if len(targets) > 0 && targets[0] == "-l" {
+		var names []string
+		for name := range signals {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(a, b int) bool { return signals[names[a]] < signals[names[b]] })
+		for _, name := range names {
+			fmt.Fprintf(stdout, "%2d) SIG%s\n", int(signals[name]), name)
+		}
+		return 0
+	}
+	if len(targets) > 1 && targets[0] == "-s" {
+		targets = append([]string{"-" + targets[1]}, targets[2:]...)
+	}
+	if len(targets) > 0 && strings.HasPrefix(targets[0], "-") {
+		name := strings.TrimPrefix(strings.ToUpper(targets[0][1:]), "SIG")
+		if n, err := strconv.Atoi(name); err == nil {
+			sig = syscall.Signal(n)
+		} else if s, ok := signals[name]; ok {
+			sig = s
+		} else {
+			fmt.Fprintf(stderr, "gosh: kill: %s: invalid signal specification\n", targets[0][1:])
+			return 1
+		}
+		targets = targets[1:]
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(stderr, "kill: usage: kill [-s sigspec | -sigspec] pid | %job ...")
+		return 2
+	}
+	rc := 0
+	for _, target := range targets {
+		if strings.HasPrefix(target, "%") {
+			j, err := findJob(target)
+			if err == nil {
+				err = j.signal(sig)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "gosh: kill: %s\n", err.Error())
+				rc = 1
+			}
+			continue
+		}
+		pid, err := strconv.Atoi(target)
+		if err != nil {
+			fmt.Fprintf(stderr, "gosh: kill: %s: arguments must be process or job IDs\n", target)
+			rc = 1
+			continue
+		}
+		if err := syscall.Kill(pid, sig); err != nil {
+			fmt.Fprintf(stderr, "gosh: kill: (%d) - %s\n", pid, err.Error())
+			rc = 1
+		}
+	}
+	return rc
+}
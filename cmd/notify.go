@@ -0,0 +1,119 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// notify tells the user that a long job is finished, whatever the screen
+// displayed : toast, bell and the hook script of the config
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"gosh/logger"
+	"gosh/ui"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type JobEvent struct {
+	Cmd        string
+	RC         int
+	Runtime    time.Duration
+	Background bool
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	TOAST_MAX_CMD = 40 // Longer commands are shortened in the toast
+)
+
+// ****************************************************************************
+// notifyAfter()
+// notifyAfter returns the duration from which the end of a job is notified,
+// 0 when it is never
+// ****************************************************************************
+func notifyAfter() time.Duration {
+	switch n := ui.MyConfig.Shell.NotifyAfter; {
+	case n < 0:
+		return 0
+	case n == 0:
+		return conf.NOTIFY_AFTER * time.Second
+	default:
+		return time.Duration(n) * time.Second
+	}
+}
+
+// ****************************************************************************
+// checkLongJob()
+// checkLongJob emits the event of a finished job when it was long enough
+// ****************************************************************************
+func checkLongJob(j *job) {
+	if Batch {
+		return
+	}
+	after := notifyAfter()
+	j.mutex.Lock()
+	e := JobEvent{Cmd: j.cmdLine, RC: j.rc, Runtime: j.end.Sub(j.start), Background: j.background}
+	j.mutex.Unlock()
+	if after > 0 && e.Runtime >= after {
+		go notifyJob(e)
+	}
+}
+
+// ****************************************************************************
+// notifyJob()
+// ****************************************************************************
+func notifyJob(e JobEvent) {
+	logger.Info("notify.go: %s finished with RC=%d after %s", e.Cmd, e.RC, e.Runtime)
+	c := e.Cmd
+	if len([]rune(c)) > TOAST_MAX_CMD {
+		c = string([]rune(c)[:TOAST_MAX_CMD-1]) + "…"
+	}
+	text := fmt.Sprintf("%s : RC=%d after %s", c, e.RC, e.Runtime.Round(time.Second))
+	ui.App.QueueUpdateDraw(func() {
+		ui.ShowToast("Command finished", text, e.RC != 0)
+		ui.Bell()
+	})
+	runHook(e)
+}
+
+// ****************************************************************************
+// runHook()
+// runHook runs the hook script of the config with the command, its return
+// code and its runtime in seconds as arguments
+// ****************************************************************************
+func runHook(e JobEvent) {
+	hook := ui.MyConfig.Shell.NotifyHook
+	if hook == "" {
+		return
+	}
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(hook, "~/") {
+		hook = home + hook[1:]
+	}
+	xCmd := exec.Command(hook, e.Cmd, strconv.Itoa(e.RC), fmt.Sprintf("%.2f", e.Runtime.Seconds()))
+	xCmd.Dir = conf.Cwd
+	xCmd.Env = environ()
+	if out, err := xCmd.CombinedOutput(); err != nil {
+		logger.Warning("notify.go: hook %s failed: %v %s", hook, err, out)
+	}
+}
@@ -0,0 +1,612 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// parser is the command line parser of the shell
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type tokenKind int
+
+const (
+	TOKEN_WORD tokenKind = iota
+	TOKEN_PIPE
+	TOKEN_AND
+	TOKEN_OR
+	TOKEN_SEMI
+	TOKEN_AMP
+	TOKEN_REDIR_IN
+	TOKEN_REDIR_OUT
+	TOKEN_REDIR_APPEND
+	TOKEN_REDIR_DUP
+	TOKEN_REDIR_ALL
+	TOKEN_REDIR_ALL_APPEND
+)
+
+type quoteKind int
+
+const (
+	QUOTE_NONE quoteKind = iota
+	QUOTE_SINGLE
+	QUOTE_DOUBLE
+)
+
+type wordPart struct {
+	text  string
+	quote quoteKind
+}
+
+type word []wordPart
+
+type token struct {
+	kind  tokenKind
+	word  word
+	fd    int
+	dupFd int
+}
+
+type redirect struct {
+	kind   tokenKind
+	fd     int
+	dupFd  int
+	target word
+}
+
+type simpleCommand struct {
+	args      []word
+	redirects []redirect
+}
+
+type pipeline struct {
+	op       tokenKind // Operator linking this pipeline to the previous one
+	commands []*simpleCommand
+}
+
+// list is a sequence of pipelines linked by && and ||, ended by ; or &
+type list struct {
+	pipes      []*pipeline
+	background bool
+}
+
+// ****************************************************************************
+// tokenize()
+// tokenize splits a command line into words and operators, honoring quotes
+// ****************************************************************************
+func tokenize(line string) ([]token, error) {
+	var tokens []token
+	var w word
+	var buf strings.Builder
+	inWord := false
+
+	flushPart := func() {
+		if buf.Len() > 0 {
+			w = append(w, wordPart{text: buf.String(), quote: QUOTE_NONE})
+			buf.Reset()
+		}
+	}
+	flushWord := func() {
+		flushPart()
+		if inWord {
+			tokens = append(tokens, token{kind: TOKEN_WORD, word: w, fd: -1})
+		}
+		w = nil
+		inWord = false
+	}
+
+	rs := []rune(line)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\\':
+			if i+1 < len(rs) {
+				flushPart()
+				w = append(w, wordPart{text: string(rs[i+1]), quote: QUOTE_SINGLE})
+				inWord = true
+				i++
+			}
+		case r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != '\'' {
+				j++
+			}
+			if j >= len(rs) {
+				return nil, errors.New("unterminated single quote")
+			}
+			flushPart()
+			w = append(w, wordPart{text: string(rs[i+1 : j]), quote: QUOTE_SINGLE})
+			inWord = true
+			i = j
+		case r == '"':
+			var dq strings.Builder
+			flushPart()
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"'; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) && rs[j+1] == '$' {
+					// An escaped $ must not be expanded
+					w = append(w, wordPart{text: dq.String(), quote: QUOTE_DOUBLE}, wordPart{text: "$", quote: QUOTE_SINGLE})
+					dq.Reset()
+					j++
+					continue
+				}
+				if rs[j] == '\\' && j+1 < len(rs) && strings.ContainsRune("\"\\`", rs[j+1]) {
+					j++
+				}
+				dq.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, errors.New("unterminated double quote")
+			}
+			w = append(w, wordPart{text: dq.String(), quote: QUOTE_DOUBLE})
+			inWord = true
+			i = j
+		case unicode.IsSpace(r):
+			flushWord()
+		case r == '#' && !inWord:
+			// Comment up to the end of the line
+			i = len(rs)
+		case r == '|':
+			flushWord()
+			if i+1 < len(rs) && rs[i+1] == '|' {
+				tokens = append(tokens, token{kind: TOKEN_OR})
+				i++
+			} else {
+				tokens = append(tokens, token{kind: TOKEN_PIPE})
+			}
+		case r == ';':
+			flushWord()
+			tokens = append(tokens, token{kind: TOKEN_SEMI})
+		case r == '&':
+			flushWord()
+			if i+1 < len(rs) && rs[i+1] == '&' {
+				tokens = append(tokens, token{kind: TOKEN_AND})
+				i++
+			} else if i+1 < len(rs) && rs[i+1] == '>' {
+				if i+2 < len(rs) && rs[i+2] == '>' {
+					tokens = append(tokens, token{kind: TOKEN_REDIR_ALL_APPEND, fd: -1})
+					i += 2
+				} else {
+					tokens = append(tokens, token{kind: TOKEN_REDIR_ALL, fd: -1})
+					i++
+				}
+			} else {
+				tokens = append(tokens, token{kind: TOKEN_AMP})
+			}
+		case r == '<' || r == '>':
+			// A word made only of digits just before the operator is a file descriptor
+			fd := -1
+			if inWord && len(w) == 0 && isDigits(buf.String()) {
+				fd, _ = strconv.Atoi(buf.String())
+				buf.Reset()
+				inWord = false
+			}
+			flushWord()
+			if r == '<' {
+				tokens = append(tokens, token{kind: TOKEN_REDIR_IN, fd: fd})
+			} else if i+1 < len(rs) && rs[i+1] == '>' {
+				tokens = append(tokens, token{kind: TOKEN_REDIR_APPEND, fd: fd})
+				i++
+			} else if i+1 < len(rs) && rs[i+1] == '&' {
+				j := i + 2
+				for j < len(rs) && unicode.IsDigit(rs[j]) {
+					j++
+				}
+				if j == i+2 {
+					return nil, errors.New("file descriptor expected after >&")
+				}
+				dupFd, _ := strconv.Atoi(string(rs[i+2 : j]))
+				tokens = append(tokens, token{kind: TOKEN_REDIR_DUP, fd: fd, dupFd: dupFd})
+				i = j - 1
+			} else {
+				tokens = append(tokens, token{kind: TOKEN_REDIR_OUT, fd: fd})
+			}
+		case r == '$' && i+1 < len(rs) && rs[i+1] == '{':
+			// ${...} may contain blanks or operators
+			depth := 0
+			j := i + 1
+			for ; j < len(rs); j++ {
+				if rs[j] == '{' {
+					depth++
+				} else if rs[j] == '}' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if j >= len(rs) {
+				return nil, errors.New("bad substitution: missing }")
+			}
+			buf.WriteString(string(rs[i : j+1]))
+			inWord = true
+			i = j
+		default:
+			buf.WriteRune(r)
+			inWord = true
+		}
+	}
+	flushWord()
+	return tokens, nil
+}
+
+// ****************************************************************************
+// parseLine()
+// parseLine builds the lists of pipelines to run from a command line
+// ****************************************************************************
+func parseLine(line string) ([]*list, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return nil, err
+	}
+	if tokens, err = expandAliases(tokens, nil); err != nil {
+		return nil, err
+	}
+
+	var lists []*list
+	current := &list{}
+	pipe := &pipeline{op: TOKEN_SEMI}
+	command := &simpleCommand{}
+
+	endCommand := func() error {
+		if len(command.args) == 0 && len(command.redirects) == 0 {
+			return errors.New("syntax error near unexpected operator")
+		}
+		pipe.commands = append(pipe.commands, command)
+		command = &simpleCommand{}
+		return nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.kind {
+		case TOKEN_WORD:
+			command.args = append(command.args, t.word)
+		case TOKEN_REDIR_IN, TOKEN_REDIR_OUT, TOKEN_REDIR_APPEND, TOKEN_REDIR_ALL, TOKEN_REDIR_ALL_APPEND:
+			if i+1 >= len(tokens) || tokens[i+1].kind != TOKEN_WORD {
+				return nil, errors.New("syntax error: missing file name after redirection")
+			}
+			command.redirects = append(command.redirects, redirect{kind: t.kind, fd: t.fd, target: tokens[i+1].word})
+			i++
+		case TOKEN_REDIR_DUP:
+			command.redirects = append(command.redirects, redirect{kind: t.kind, fd: t.fd, dupFd: t.dupFd})
+		case TOKEN_PIPE:
+			if err := endCommand(); err != nil {
+				return nil, err
+			}
+		case TOKEN_AND, TOKEN_OR:
+			if err := endCommand(); err != nil {
+				return nil, err
+			}
+			current.pipes = append(current.pipes, pipe)
+			pipe = &pipeline{op: t.kind}
+		case TOKEN_SEMI, TOKEN_AMP:
+			if err := endCommand(); err != nil {
+				return nil, err
+			}
+			current.pipes = append(current.pipes, pipe)
+			current.background = t.kind == TOKEN_AMP
+			lists = append(lists, current)
+			current = &list{}
+			pipe = &pipeline{op: TOKEN_SEMI}
+		}
+	}
+	if len(command.args) > 0 || len(command.redirects) > 0 {
+		pipe.commands = append(pipe.commands, command)
+	} else if len(pipe.commands) > 0 || pipe.op != TOKEN_SEMI {
+		return nil, errors.New("syntax error: unexpected end of line")
+	}
+	if len(pipe.commands) > 0 {
+		current.pipes = append(current.pipes, pipe)
+		lists = append(lists, current)
+	}
+	return lists, nil
+}
+
+// ****************************************************************************
+// String() word
+// String returns the word as it could be typed
+// ****************************************************************************
+func (w word) String() string {
+	var b strings.Builder
+	for _, p := range w {
+		switch p.quote {
+		case QUOTE_NONE:
+			b.WriteString(p.text)
+		case QUOTE_SINGLE:
+			b.WriteString("'" + strings.ReplaceAll(p.text, "'", `'\''`) + "'")
+		case QUOTE_DOUBLE:
+			b.WriteString(`"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(p.text) + `"`)
+		}
+	}
+	return b.String()
+}
+
+// ****************************************************************************
+// String() simpleCommand
+// ****************************************************************************
+func (c *simpleCommand) String() string {
+	var fields []string
+	for _, w := range c.args {
+		fields = append(fields, w.String())
+	}
+	for _, r := range c.redirects {
+		fd := ""
+		if r.fd >= 0 {
+			fd = strconv.Itoa(r.fd)
+		}
+		switch r.kind {
+		case TOKEN_REDIR_IN:
+			fields = append(fields, fd+"<", r.target.String())
+		case TOKEN_REDIR_OUT:
+			fields = append(fields, fd+">", r.target.String())
+		case TOKEN_REDIR_APPEND:
+			fields = append(fields, fd+">>", r.target.String())
+		case TOKEN_REDIR_ALL:
+			fields = append(fields, "&>", r.target.String())
+		case TOKEN_REDIR_ALL_APPEND:
+			fields = append(fields, "&>>", r.target.String())
+		case TOKEN_REDIR_DUP:
+			fields = append(fields, fmt.Sprintf("%s>&%d", fd, r.dupFd))
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// ****************************************************************************
+// String() list
+// ****************************************************************************
+func (l *list) String() string {
+	var b strings.Builder
+	for i, p := range l.pipes {
+		if i > 0 {
+			if p.op == TOKEN_AND {
+				b.WriteString(" && ")
+			} else {
+				b.WriteString(" || ")
+			}
+		}
+		for j, c := range p.commands {
+			if j > 0 {
+				b.WriteString(" | ")
+			}
+			b.WriteString(c.String())
+		}
+	}
+	return b.String()
+}
+
+// ****************************************************************************
+// field is a word being built by the expansion, before the globbing
+// ****************************************************************************
+type field struct {
+	literal strings.Builder
+	pattern strings.Builder
+	hasMeta bool
+	started bool
+}
+
+func (f *field) addUnquoted(s string) {
+	f.literal.WriteString(s)
+	f.pattern.WriteString(s)
+	if strings.ContainsAny(s, "*?[") {
+		f.hasMeta = true
+	}
+	f.started = true
+}
+
+func (f *field) addQuoted(s string) {
+	f.literal.WriteString(s)
+	// Quoted characters must match literally
+	for _, r := range s {
+		if strings.ContainsRune("*?[\\", r) {
+			f.pattern.WriteRune('\\')
+		}
+		f.pattern.WriteRune(r)
+	}
+	f.started = true
+}
+
+// ****************************************************************************
+// expandWord()
+// expandWord returns the fields resulting from the expansion of a word
+// ****************************************************************************
+func expandWord(w word) []string {
+	var fields []*field
+	current := &field{}
+	endField := func() {
+		if current.started {
+			fields = append(fields, current)
+		}
+		current = &field{}
+	}
+
+	for _, p := range expandTilde(w) {
+		switch p.quote {
+		case QUOTE_SINGLE:
+			current.addQuoted(p.text)
+		case QUOTE_DOUBLE:
+			var b strings.Builder
+			for _, seg := range expandVars(p.text) {
+				b.WriteString(seg.text)
+			}
+			current.addQuoted(b.String())
+		default:
+			for _, seg := range expandVars(p.text) {
+				if !seg.expanded {
+					current.addUnquoted(seg.text)
+					continue
+				}
+				// The unquoted values are split on blanks
+				pieces := strings.Fields(seg.text)
+				if len(pieces) == 0 {
+					if seg.text != "" {
+						endField()
+					}
+					continue
+				}
+				if strings.TrimLeftFunc(seg.text, unicode.IsSpace) != seg.text {
+					endField()
+				}
+				for i, piece := range pieces {
+					if i > 0 {
+						endField()
+					}
+					current.addUnquoted(piece)
+				}
+				if strings.TrimRightFunc(seg.text, unicode.IsSpace) != seg.text {
+					endField()
+				}
+			}
+		}
+	}
+	endField()
+
+	var result []string
+	for _, f := range fields {
+		if f.hasMeta {
+			if matches := glob(f.pattern.String()); len(matches) > 0 {
+				result = append(result, matches...)
+				continue
+			}
+		}
+		result = append(result, f.literal.String())
+	}
+	return result
+}
+
+// ****************************************************************************
+// expandTilde()
+// expandTilde replaces an unquoted leading ~ or ~user by the home folder
+// ****************************************************************************
+func expandTilde(w word) word {
+	if len(w) == 0 || w[0].quote != QUOTE_NONE || !strings.HasPrefix(w[0].text, "~") {
+		return w
+	}
+	name, rest, found := strings.Cut(w[0].text[1:], "/")
+	if !found && len(w) > 1 {
+		// ~"user" is not expanded
+		return w
+	}
+	var home string
+	if name == "" {
+		home, _ = os.UserHomeDir()
+	} else if u, err := user.Lookup(name); err == nil {
+		home = u.HomeDir
+	}
+	if home == "" {
+		return w
+	}
+	// The home folder is not subject to globbing
+	expanded := word{wordPart{text: home, quote: QUOTE_SINGLE}}
+	if found {
+		expanded = append(expanded, wordPart{text: "/" + rest, quote: QUOTE_NONE})
+	}
+	return append(expanded, w[1:]...)
+}
+
+// ****************************************************************************
+// expandArgs()
+// ****************************************************************************
+func expandArgs(ws []word) []string {
+	var args []string
+	for _, w := range ws {
+		args = append(args, expandWord(w)...)
+	}
+	return args
+}
+
+// ****************************************************************************
+// expandTarget()
+// expandTarget expands the file name of a redirection into a single path
+// ****************************************************************************
+func expandTarget(w word) (string, error) {
+	fields := expandWord(w)
+	if len(fields) != 1 {
+		return "", fmt.Errorf("%s: ambiguous redirect", strings.Join(fields, " "))
+	}
+	if filepath.IsAbs(fields[0]) {
+		return fields[0], nil
+	}
+	return filepath.Join(conf.Cwd, fields[0]), nil
+}
+
+// ****************************************************************************
+// glob()
+// glob expands a pattern relative to the current working directory
+// ****************************************************************************
+func glob(pattern string) []string {
+	var matches []string
+	var found []string
+	// A trailing slash only matches the folders, and is kept
+	dirOnly := strings.HasSuffix(pattern, "/") && strings.Trim(pattern, "/") != ""
+	if dirOnly {
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if filepath.IsAbs(pattern) {
+		found, _ = filepath.Glob(pattern)
+	} else {
+		found, _ = filepath.Glob(filepath.Join(conf.Cwd, pattern))
+	}
+	// Like the other shells, hidden files must be matched explicitly
+	dotted := strings.HasPrefix(filepath.Base(pattern), ".")
+	for _, m := range found {
+		if !dotted && strings.HasPrefix(filepath.Base(m), ".") {
+			continue
+		}
+		if dirOnly {
+			if fi, err := os.Stat(m); err != nil || !fi.IsDir() {
+				continue
+			}
+		}
+		if !filepath.IsAbs(pattern) {
+			if rel, err := filepath.Rel(conf.Cwd, m); err == nil {
+				m = rel
+			}
+		}
+		if dirOnly {
+			m += "/"
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// ****************************************************************************
+// isDigits()
+// ****************************************************************************
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,248 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/conf"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ****************************************************************************
+// fields()
+// fields returns the words of a command line once expanded
+// ****************************************************************************
+func fields(t *testing.T, line string) []string {
+	t.Helper()
+	tokens, err := tokenize(line)
+	if err != nil {
+		t.Fatalf("tokenize(%q): %v", line, err)
+	}
+	var result []string
+	for _, tk := range tokens {
+		if tk.kind != TOKEN_WORD {
+			t.Fatalf("tokenize(%q): operator %d unexpected", line, tk.kind)
+		}
+		result = append(result, expandWord(tk.word)...)
+	}
+	return result
+}
+
+// ****************************************************************************
+// TestQuoting()
+// ****************************************************************************
+func TestQuoting(t *testing.T) {
+	t.Setenv("GOSH_TEST", "x  y")
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`echo a   b`, []string{"echo", "a", "b"}},
+		{`echo 'a b' "c d"`, []string{"echo", "a b", "c d"}},
+		{`echo a\ b`, []string{"echo", "a b"}},
+		{`echo "a\"b" "a\\b" "a\b"`, []string{"echo", `a"b`, `a\b`, `a\b`}},
+		{`echo 'it'\''s'`, []string{"echo", "it's"}},
+		{`echo 'a\b'`, []string{"echo", `a\b`}},
+		{`echo a"b"'c'`, []string{"echo", "abc"}},
+		{`echo '' ""`, []string{"echo", "", ""}},
+		{`echo $GOSH_TEST`, []string{"echo", "x", "y"}},
+		{`echo "$GOSH_TEST"`, []string{"echo", "x  y"}},
+		{`echo '$GOSH_TEST'`, []string{"echo", "$GOSH_TEST"}},
+		{`echo "\$GOSH_TEST"`, []string{"echo", "$GOSH_TEST"}},
+		{`echo \$GOSH_TEST`, []string{"echo", "$GOSH_TEST"}},
+		{`echo ${GOSH_TEST}z`, []string{"echo", "x", "yz"}},
+		{`echo ${GOSH_UNSET:-a b}`, []string{"echo", "a", "b"}},
+		{`echo $GOSH_UNSET`, []string{"echo"}},
+		{`echo a#b # comment`, []string{"echo", "a#b"}},
+		{`echo "*" '?' \[`, []string{"echo", "*", "?", "["}},
+	}
+	for _, test := range tests {
+		if got := fields(t, test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestTokenizeErrors()
+// ****************************************************************************
+func TestTokenizeErrors(t *testing.T) {
+	for _, line := range []string{
+		`echo 'a`,
+		`echo "a`,
+		`echo ${a`,
+		`echo a >&`,
+	} {
+		if _, err := tokenize(line); err == nil {
+			t.Errorf("%s: no error", line)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestRedirects()
+// ****************************************************************************
+func TestRedirects(t *testing.T) {
+	tests := []struct {
+		line   string
+		kind   tokenKind
+		fd     int
+		dupFd  int
+		target string
+	}{
+		{`cat < in`, TOKEN_REDIR_IN, -1, 0, "in"},
+		{`cat 0<in`, TOKEN_REDIR_IN, 0, 0, "in"},
+		{`ls > out`, TOKEN_REDIR_OUT, -1, 0, "out"},
+		{`ls 2>err`, TOKEN_REDIR_OUT, 2, 0, "err"},
+		{`ls >> out`, TOKEN_REDIR_APPEND, -1, 0, "out"},
+		{`ls 2>>err`, TOKEN_REDIR_APPEND, 2, 0, "err"},
+		{`ls 2>&1`, TOKEN_REDIR_DUP, 2, 1, ""},
+		{`ls >&2`, TOKEN_REDIR_DUP, -1, 2, ""},
+		{`ls &> all`, TOKEN_REDIR_ALL, -1, 0, "all"},
+		{`ls &>> all`, TOKEN_REDIR_ALL_APPEND, -1, 0, "all"},
+		{`ls >"a b"`, TOKEN_REDIR_OUT, -1, 0, "a b"},
+	}
+	for _, test := range tests {
+		lists, err := parseLine(test.line)
+		if err != nil {
+			t.Errorf("%s: %v", test.line, err)
+			continue
+		}
+		c := lists[0].pipes[0].commands[0]
+		if len(c.args) != 1 || len(c.redirects) != 1 {
+			t.Errorf("%s: %d args, %d redirects", test.line, len(c.args), len(c.redirects))
+			continue
+		}
+		r := c.redirects[0]
+		target := strings.Join(expandWord(r.target), " ")
+		if r.kind != test.kind || r.fd != test.fd || r.dupFd != test.dupFd || target != test.target {
+			t.Errorf("%s: got %d %d>&%d %q, want %d %d>&%d %q", test.line,
+				r.kind, r.fd, r.dupFd, target, test.kind, test.fd, test.dupFd, test.target)
+		}
+	}
+
+	// A number glued to a word is an argument, not a file descriptor
+	lists, err := parseLine(`echo a2>out`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := lists[0].pipes[0].commands[0]; len(c.args) != 2 || c.redirects[0].fd != -1 {
+		t.Errorf("echo a2>out: %s", c)
+	}
+
+	for _, line := range []string{`ls >`, `ls > | wc`, `< `} {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("%s: no error", line)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestOperators()
+// ****************************************************************************
+func TestOperators(t *testing.T) {
+	type want struct {
+		text       string
+		background bool
+	}
+	tests := []struct {
+		line  string
+		lists []want
+	}{
+		{`ls`, []want{{"ls", false}}},
+		{`ls | wc -l`, []want{{"ls | wc -l", false}}},
+		{`a && b || c`, []want{{"a && b || c", false}}},
+		{`a;b`, []want{{"a", false}, {"b", false}}},
+		{`a ; b ;`, []want{{"a", false}, {"b", false}}},
+		{`a & b`, []want{{"a", true}, {"b", false}}},
+		{`sleep 1&`, []want{{"sleep 1", true}}},
+		{`a|b&&c|d;e`, []want{{"a | b && c | d", false}, {"e", false}}},
+		{`echo "a && b" 'c;d' e\|f`, []want{{`echo "a && b" 'c;d' e'|'f`, false}}},
+		{``, nil},
+		{`# comment`, nil},
+	}
+	for _, test := range tests {
+		lists, err := parseLine(test.line)
+		if err != nil {
+			t.Errorf("%s: %v", test.line, err)
+			continue
+		}
+		var got []want
+		for _, l := range lists {
+			got = append(got, want{l.String(), l.background})
+		}
+		if !reflect.DeepEqual(got, test.lists) {
+			t.Errorf("%s: got %v, want %v", test.line, got, test.lists)
+		}
+	}
+
+	for _, line := range []string{`| ls`, `ls |`, `ls &&`, `|| ls`, `ls ;; ls`, `& ls`, `ls | | wc`} {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("%s: no error", line)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestGlob()
+// ****************************************************************************
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"d1", "d2", ".hidden_dir"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"a.txt", "b.txt", "c.go", ".hidden", "d1/e.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("d2", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	cwd := conf.Cwd
+	conf.Cwd = dir
+	defer func() { conf.Cwd = cwd }()
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`ls *.txt`, []string{"ls", "a.txt", "b.txt"}},
+		{`ls ?.go`, []string{"ls", "c.go"}},
+		{`ls [ab].txt`, []string{"ls", "a.txt", "b.txt"}},
+		{`ls *`, []string{"ls", "a.txt", "b.txt", "c.go", "d1", "d2", "link"}},
+		{`ls .h*`, []string{"ls", ".hidden", ".hidden_dir"}},
+		{`ls */`, []string{"ls", "d1/", "d2/", "link/"}},
+		{`ls d*//`, []string{"ls", "d1/", "d2/"}},
+		{`ls */*.txt`, []string{"ls", "d1/e.txt"}},
+		{`ls *.none`, []string{"ls", "*.none"}},
+		{`ls *.txt/`, []string{"ls", "*.txt/"}},
+		{`ls "*.txt"`, []string{"ls", "*.txt"}},
+		{`ls '*'.txt`, []string{"ls", "*.txt"}},
+		{`ls \*.txt`, []string{"ls", "*.txt"}},
+		{`ls "a".*`, []string{"ls", "a.txt"}},
+		{`ls ` + dir + `/*.go`, []string{"ls", filepath.Join(dir, "c.go")}},
+		{`ls ` + dir + `/d*/`, []string{"ls", filepath.Join(dir, "d1") + "/", filepath.Join(dir, "d2") + "/"}},
+	}
+	for _, test := range tests {
+		if got := fields(t, test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.line, got, test.want)
+		}
+	}
+}
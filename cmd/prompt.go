@@ -0,0 +1,158 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// prompt renders the template of the prompt, defined in the config file, with
+// the state of the shell
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"gosh/sudo"
+	"gosh/ui"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	lastRuntime   float64 // Duration in seconds of the last foreground command
+	rePlaceholder = regexp.MustCompile(`\{[a-z]+\}`)
+)
+
+// ****************************************************************************
+// ShowPrompt()
+// ShowPrompt renders the prompt in the status bar, called on the UI thread
+// ****************************************************************************
+func ShowPrompt() {
+	template := ui.MyConfig.Shell.Prompt
+	if template == "" {
+		template = conf.PROMPT_TEMPLATE
+	}
+	text := RenderPrompt(template)
+	// The host of a remote screen is always shown
+	if r := currentRemote(); r != nil && !strings.Contains(template, "{host}") {
+		text = tview.Escape(r.host) + " " + text
+	}
+	ui.SetPrompt(text)
+}
+
+// ****************************************************************************
+// RenderPrompt()
+// RenderPrompt replaces the placeholders of template by their values, the
+// unknown ones are kept as they are :
+//
+//	{user} {host} {cwd} {dir} {git} {rc} {duration} {time} {jobs} {sudo}
+//
+// ****************************************************************************
+func RenderPrompt(template string) string {
+	r := currentRemote()
+	return rePlaceholder.ReplaceAllStringFunc(template, func(p string) string {
+		if r != nil {
+			if value, ok := remotePrompt(r, p); ok {
+				return tview.Escape(value)
+			}
+		}
+		var value string
+		switch p {
+		case "{user}":
+			value = CurrentUser
+		case "{host}":
+			value, _ = os.Hostname()
+		case "{cwd}":
+			value = tildeName(conf.Cwd)
+		case "{dir}":
+			value = filepath.Base(conf.Cwd)
+		case "{git}":
+			value = gitBranch(conf.Cwd)
+		case "{rc}":
+			value = strconv.Itoa(int(lastRC.Load()))
+		case "{duration}":
+			value = fmt.Sprintf("%.2fs", lastRuntime)
+		case "{time}":
+			value = time.Now().Format(ui.MyConfig.FormatTime)
+		case "{jobs}":
+			if n := RunningJobs(); n > 0 {
+				value = strconv.Itoa(n)
+			}
+		case "{sudo}":
+			if isElevated() {
+				value = "#"
+			}
+		default:
+			return p
+		}
+		return tview.Escape(value)
+	})
+}
+
+// ****************************************************************************
+// gitBranch()
+// gitBranch returns the branch checked out in the repository holding dir,
+// read from its HEAD file, or the short hash of a detached HEAD
+// ****************************************************************************
+func gitBranch(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			if !info.IsDir() {
+				// A worktree or a submodule points to its real folder
+				b, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				gitDir = strings.TrimSpace(strings.TrimPrefix(string(b), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+			}
+			b, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			head := strings.TrimSpace(string(b))
+			if ref, ok := strings.CutPrefix(head, "ref: refs/heads/"); ok {
+				return ref
+			}
+			if len(head) > 7 {
+				head = head[:7]
+			}
+			return head
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ****************************************************************************
+// isElevated()
+// isElevated tells if the commands run with the rights of root, or if the
+// built-in actions do with a cached sudo password
+// ****************************************************************************
+func isElevated() bool {
+	return sudo.Elevated()
+}
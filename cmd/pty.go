@@ -0,0 +1,244 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// pty runs the commands of the prompt in a pseudo-terminal, displayed by an
+// emulator while the job is running. The last PTY_MAX_OUTPUT bytes written
+// are kept for the console.
+// Under the terminal, stdout and stderr of the commands are the same file :
+// their lines are not told apart in the block of the console, only the lines
+// of the builtins are.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"gosh/logger"
+	"gosh/ui"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/hinshun/vt10x"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type ptyJob struct {
+	master   *os.File
+	tty      *os.File // Slave side, given to the commands
+	vt       vt10x.Terminal
+	title    string
+	mutex    sync.Mutex
+	output   bytes.Buffer // The last bytes written by the commands
+	dropped  bool         // Older output removed from output
+	shown    bool
+	finished bool
+	done     chan struct{}
+	redraw   atomic.Bool // A redraw of the screen is already queued
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	PTY_SHOW_DELAY = 250 * time.Millisecond // Quick commands don't need the emulator
+	PTY_TERM       = "xterm-256color"
+	PTY_MAX_OUTPUT = JOB_MAX_OUTPUT // Bytes of output kept for the console
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	reAltScreen = regexp.MustCompile(`(?s)\x1b\[\?(?:1049|1047|47)h.*?(?:\x1b\[\?(?:1049|1047|47)l|$)`)
+	reOSC       = regexp.MustCompile(`\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+	reCSI       = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	reEscape    = regexp.MustCompile(`\x1b[()][0-9A-Za-z]|\x1b[=>78DEHMNOZc]`)
+)
+
+// ****************************************************************************
+// startPty()
+// startPty opens a pseudo-terminal of the given size and starts to read it
+// ****************************************************************************
+func startPty(title string, cols int, rows int) (*ptyJob, error) {
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+	master, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	pty.Setsize(master, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	p := &ptyJob{
+		master: master,
+		tty:    tty,
+		vt:     vt10x.New(vt10x.WithWriter(master), vt10x.WithSize(cols, rows)),
+		title:  title,
+		done:   make(chan struct{}),
+	}
+	go p.read()
+	time.AfterFunc(PTY_SHOW_DELAY, func() {
+		ui.App.QueueUpdateDraw(p.show)
+	})
+	return p, nil
+}
+
+// ****************************************************************************
+// read()
+// read feeds the emulator with the output of the commands
+// ****************************************************************************
+func (p *ptyJob) read() {
+	defer close(p.done)
+	buf := make([]byte, 4096)
+	for {
+		n, err := p.master.Read(buf)
+		if n > 0 {
+			p.vt.Write(buf[:n])
+			p.mutex.Lock()
+			p.output.Write(buf[:n])
+			if extra := p.output.Len() - PTY_MAX_OUTPUT; extra > 0 {
+				// Whole lines are dropped
+				if i := bytes.IndexByte(p.output.Bytes()[extra:], '\n'); i >= 0 {
+					extra += i + 1
+				}
+				p.output.Next(extra)
+				p.dropped = true
+			}
+			p.mutex.Unlock()
+			if p.redraw.CompareAndSwap(false, true) {
+				go ui.App.QueueUpdateDraw(func() {
+					p.redraw.Store(false)
+				})
+			}
+		}
+		if err != nil {
+			// EIO when all the commands have exited
+			return
+		}
+	}
+}
+
+// ****************************************************************************
+// show()
+// show displays the emulator, called on the UI thread
+// ****************************************************************************
+func (p *ptyJob) show() {
+	if p.finished || p.shown {
+		return
+	}
+	p.shown = true
+	ui.ShowTerminal(p.vt, p.title, p.write, p.resize)
+}
+
+// ****************************************************************************
+// write()
+// write sends the keystrokes to the commands
+// ****************************************************************************
+func (p *ptyJob) write(b []byte) {
+	if _, err := p.master.Write(b); err != nil {
+		logger.Error("pty.go: %v", err)
+	}
+}
+
+// ****************************************************************************
+// resize()
+// ****************************************************************************
+func (p *ptyJob) resize(cols int, rows int) {
+	pty.Setsize(p.master, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// ****************************************************************************
+// close()
+// close waits for the last output, goes back to the console and returns the
+// text to keep in it
+// ****************************************************************************
+func (p *ptyJob) close() string {
+	p.tty.Close()
+	select {
+	case <-p.done:
+	case <-time.After(time.Second):
+		// A background process may still hold the terminal
+	}
+	ui.App.QueueUpdateDraw(func() {
+		p.finished = true
+		if p.shown {
+			ui.HideTerminal()
+		}
+	})
+	p.master.Close()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.dropped {
+		return "[gray]… older output dropped[-]\n" + consoleText(p.output.Bytes())
+	}
+	return consoleText(p.output.Bytes())
+}
+
+// ****************************************************************************
+// consoleText()
+// consoleText converts the raw output of a terminal into text for the
+// console, the full screen programs leave nothing behind them
+// ****************************************************************************
+func consoleText(raw []byte) string {
+	s := reAltScreen.ReplaceAllString(string(raw), "")
+	s = reOSC.ReplaceAllString(s, "")
+	// Only the colors are understood by the console
+	s = reCSI.ReplaceAllStringFunc(s, func(seq string) string {
+		if strings.HasSuffix(seq, "m") && !strings.Contains(seq, "?") {
+			return seq
+		}
+		return ""
+	})
+	s = reEscape.ReplaceAllString(s, "")
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// A carriage return overwrites the line, keep the last version
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		lines = append(lines, tview.TranslateANSI(eraseBackspaces(line)))
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// ****************************************************************************
+// eraseBackspaces()
+// ****************************************************************************
+func eraseBackspaces(line string) string {
+	if !strings.ContainsAny(line, "\b\a") {
+		return line
+	}
+	var out []rune
+	for _, r := range line {
+		switch r {
+		case '\b':
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case '\a':
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
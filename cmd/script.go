@@ -0,0 +1,267 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// script runs the files of commands : the goshrc file at start-up, the files
+// given to !source, and the batch mode used without the user interface
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"gosh/logger"
+	"gosh/ui"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	SCRIPT_MAX_DEPTH = 32
+)
+
+// ****************************************************************************
+// RunStartupScript()
+// RunStartupScript runs the goshrc file of the app folder, if there is one
+// ****************************************************************************
+func RunStartupScript(fName string) {
+	if _, err := os.Stat(fName); errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	c := "!source " + fName
+	logger.Info("script.go: Running %s", fName)
+	j := newJob(c, false)
+	stdout := newConsoleWriter(false)
+	stderr := newConsoleWriter(true)
+	start := time.Now()
+	rc := sourceFile(j, fName, nil, stdout, stderr)
+	runtime := time.Since(start).Seconds()
+	stdout.Flush()
+	stderr.Flush()
+	j.finish(rc)
+	showResult(rc, 0, runtime, "")
+}
+
+// ****************************************************************************
+// RunBatch()
+// RunBatch runs a command line, or a script file when command is empty,
+// without the user interface and returns the last return code
+// ****************************************************************************
+func RunBatch(command string, script string, args []string) int {
+	Batch = true
+	j := newJob(command, false)
+	rc := 0
+	if command != "" {
+		if len(args) == 0 {
+			args = []string{"gosh"}
+		}
+		// The positional parameters come after the command, like with sh -c
+		lines, err := scriptLines(strings.NewReader(command))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gosh: %s\n", err.Error())
+			return 2
+		}
+		for _, line := range lines {
+			if j.isStopped() {
+				break
+			}
+			if !isFunctionDefinition(line) {
+				line = substituteArgs(line, args)
+			}
+			rc = xeqLine(j, "gosh", line, os.Stdout, os.Stderr, nil)
+			lastRC.Store(int32(rc))
+		}
+	} else {
+		rc = sourceFile(j, script, args, os.Stdout, os.Stderr)
+	}
+	j.finish(rc)
+	if j.exited {
+		return j.exitRC
+	}
+	return rc
+}
+
+// ****************************************************************************
+// runSourceJob()
+// runSourceJob runs the !source command of the prompt as a foreground job
+// ****************************************************************************
+func runSourceJob(c string, h *HistoryEntry) {
+	j := newJob(c, false)
+	stdout := newConsoleWriter(false)
+	stderr := newConsoleWriter(true)
+	start := time.Now()
+	rc := doSource(j, c, stdout, stderr)
+	lastRC.Store(int32(rc))
+	elapsed := time.Since(start)
+	stdout.Flush()
+	stderr.Flush()
+	j.finish(rc)
+	finishHistory(h, rc, elapsed)
+	showResult(rc, 0, elapsed.Seconds(), "")
+}
+
+// ****************************************************************************
+// doSource()
+// doSource runs the file given to a !source command line, with its arguments
+// ****************************************************************************
+func doSource(j *job, line string, stdout io.Writer, stderr io.Writer) int {
+	lists, err := parseLine(strings.TrimPrefix(line, "!"))
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: !source: %s\n", err.Error())
+		return 2
+	}
+	if len(lists) != 1 || len(lists[0].pipes) != 1 || len(lists[0].pipes[0].commands) != 1 || lists[0].background {
+		fmt.Fprintf(stderr, "gosh: !source: usage: !source file [arguments]\n")
+		return 2
+	}
+	args := expandArgs(lists[0].pipes[0].commands[0].args)
+	if len(args) < 2 {
+		fmt.Fprintf(stderr, "gosh: !source: filename argument required\n")
+		return 2
+	}
+	return sourceFile(j, args[1], args[2:], stdout, stderr)
+}
+
+// ****************************************************************************
+// sourceFile()
+// sourceFile runs the commands of a file, $1... being the arguments. The
+// external commands don't get a terminal.
+// ****************************************************************************
+func sourceFile(j *job, fName string, args []string, stdout io.Writer, stderr io.Writer) int {
+	if j.scripts >= SCRIPT_MAX_DEPTH {
+		fmt.Fprintf(stderr, "gosh: %s: maximum script nesting level exceeded (%d)\n", fName, SCRIPT_MAX_DEPTH)
+		return 1
+	}
+	if !filepath.IsAbs(fName) {
+		fName = filepath.Join(conf.Cwd, fName)
+	}
+	f, err := os.Open(fName)
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: %s\n", err.Error())
+		return 1
+	}
+	lines, err := scriptLines(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: %s: %s\n", fName, err.Error())
+		return 1
+	}
+	j.scripts++
+	defer func() { j.scripts-- }()
+
+	params := append([]string{fName}, args...)
+	rc := 0
+	for _, line := range lines {
+		if j.isStopped() {
+			break
+		}
+		// The $1... of a function are its own arguments
+		if !isFunctionDefinition(line) {
+			line = substituteArgs(line, params)
+		}
+		rc = xeqLine(j, filepath.Base(fName), line, stdout, stderr, nil)
+		j.setLastRC(rc)
+	}
+	if j.exited {
+		return j.exitRC
+	}
+	return rc
+}
+
+// ****************************************************************************
+// xeqLine()
+// xeqLine runs a line of a script or of a function in the job j, name being
+// used for the error messages
+// ****************************************************************************
+func xeqLine(j *job, name string, line string, stdout io.Writer, stderr io.Writer, tty *os.File) int {
+	if strings.HasPrefix(line, "!") {
+		key := screenKey(line)
+		switch {
+		case key == "!sour":
+			return doSource(j, line, stdout, stderr)
+		case Batch:
+			fmt.Fprintf(stderr, "gosh: %s: %s: not available in batch mode\n", name, strings.Fields(line)[0])
+			return 1
+		}
+		ok := false
+		updateUI(func() {
+			ok = screenCommand(line)
+		})
+		if !ok {
+			fmt.Fprintf(stderr, "gosh: %s: %s: invalid command\n", name, strings.Fields(line)[0])
+			return 1
+		}
+		return 0
+	}
+	if isFunctionDefinition(line) {
+		if err := defineFunction(line); err != nil {
+			fmt.Fprintf(stderr, "gosh: %s: %s\n", name, err.Error())
+			return 2
+		}
+		return 0
+	}
+	lists, err := parseLine(line)
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: %s: %s\n", name, err.Error())
+		return 2
+	}
+	rc := 0
+	for _, l := range lists {
+		if j.isStopped() {
+			break
+		}
+		if l.background {
+			bg := startJob(l)
+			fmt.Fprintf(stdout, "[%d] %s\n", bg.id, bg.cmdLine)
+			continue
+		}
+		rc, _ = runList(j, l.pipes, stdout, stderr, tty)
+	}
+	return rc
+}
+
+// ****************************************************************************
+// doExit()
+// doExit ends the scripts and the batch mode, in the prompt it asks to quit
+// ****************************************************************************
+func doExit(j *job, args []string, stderr io.Writer) int {
+	rc := int(lastRC.Load())
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Fprintf(stderr, "gosh: exit: %s: numeric argument required\n", args[1])
+			return 2
+		}
+		rc = n & 0xFF
+	}
+	if !Batch && j.scripts == 0 {
+		updateUI(func() {
+			ui.PgsApp.SwitchToPage("dlgQuit")
+		})
+		return 0
+	}
+	j.mutex.Lock()
+	j.exited = true
+	j.exitRC = rc
+	j.mutex.Unlock()
+	return rc
+}
@@ -0,0 +1,518 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// ssh opens Shell screens on remote hosts : !ssh [-p port] [-i key] user@host
+// Each command runs in a new session from the current folder of the screen
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"gosh/dialog"
+	"gosh/logger"
+	"gosh/ui"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rivo/tview"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type remote struct {
+	user   string
+	host   string
+	port   string
+	client *ssh.Client
+	mutex  sync.Mutex
+	home   string
+	cwd    string
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	DlgTrustHost *dialog.Dialog
+	sshSignals   = map[syscall.Signal]ssh.Signal{
+		syscall.SIGINT:  ssh.SIGINT,
+		syscall.SIGTERM: ssh.SIGTERM,
+		syscall.SIGKILL: ssh.SIGKILL,
+		syscall.SIGHUP:  ssh.SIGHUP,
+		syscall.SIGQUIT: ssh.SIGQUIT,
+		syscall.SIGUSR1: ssh.SIGUSR1,
+		syscall.SIGUSR2: ssh.SIGUSR2,
+	}
+)
+
+// ****************************************************************************
+// Close() *remote
+// Close ends the connection when its screen is closed
+// ****************************************************************************
+func (r *remote) Close() error {
+	logger.Info("ssh.go: Closing the connection to %s", r)
+	return r.client.Close()
+}
+
+// ****************************************************************************
+// String() *remote
+// ****************************************************************************
+func (r *remote) String() string {
+	if r.port == "22" {
+		return r.user + "@" + r.host
+	}
+	return r.user + "@" + r.host + ":" + r.port
+}
+
+// ****************************************************************************
+// dir() *remote
+// ****************************************************************************
+func (r *remote) dir() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.cwd
+}
+
+// ****************************************************************************
+// tildeName() *remote
+// ****************************************************************************
+func (r *remote) tildeName() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.home == "" || r.home == "/" {
+		return r.cwd
+	}
+	if r.cwd == r.home {
+		return "~"
+	}
+	if strings.HasPrefix(r.cwd, r.home+"/") {
+		return "~" + r.cwd[len(r.home):]
+	}
+	return r.cwd
+}
+
+// ****************************************************************************
+// currentRemote()
+// currentRemote returns the connection of the current screen, nil when it is
+// not a remote Shell screen
+// ****************************************************************************
+func currentRemote() *remote {
+	if ui.IdxScreens < 0 || ui.IdxScreens >= len(ui.ArrScreens) {
+		return nil
+	}
+	r, _ := ui.ArrScreens[ui.IdxScreens].Param.(*remote)
+	return r
+}
+
+// ****************************************************************************
+// doSSH()
+// doSSH reads the arguments of !ssh and connects in the background, on the
+// UI thread
+// ****************************************************************************
+func doSSH(c string) {
+	user, host, port, keyFile, err := parseSSH(strings.Fields(c)[1:])
+	if err != nil {
+		ui.OutConsole("[yellow]gosh: !ssh: " + tview.Escape(err.Error()) + "[-]")
+		ui.SetStatus(err.Error())
+		return
+	}
+	ui.SetStatus(fmt.Sprintf("Connecting to %s…", host))
+	ui.PleaseWait()
+	go func() {
+		r, err := dialRemote(user, host, port, keyFile)
+		updateUI(func() {
+			ui.JobsDone()
+			if err != nil {
+				ui.OutConsole("[yellow]gosh: !ssh: " + tview.Escape(err.Error()) + "[-]")
+				ui.SetStatus(err.Error())
+				return
+			}
+			ui.AddNewScreen(ui.ModeShell, nil, r)
+			ui.OutConsole(fmt.Sprintf("[yellow]Connected to %s[-]", tview.Escape(r.String())))
+			ShowPrompt()
+		})
+	}()
+}
+
+// ****************************************************************************
+// parseSSH()
+// parseSSH reads [-p port] [-i key] [user@]host[:port]
+// ****************************************************************************
+func parseSSH(args []string) (user string, host string, port string, keyFile string, err error) {
+	port = "22"
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-p", "-i":
+			if i == len(args)-1 {
+				return "", "", "", "", fmt.Errorf("%s: option requires an argument", args[i])
+			}
+			if args[i] == "-p" {
+				port = args[i+1]
+			} else {
+				keyFile = args[i+1]
+			}
+			i++
+		default:
+			if host != "" || strings.HasPrefix(args[i], "-") {
+				return "", "", "", "", fmt.Errorf("usage: !ssh [-p port] [-i key] [user@]host[:port]")
+			}
+			host = args[i]
+		}
+	}
+	if host == "" {
+		return "", "", "", "", fmt.Errorf("usage: !ssh [-p port] [-i key] [user@]host[:port]")
+	}
+	user = CurrentUser
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		user, host = host[:i], host[i+1:]
+	}
+	if h, p, e := net.SplitHostPort(host); e == nil {
+		host, port = h, p
+	}
+	host = strings.Trim(host, "[]")
+	if home, e := os.UserHomeDir(); e == nil && strings.HasPrefix(keyFile, "~/") {
+		keyFile = home + keyFile[1:]
+	}
+	return user, host, port, keyFile, nil
+}
+
+// ****************************************************************************
+// dialRemote()
+// dialRemote opens the connection and finds the home folder, where the
+// screen starts
+// ****************************************************************************
+func dialRemote(user string, host string, port string, keyFile string) (*remote, error) {
+	var methods []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			// The agent is needed during the handshake only
+			defer conn.Close()
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		} else {
+			logger.Debug("ssh.go: No agent: %v", err)
+		}
+	}
+	if signers := keySigners(keyFile); len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no SSH agent and no usable key in ~/.ssh")
+	}
+	hostKey, err := hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: hostKey,
+		Timeout:         sshTimeout(),
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(host, port), config)
+	if err != nil {
+		return nil, err
+	}
+	r := &remote{user: user, host: host, port: port, client: client}
+	out, _, err := r.output("pwd")
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	r.home = strings.TrimSpace(out)
+	r.cwd = r.home
+	logger.Info("ssh.go: Connected to %s in %s", r, r.cwd)
+	return r, nil
+}
+
+// ****************************************************************************
+// keySigners()
+// keySigners loads keyFile, or the default keys of ~/.ssh. The keys protected
+// by a passphrase are left to the agent.
+// ****************************************************************************
+func keySigners(keyFile string) []ssh.Signer {
+	files := []string{keyFile}
+	if keyFile == "" {
+		files = nil
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+				files = append(files, filepath.Join(home, ".ssh", name))
+			}
+		}
+	}
+	var signers []ssh.Signer
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(b)
+		if err != nil {
+			logger.Debug("ssh.go: Key %s skipped: %v", f, err)
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+// ****************************************************************************
+// hostKeyCallback()
+// hostKeyCallback checks the host keys with ~/.ssh/known_hosts. The user is
+// asked to trust an unknown host, a changed key is refused.
+// ****************************************************************************
+func hostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	fName := filepath.Join(home, ".ssh", "known_hosts")
+	if err := os.MkdirAll(filepath.Dir(fName), 0700); err != nil {
+		return nil, err
+	}
+	if f, err := os.OpenFile(fName, os.O_CREATE|os.O_RDONLY, 0600); err == nil {
+		f.Close()
+	}
+	known, err := knownhosts.New(fName)
+	if err != nil {
+		return nil, err
+	}
+	return func(hostname string, addr net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, addr, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("the host key of %s has changed, see %s:%d", hostname, keyErr.Want[0].Filename, keyErr.Want[0].Line)
+		}
+		if !trustHost(hostname, key) {
+			return fmt.Errorf("host %s not trusted", hostname)
+		}
+		f, err := os.OpenFile(fName, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+		return err
+	}, nil
+}
+
+// ****************************************************************************
+// trustHost()
+// trustHost asks the user to trust the key of an unknown host, and waits for
+// the answer
+// ****************************************************************************
+func trustHost(hostname string, key ssh.PublicKey) bool {
+	answer := make(chan bool, 1)
+	updateUI(func() {
+		DlgTrustHost = DlgTrustHost.YesNo("Unknown Host", // Title
+			fmt.Sprintf("The authenticity of %s can't be established.\n%s key fingerprint is %s.\nAdd it to ~/.ssh/known_hosts and connect ?",
+				hostname, key.Type(), ssh.FingerprintSHA256(key)), // Message
+			func(rc dialog.DlgButton, idx int) {
+				answer <- rc == dialog.BUTTON_YES
+			},
+			0,
+			ui.GetCurrentScreen(), ui.TxtPrompt) // Focus return
+		ui.PgsApp.AddPage("dlgTrustHost", DlgTrustHost.Popup(), true, false)
+		ui.PgsApp.ShowPage("dlgTrustHost")
+	})
+	return <-answer
+}
+
+// ****************************************************************************
+// output() *remote
+// output runs a command and returns its output
+// ****************************************************************************
+func (r *remote) output(c string) (string, string, error) {
+	session, err := r.client.NewSession()
+	if err != nil {
+		return "", "", err
+	}
+	defer session.Close()
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run(c)
+	return stdout.String(), stderr.String(), err
+}
+
+// ****************************************************************************
+// xeqRemote()
+// xeqRemote runs a command line on the host of the screen, on the UI thread
+// ****************************************************************************
+func xeqRemote(r *remote, c string, h *HistoryEntry) {
+	if c == "exit" || c == "logout" {
+		go finishHistory(h, 0, 0)
+		ui.CloseCurrentScreen()
+		return
+	}
+	ui.HeaderConsole(r.host + ": " + c)
+	ui.PleaseWait()
+	go runRemote(r, c, h)
+}
+
+// ****************************************************************************
+// runRemote()
+// runRemote runs a command as a foreground job in a new session. cd changes
+// the folder of the screen, the other commands start from it.
+// ****************************************************************************
+func runRemote(r *remote, c string, h *HistoryEntry) {
+	logger.Debug("ssh.go: Running on %s: %s", r, c)
+	j := newJob(c, false)
+	stdout := newConsoleWriter(false)
+	stderr := newConsoleWriter(true)
+	start := time.Now()
+	var rc int
+	if strings.Fields(c)[0] == "cd" && !strings.ContainsAny(c, ";&|<>()\n") {
+		rc = remoteCd(r, c, stderr)
+	} else {
+		rc = remoteRun(j, r, "cd "+quoteRemote(r.dir())+" || exit 1\n"+c, stdout, stderr)
+	}
+	elapsed := time.Since(start)
+	stdout.Flush()
+	stderr.Flush()
+	lastRC.Store(int32(rc))
+	j.finish(rc)
+	finishHistory(h, rc, elapsed)
+	showResult(rc, 0, elapsed.Seconds(), "")
+}
+
+// ****************************************************************************
+// remoteCd()
+// remoteCd changes the folder of the screen, like cd, cd ~ or cd $VAR
+// ****************************************************************************
+func remoteCd(r *remote, c string, stderr io.Writer) int {
+	if len(strings.Fields(c)) == 1 {
+		c = "cd"
+	}
+	out, errOut, err := r.output("cd " + quoteRemote(r.dir()) + " && " + c + " && pwd")
+	if err != nil {
+		if errOut == "" {
+			errOut = err.Error() + "\n"
+		}
+		stderr.Write([]byte(errOut))
+		return 1
+	}
+	r.mutex.Lock()
+	r.cwd = strings.TrimSpace(out)
+	r.mutex.Unlock()
+	return 0
+}
+
+// ****************************************************************************
+// remoteRun()
+// remoteRun runs a script in a session attached to the job, which can be
+// interrupted, and returns its exit status
+// ****************************************************************************
+func remoteRun(j *job, r *remote, script string, stdout io.Writer, stderr io.Writer) int {
+	session, err := r.client.NewSession()
+	if err != nil {
+		fmt.Fprintf(stderr, "gosh: %s: %v\n", r, err)
+		return 255
+	}
+	defer session.Close()
+	session.Stdout = stdout
+	session.Stderr = stderr
+	j.mutex.Lock()
+	j.session = session
+	j.mutex.Unlock()
+	err = session.Run(script)
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		if exitErr.Signal() != "" {
+			return 128 + int(signalNumber(exitErr.Signal()))
+		}
+		return exitErr.ExitStatus()
+	case j.isStopped():
+		return 128 + int(syscall.SIGINT)
+	default:
+		fmt.Fprintf(stderr, "gosh: %s: %v\n", r, err)
+		return 255
+	}
+}
+
+// ****************************************************************************
+// signalNumber()
+// ****************************************************************************
+func signalNumber(name string) syscall.Signal {
+	for sig, s := range sshSignals {
+		if string(s) == name {
+			return sig
+		}
+	}
+	return syscall.SIGTERM
+}
+
+// ****************************************************************************
+// quoteRemote()
+// quoteRemote quotes a path for the shell of the host
+// ****************************************************************************
+func quoteRemote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ****************************************************************************
+// remotePrompt()
+// remotePrompt gives the values of the prompt placeholders for a remote
+// screen, ok is false for the ones which are the same as on the local box
+// ****************************************************************************
+func remotePrompt(r *remote, p string) (value string, ok bool) {
+	switch p {
+	case "{user}":
+		return r.user, true
+	case "{host}":
+		return r.host, true
+	case "{cwd}":
+		return r.tildeName(), true
+	case "{dir}":
+		return path.Base(r.dir()), true
+	case "{git}":
+		return "", true
+	case "{sudo}":
+		if r.user == "root" {
+			return "#", true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// ****************************************************************************
+// sshTimeout()
+// ****************************************************************************
+func sshTimeout() time.Duration {
+	if seconds := ui.MyConfig.Shell.SSHTimeout; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return conf.SSH_TIMEOUT * time.Second
+}
@@ -0,0 +1,316 @@
+//go:build integration
+
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package cmd
+
+// ****************************************************************************
+// The SSH mode against a real sshd, for instance in a container :
+//
+//	ssh-keygen -t ed25519 -N "" -f /tmp/gosh_key
+//	docker run -d --rm --name gosh-sshd -p 2222:2222 \
+//		-e USER_NAME=gosh -e PUBLIC_KEY="$(cat /tmp/gosh_key.pub)" \
+//		lscr.io/linuxserver/openssh-server
+//	GOSH_SSH_HOST=localhost:2222 GOSH_SSH_USER=gosh GOSH_SSH_KEY=/tmp/gosh_key \
+//		go test -tags integration -run SSH ./cmd
+//	docker stop gosh-sshd
+//
+// GOSH_SSH_KEY is a private key without passphrase authorized on the host.
+// The tests use a temporary home folder, for ~/.ssh/known_hosts.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type sshTarget struct {
+	user    string
+	host    string
+	port    string
+	keyFile string
+	hostKey ssh.PublicKey
+}
+
+// ****************************************************************************
+// setupSSH()
+// setupSSH reads the host to test, and gives it a home folder without keys,
+// agent or known hosts
+// ****************************************************************************
+func setupSSH(t *testing.T) *sshTarget {
+	t.Helper()
+	addr, user, keyFile := os.Getenv("GOSH_SSH_HOST"), os.Getenv("GOSH_SSH_USER"), os.Getenv("GOSH_SSH_KEY")
+	if addr == "" || user == "" || keyFile == "" {
+		t.Skip("GOSH_SSH_HOST, GOSH_SSH_USER and GOSH_SSH_KEY are needed")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "22"
+	}
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.ParsePrivateKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &sshTarget{user: user, host: host, port: port, keyFile: keyFile}
+
+	// The key of the host, as the first connection gets it
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			target.hostKey = key
+			return nil
+		},
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(host, port), config)
+	if err != nil {
+		t.Fatalf("sshd not reachable: %v", err)
+	}
+	client.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+	return target
+}
+
+// ****************************************************************************
+// knowHost()
+// knowHost writes key as the one of the target in ~/.ssh/known_hosts
+// ****************************************************************************
+func (target *sshTarget) knowHost(t *testing.T, key ssh.PublicKey) {
+	t.Helper()
+	dir := filepath.Join(os.Getenv("HOME"), ".ssh")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(net.JoinHostPort(target.host, target.port))}, key)
+	if err := os.WriteFile(filepath.Join(dir, "known_hosts"), []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ****************************************************************************
+// connect()
+// ****************************************************************************
+func (target *sshTarget) connect(t *testing.T, keyFile string) *remote {
+	t.Helper()
+	r, err := dialRemote(target.user, target.host, target.port, keyFile)
+	if err != nil {
+		t.Fatalf("dialRemote: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// ****************************************************************************
+// newKey()
+// newKey returns a key the host doesn't know
+// ****************************************************************************
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+// ****************************************************************************
+// TestSSHConnect()
+// ****************************************************************************
+func TestSSHConnect(t *testing.T) {
+	target := setupSSH(t)
+	target.knowHost(t, target.hostKey)
+	r := target.connect(t, target.keyFile)
+	if r.home == "" || r.dir() != r.home || r.tildeName() != "~" {
+		t.Errorf("home %q, cwd %q, tilde %q", r.home, r.dir(), r.tildeName())
+	}
+	if value, _ := remotePrompt(r, "{user}"); value != target.user {
+		t.Errorf("{user} is %q", value)
+	}
+}
+
+// ****************************************************************************
+// TestSSHKnownHosts()
+// ****************************************************************************
+func TestSSHKnownHosts(t *testing.T) {
+	target := setupSSH(t)
+
+	// Another key for the host is a man in the middle
+	other, err := ssh.NewPublicKey(newKey(t).Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.knowHost(t, other)
+	_, err = dialRemote(target.user, target.host, target.port, target.keyFile)
+	if err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("changed host key: %v", err)
+	}
+
+	target.knowHost(t, target.hostKey)
+	target.connect(t, target.keyFile)
+}
+
+// ****************************************************************************
+// TestSSHKeyAuth()
+// ****************************************************************************
+func TestSSHKeyAuth(t *testing.T) {
+	target := setupSSH(t)
+	target.knowHost(t, target.hostKey)
+
+	// No agent, and no key in the empty ~/.ssh
+	if _, err := dialRemote(target.user, target.host, target.port, ""); err == nil {
+		t.Error("connected without any key")
+	}
+
+	// A key the host doesn't accept
+	block, err := ssh.MarshalPrivateKey(newKey(t), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(t.TempDir(), "other")
+	if err := os.WriteFile(other, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialRemote(target.user, target.host, target.port, other); err == nil {
+		t.Error("connected with a key not authorized")
+	}
+
+	// The key given by -i, then the default one of ~/.ssh
+	target.connect(t, target.keyFile)
+	b, err := os.ReadFile(target.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519"), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	target.connect(t, "")
+}
+
+// ****************************************************************************
+// TestSSHAgentAuth()
+// ****************************************************************************
+func TestSSHAgentAuth(t *testing.T) {
+	target := setupSSH(t)
+	target.knowHost(t, target.hostKey)
+
+	b, err := os.ReadFile(target.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.ParseRawPrivateKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	// A short path, the sockets can't have long ones
+	dir, err := os.MkdirTemp("", "gosh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "agent")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	target.connect(t, "")
+}
+
+// ****************************************************************************
+// TestSSHRun()
+// ****************************************************************************
+func TestSSHRun(t *testing.T) {
+	target := setupSSH(t)
+	target.knowHost(t, target.hostKey)
+	r := target.connect(t, target.keyFile)
+
+	stdout, stderr, err := r.output("echo out; echo err >&2")
+	if err != nil || stdout != "out\n" || stderr != "err\n" {
+		t.Errorf("output: %q %q %v", stdout, stderr, err)
+	}
+
+	tests := []struct {
+		script string
+		rc     int
+		out    string
+	}{
+		{"echo hello", 0, "hello\n"},
+		{"true", 0, ""},
+		{"false", 1, ""},
+		{"exit 3", 3, ""},
+		{"kill -TERM $$", 143, ""},
+	}
+	for _, test := range tests {
+		var out, errOut bytes.Buffer
+		j := newJob(test.script, false)
+		rc := remoteRun(j, r, test.script, &out, &errOut)
+		j.finish(rc)
+		if rc != test.rc || out.String() != test.out {
+			t.Errorf("%s: rc %d %q, want %d %q (stderr %q)", test.script, rc, out.String(), test.rc, test.out, errOut.String())
+		}
+	}
+
+	// cd changes the folder the next commands start from
+	var errOut bytes.Buffer
+	if rc := remoteCd(r, "cd /tmp", &errOut); rc != 0 || r.dir() != "/tmp" {
+		t.Fatalf("cd /tmp: rc %d, cwd %q, %s", rc, r.dir(), errOut.String())
+	}
+	if rc := remoteCd(r, "cd /nonexistent", &errOut); rc == 0 || r.dir() != "/tmp" {
+		t.Errorf("cd /nonexistent: rc %d, cwd %q", rc, r.dir())
+	}
+	var out bytes.Buffer
+	j := newJob("pwd", false)
+	rc := remoteRun(j, r, "cd "+quoteRemote(r.dir())+" || exit 1\npwd", &out, &errOut)
+	j.finish(rc)
+	if rc != 0 || out.String() != "/tmp\n" {
+		t.Errorf("pwd in /tmp: rc %d %q", rc, out.String())
+	}
+}
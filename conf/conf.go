@@ -10,12 +10,6 @@
 // ****************************************************************************
 package conf
 
-import (
-	"os"
-
-	"github.com/gdamore/tcell/v2"
-)
-
 const (
 	STATUS_MESSAGE_DURATION = 3
 	APP_NAME                = "Gosh"
@@ -24,20 +18,32 @@ const (
 	APP_URL                 = "https://github.com/jplozf/gosh"
 	FILE_HISTORY_CMD        = "cmd_history"
 	FILE_HISTORY_SQL        = "sql_history"
+	FILE_ENV                = "environment"
+	FILE_ALIASES            = "aliases"
+	FILE_RC                 = "goshrc"
+	FILE_AUDIT              = "audit.jsonl"
+	PROMPT_TEMPLATE         = "👻{user}@{host}⯈"
+	HISTORY_MAX_SIZE        = 1000
+	CONSOLE_MAX_LINES       = 10000
+	NOTIFY_AFTER            = 30 // Seconds
+	TOAST_DURATION          = 5
+	SSH_TIMEOUT             = 10 // Seconds
+	SUDO_TIMEOUT            = 5  // Minutes
+	LOG_LEVEL               = "info"
+	LOG_MAX_SIZE            = 1024 // KB
+	LOG_MAX_FILES           = 3
+	ELEVATED_BADGE          = " ⚡ELEVATED "
 	APP_FOLDER              = ".gosh"
 	FILE_MAX_PREVIEW        = 1024
-	HASH_THRESHOLD_SIZE     = 1_073_741_824.0
-	COLOR_FOLDER            = tcell.ColorLightGreen
-	COLOR_FILE              = tcell.ColorYellow
-	COLOR_EXECUTABLE        = tcell.ColorLightYellow
-	COLOR_SELECTED          = tcell.ColorRed
+	HASH_THRESHOLD_SIZE     = 1_073_741_824
+	FOLDER_THEMES           = "themes"
 	ICON_MODIFIED           = "●"
 	NEW_FILE_TEMPLATE       = "gosh_edit_"
 	LABEL_PARENT_FOLDER     = "<UP>"
 	FILE_LOG                = "gosh.log"
 	FILE_CONFIG             = "gosh.json"
-	FKEY_LABELS             = "F1=Help F2=Prompt F3=Close F4=Stop Cmd F5=Refresh F6=Previous F7=Next F8=Context Menu F10=Main Menu F12=Exit"
+	FILE_KEYS               = "keys.json"
+	CONFIG_VERSION          = 3
 )
 
 var Cwd string
-var LogFile *os.File
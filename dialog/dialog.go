@@ -15,6 +15,7 @@ package dialog
 // ****************************************************************************
 import (
 	"gosh/ui"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -38,6 +39,8 @@ const (
 	INPUT_NONE DlgInput = iota
 	INPUT_TEXT
 	INPUT_LIST
+	INPUT_PASSWORD
+	INPUT_CHOICE
 )
 
 type DlgRC struct {
@@ -53,6 +56,8 @@ type Dialog struct {
 	buttons []*tview.Button
 	Value   string
 	Values  []string
+	Checked bool
+	check   string
 	parent  string
 	focus   tview.Primitive
 	width   int
@@ -170,6 +175,16 @@ func (m *Dialog) Input(title string, message string, value string, done func(rc
 	return m
 }
 
+// ****************************************************************************
+// Password()
+// Password is an Input dialog whose value is masked
+// ****************************************************************************
+func (m *Dialog) Password(title string, message string, done func(rc DlgButton, idx int), idx int, parent string, focus tview.Primitive) *Dialog {
+	m = m.Input(title, message, "", done, idx, parent, focus)
+	m.dtype = INPUT_PASSWORD
+	return m
+}
+
 // ****************************************************************************
 // List()
 // ****************************************************************************
@@ -198,6 +213,48 @@ func (m *Dialog) List(title string, message string, values []string, done func(r
 	return m
 }
 
+// ****************************************************************************
+// Choice()
+// Choice has a button per choice, whose label is returned in Value, and an
+// optional check box; Cancel and Esc return BUTTON_CANCEL
+// ****************************************************************************
+func (m *Dialog) Choice(title string, message string, choices []string, check string, done func(rc DlgButton, idx int), idx int, parent string, focus tview.Primitive) *Dialog {
+	m = &Dialog{
+		Form:    tview.NewForm(),
+		title:   title,
+		message: message,
+		check:   check,
+		done:    done,
+		parent:  parent,
+		focus:   focus,
+		idx:     idx,
+		dtype:   INPUT_CHOICE,
+	}
+
+	m.SetButtonsAlign(tview.AlignCenter)
+	m.SetButtonBackgroundColor(tview.Styles.PrimitiveBackgroundColor)
+	m.SetButtonTextColor(tview.Styles.PrimaryTextColor)
+	m.SetBackgroundColor(tview.Styles.ContrastBackgroundColor).SetBorderPadding(0, 0, 0, 0)
+	m.SetBorder(true).
+		SetBackgroundColor(tview.Styles.ContrastBackgroundColor).
+		SetBorderPadding(1, 1, 1, 1)
+	for _, choice := range choices {
+		m.buttons = append(m.buttons, tview.NewButton(choice))
+	}
+	return m
+}
+
+// ****************************************************************************
+// SetMessage()
+// SetMessage replaces the message of a dialog already shown
+// ****************************************************************************
+func (m *Dialog) SetMessage(message string) {
+	m.message = message
+	if m.GetFormItemCount() > 0 {
+		m.GetFormItem(0).(*tview.TextView).SetText(message)
+	}
+}
+
 // ****************************************************************************
 // refresh() the dialog
 // ****************************************************************************
@@ -208,9 +265,17 @@ func (m *Dialog) refresh() {
 	case INPUT_TEXT:
 		m.AddTextView("", m.message, 0, 1, true, false)
 		m.AddInputField(">", m.Value, 0, nil, nil)
+	case INPUT_PASSWORD:
+		m.AddTextView("", m.message, 0, 1, true, false)
+		m.AddPasswordField(">", "", 0, '*', nil)
 	case INPUT_LIST:
 		m.AddTextView("", m.message, 0, 1, true, false)
 		m.AddDropDown("", m.Values, 0, nil)
+	case INPUT_CHOICE:
+		m.AddTextView("", m.message, 0, strings.Count(m.message, "\n")+1, true, false)
+		if m.check != "" {
+			m.AddCheckbox(m.check, false, nil)
+		}
 	default:
 		m.AddTextView("", m.message, 0, 1, true, false)
 	}
@@ -218,7 +283,9 @@ func (m *Dialog) refresh() {
 	for _, button := range m.buttons {
 		l := button.GetLabel()
 		var f func()
-		if l == "Yes" {
+		if m.dtype == INPUT_CHOICE && l != "Cancel" {
+			f = m.doChoice(l)
+		} else if l == "Yes" {
 			f = m.doYes
 		} else if l == "No" {
 			f = m.doNo
@@ -230,17 +297,25 @@ func (m *Dialog) refresh() {
 		m.AddButton(l, f)
 		m.width += len(l) + 2
 	}
-	if m.width < len(m.message) {
-		m.width = len(m.message)
+	for _, line := range strings.Split(m.message, "\n") {
+		if m.width < len(line) {
+			m.width = len(line)
+		}
 	}
 	if m.width < len(m.title) {
 		m.width = len(m.title)
 	}
 	m.width += 10
 	m.height = 9
-	if m.dtype == INPUT_TEXT || m.dtype == INPUT_LIST {
+	if m.dtype == INPUT_TEXT || m.dtype == INPUT_LIST || m.dtype == INPUT_PASSWORD {
 		m.height += 2
 	}
+	if m.dtype == INPUT_CHOICE {
+		m.height += strings.Count(m.message, "\n")
+		if m.check != "" {
+			m.height += 2
+		}
+	}
 }
 
 // ****************************************************************************
@@ -251,6 +326,10 @@ func (m *Dialog) Popup() tview.Primitive {
 	m.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Key() {
 		case tcell.KeyEsc:
+			if m.dtype == INPUT_CHOICE {
+				m.doCancel()
+				return nil
+			}
 			ui.PgsApp.SwitchToPage(m.parent)
 			ui.App.SetFocus(m.focus)
 			return nil
@@ -301,7 +380,7 @@ func (m *Dialog) doOK() {
 	ui.PgsApp.SwitchToPage(m.parent)
 	ui.App.SetFocus(m.focus)
 	switch m.dtype {
-	case INPUT_TEXT:
+	case INPUT_TEXT, INPUT_PASSWORD:
 		m.Value = m.GetFormItem(1).(*tview.InputField).GetText()
 	case INPUT_LIST:
 		_, m.Value = m.GetFormItem(1).(*tview.DropDown).GetCurrentOption()
@@ -310,3 +389,18 @@ func (m *Dialog) doOK() {
 	}
 	m.done(BUTTON_OK, m.idx)
 }
+
+// ****************************************************************************
+// doChoice()
+// ****************************************************************************
+func (m *Dialog) doChoice(choice string) func() {
+	return func() {
+		ui.PgsApp.SwitchToPage(m.parent)
+		ui.App.SetFocus(m.focus)
+		m.Value = choice
+		if m.check != "" {
+			m.Checked = m.GetFormItem(1).(*tview.Checkbox).IsChecked()
+		}
+		m.done(BUTTON_OK, m.idx)
+	}
+}
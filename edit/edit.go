@@ -15,17 +15,17 @@ package edit
 // ****************************************************************************
 import (
 	"fmt"
+	"gosh/audit"
 	"gosh/conf"
 	"gosh/dialog"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/pgavlin/femto"
 	"github.com/pgavlin/femto/runtime"
 	"github.com/rivo/tview"
@@ -50,11 +50,11 @@ const (
 // GLOBALS
 // ****************************************************************************
 var (
-	openFiles     []editfile
-	currentFile   editfile
 	DlgSaveFile   *dialog.Dialog
 	DlgSaveFileAs *dialog.Dialog
 	currentFlow   int
+	saving        editfile                // The file of the save dialog, from any screen
+	temporaries   = make(map[string]bool) // Files of OpenText, removed once closed
 )
 
 // ****************************************************************************
@@ -62,6 +62,14 @@ var (
 // ****************************************************************************
 func SwitchToEditor(fName string) {
 	ui.AddNewScreen(ui.ModeTextEdit, nil, nil)
+	openHere(fName)
+}
+
+// ****************************************************************************
+// openHere()
+// openHere opens fName in the Editor screen shown
+// ****************************************************************************
+func openHere(fName string) {
 	OpenFile(fName)
 	ShowTreeDir(filepath.Dir(fName))
 	ui.App.SetFocus(ui.EdtMain)
@@ -71,42 +79,99 @@ func SwitchToEditor(fName string) {
 // OpenFile()
 // ****************************************************************************
 func OpenFile(fName string) {
+	scr := current()
 	if isFileAlreadyOpen(fName) {
 		SwitchOpenFile(fName)
 	} else {
-		var colorscheme femto.Colorscheme
-		if monokai := runtime.Files.FindFile(femto.RTColorscheme, "monokai"); monokai != nil {
-			if data, err := monokai.Data(); err == nil {
-				colorscheme = femto.ParseColorscheme(string(data))
-			}
-		}
 		ui.EdtMain.SetRuntimeFiles(runtime.Files)
 		content, err := ioutil.ReadFile(fName)
 		if err != nil {
 			ui.SetStatus(fmt.Sprintf("Could not read %v: %v", fName, err))
 		} else {
-			currentFile.fName = fName
-			currentFile.buffer = femto.NewBufferFromString(string(content), currentFile.fName)
-			ui.EdtMain.OpenBuffer(currentFile.buffer)
-			ui.EdtMain.SetColorscheme(colorscheme)
+			scr.current.fName = fName
+			// The screens editing the same file share its buffer
+			if buffer := findBuffer(fName); buffer != nil {
+				scr.current.buffer = buffer
+			} else {
+				scr.current.buffer = femto.NewBufferFromString(string(content), scr.current.fName)
+			}
+			ui.EdtMain.OpenBuffer(scr.current.buffer)
+			ui.EdtMain.SetColorscheme(colorscheme())
 			ui.EdtMain.SetTitleAlign(tview.AlignRight)
-			openFiles = append(openFiles, currentFile)
+			scr.files = append(scr.files, scr.current)
 			go UpdateStatus()
 			go focusOpenFile(fName)
-			ui.SetStatus(fmt.Sprintf("Opening file %s", currentFile.fName))
-			ui.TblOpenFiles.SetTitle(fmt.Sprintf("Open Files (%d)", len(openFiles)))
+			ui.SetStatus(fmt.Sprintf("Opening file %s", scr.current.fName))
+			ui.TblOpenFiles.SetTitle(fmt.Sprintf("Open Files (%d)", len(scr.files)))
+		}
+	}
+}
+
+// ****************************************************************************
+// ApplyTheme()
+// ApplyTheme gives the colorscheme of the current theme to the editor
+// ****************************************************************************
+func ApplyTheme() {
+	ui.EdtMain.SetColorscheme(colorscheme())
+	for _, s := range screens {
+		recolorTree(s.root)
+	}
+	if cur != nil {
+		recolorTree(cur.root)
+	}
+}
+
+// ****************************************************************************
+// recolorTree()
+// recolorTree gives the nodes of an explorer the colors of their roles, as
+// ShowTreeDir does : the root highlighted, and the folders
+// ****************************************************************************
+func recolorTree(root *tview.TreeNode) {
+	if root == nil {
+		return
+	}
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		switch {
+		case parent == nil:
+			node.SetColor(theme.Highlight)
+		default:
+			path, _ := node.GetReference().(string)
+			if fileInfo, err := os.Lstat(path); err == nil && fileInfo.IsDir() {
+				node.SetColor(theme.Folder)
+			} else {
+				node.SetColor(theme.Text)
+			}
+		}
+		return true
+	})
+}
+
+// ****************************************************************************
+// colorscheme()
+// colorscheme returns the femto colorscheme of the current theme, monokai
+// when it is unknown
+// ****************************************************************************
+func colorscheme() femto.Colorscheme {
+	for _, name := range []string{theme.Editor(), "monokai"} {
+		if file := runtime.Files.FindFile(femto.RTColorscheme, name); file != nil {
+			if data, err := file.Data(); err == nil {
+				return femto.ParseColorscheme(string(data))
+			}
 		}
 	}
+	return nil
 }
 
 // ****************************************************************************
 // SaveFile()
 // ****************************************************************************
 func SaveFile() {
-	err := ioutil.WriteFile(currentFile.fName, []byte(currentFile.buffer.String()), 0600)
+	scr := current()
+	err := ioutil.WriteFile(scr.current.fName, []byte(scr.current.buffer.String()), 0600)
+	audit.Log(audit.ACTION_SAVE, scr.current.fName, err)
 	if err == nil {
-		ui.SetStatus(fmt.Sprintf("File %s successfully saved", currentFile.fName))
-		currentFile.buffer.IsModified = false
+		ui.SetStatus(fmt.Sprintf("File %s successfully saved", scr.current.fName))
+		scr.current.buffer.IsModified = false
 	} else {
 		ui.SetStatus(err.Error())
 	}
@@ -116,10 +181,11 @@ func SaveFile() {
 // SaveFileAs()
 // ****************************************************************************
 func SaveFileAs() {
+	scr := current()
 	currentFlow = FLOW_SELF
 	DlgSaveFileAs = DlgSaveFileAs.Input("Save File as...", // Title
 		"Please, enter the new name for this file :", // Message
-		currentFile.fName,
+		scr.current.fName,
 		confirmSaveAs,
 		0,
 		ui.GetCurrentScreen(), ui.EdtMain) // Focus return
@@ -149,17 +215,81 @@ func NewFile(dir string) {
 	}
 }
 
+// ****************************************************************************
+// OpenText()
+// OpenText opens text in a new Editor screen, in a temporary file which is
+// removed when it is closed
+// ****************************************************************************
+func OpenText(text string) {
+	f, err := os.CreateTemp(os.TempDir(), conf.NEW_FILE_TEMPLATE)
+	if err != nil {
+		ui.SetStatus(err.Error())
+		return
+	}
+	_, err = f.WriteString(text)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		ui.SetStatus(err.Error())
+		return
+	}
+	temporaries[f.Name()] = true
+	SwitchToEditor(f.Name())
+}
+
+// ****************************************************************************
+// dropTemporary()
+// dropTemporary removes a file of OpenText no screen has open anymore
+// ****************************************************************************
+func dropTemporary(fName string) {
+	if temporaries[fName] && findBuffer(fName) == nil {
+		os.Remove(fName)
+		delete(temporaries, fName)
+	}
+}
+
+// ****************************************************************************
+// RemoveTemporaries()
+// RemoveTemporaries removes the files of OpenText, when quitting
+// ****************************************************************************
+func RemoveTemporaries() {
+	for fName := range temporaries {
+		os.Remove(fName)
+	}
+	temporaries = make(map[string]bool)
+}
+
 // ****************************************************************************
 // NewFileOrLastFile()
 // ****************************************************************************
 func NewFileOrLastFile(dir string) {
-	if len(openFiles) > 0 {
-		SwitchToEditor(currentFile.fName)
+	scr := current()
+	if len(orphans) > 0 {
+		// The files of the closed screens go on in this one
+		scr.files = orphans
+		orphans = nil
+		openHere(scr.files[len(scr.files)-1].fName)
 	} else {
-		NewFile(dir)
+		newFileHere(dir)
 	}
 }
 
+// ****************************************************************************
+// newFileHere()
+// newFileHere opens a new file in the Editor screen shown
+// ****************************************************************************
+func newFileHere(dir string) {
+	f, err := os.CreateTemp(dir, conf.NEW_FILE_TEMPLATE)
+	if err != nil {
+		ui.SetStatus(err.Error())
+		return
+	}
+	f.Close()
+	openHere(f.Name())
+}
+
 // ****************************************************************************
 // UpdateStatus()
 // ****************************************************************************
@@ -168,17 +298,21 @@ func UpdateStatus() {
 	for {
 		time.Sleep(100 * time.Millisecond)
 		ui.App.QueueUpdateDraw(func() {
-			ui.TxtEditName.SetText(currentFile.fName)
-			if currentFile.buffer.Modified() {
+			scr := current()
+			if scr.current.buffer == nil {
+				return
+			}
+			ui.TxtEditName.SetText(scr.current.fName)
+			if scr.current.buffer.Modified() {
 				status = conf.ICON_MODIFIED
 			} else {
 				status = " "
 			}
-			x := currentFile.buffer.Cursor.X + 1
-			y := currentFile.buffer.Cursor.Y + 1
+			x := scr.current.buffer.Cursor.X + 1
+			y := scr.current.buffer.Cursor.Y + 1
 			ui.EdtMain.SetTitle(fmt.Sprintf("[ Ln %d, Col %d %s ]", y, x, status))
 			ui.TblOpenFiles.Clear()
-			for i, f := range openFiles {
+			for i, f := range scr.files {
 				if f.buffer.Modified() {
 					ui.TblOpenFiles.SetCell(i, 0, tview.NewTableCell(conf.ICON_MODIFIED))
 				} else {
@@ -196,12 +330,13 @@ func UpdateStatus() {
 // SwitchOpenFile()
 // ****************************************************************************
 func SwitchOpenFile(fName string) {
-	for _, e := range openFiles {
+	scr := current()
+	for _, e := range scr.files {
 		if e.fName == fName {
-			currentFile.fName = e.fName
-			currentFile.buffer = e.buffer
-			ui.EdtMain.OpenBuffer(currentFile.buffer)
-			ui.SetStatus(fmt.Sprintf("Switching to %s", currentFile.fName))
+			scr.current.fName = e.fName
+			scr.current.buffer = e.buffer
+			ui.EdtMain.OpenBuffer(scr.current.buffer)
+			ui.SetStatus(fmt.Sprintf("Switching to %s", scr.current.fName))
 			go focusOpenFile(fName)
 			break
 		}
@@ -212,8 +347,9 @@ func SwitchOpenFile(fName string) {
 // isFileAlreadyOpen()
 // ****************************************************************************
 func isFileAlreadyOpen(fName string) bool {
+	scr := current()
 	rc := false
-	for _, e := range openFiles {
+	for _, e := range scr.files {
 		if e.fName == fName {
 			rc = true
 			break
@@ -240,7 +376,7 @@ func focusOpenFile(fName string) {
 // ****************************************************************************
 func GetGlobalDirtyFlag() bool {
 	rc := false
-	for _, f := range openFiles {
+	for _, f := range allFiles() {
 		if f.buffer.Modified() {
 			rc = true
 			break
@@ -252,12 +388,13 @@ func GetGlobalDirtyFlag() bool {
 // ****************************************************************************
 // proposeToSaveFile()
 // ****************************************************************************
-func proposeToSaveFile(idx int, flow int) {
+func proposeToSaveFile(f editfile, flow int) {
 	currentFlow = flow
-	DlgSaveFile = DlgSaveFile.YesNoCancel(fmt.Sprintf("Save File %s", openFiles[idx].fName), // Title
+	saving = f
+	DlgSaveFile = DlgSaveFile.YesNoCancel(fmt.Sprintf("Save File %s", f.fName), // Title
 		"This file has been modified. Do you want to save it ?", // Message
 		confirmSave,
-		idx,
+		0,
 		ui.GetCurrentScreen(), ui.EdtMain) // Focus return
 	ui.PgsApp.AddPage("dlgSaveFile", DlgSaveFile.Popup(), true, false)
 	ui.PgsApp.ShowPage("dlgSaveFile")
@@ -268,10 +405,11 @@ func proposeToSaveFile(idx int, flow int) {
 // ****************************************************************************
 func confirmSave(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
-		err := ioutil.WriteFile(openFiles[idx].fName, []byte(openFiles[idx].buffer.String()), 0600)
+		err := ioutil.WriteFile(saving.fName, []byte(saving.buffer.String()), 0600)
+		audit.Log(audit.ACTION_SAVE, saving.fName, err)
 		if err == nil {
-			ui.SetStatus(fmt.Sprintf("File %s successfully saved", openFiles[idx].fName))
-			openFiles[idx].buffer.IsModified = false
+			ui.SetStatus(fmt.Sprintf("File %s successfully saved", saving.fName))
+			saving.buffer.IsModified = false
 			if currentFlow == FLOW_CLOSE {
 				CloseCurrentFile()
 			}
@@ -280,7 +418,7 @@ func confirmSave(rc dialog.DlgButton, idx int) {
 		}
 	}
 	if rc == dialog.BUTTON_NO {
-		openFiles[idx].buffer.IsModified = false
+		saving.buffer.IsModified = false
 		if currentFlow == FLOW_CLOSE {
 			CloseCurrentFile()
 		}
@@ -292,24 +430,28 @@ func confirmSave(rc dialog.DlgButton, idx int) {
 // confirmSaveAs()
 // ****************************************************************************
 func confirmSaveAs(rc dialog.DlgButton, idx int) {
+	scr := current()
 	if rc == dialog.BUTTON_OK {
 		newName := DlgSaveFileAs.Value
-		err := ioutil.WriteFile(newName, []byte(currentFile.buffer.String()), 0600)
+		err := ioutil.WriteFile(newName, []byte(scr.current.buffer.String()), 0600)
+		audit.Log(audit.ACTION_SAVE, newName, err)
 		if err == nil {
-			ui.SetStatus(fmt.Sprintf("File %s successfully saved", currentFile.fName))
-			currentFile.buffer.IsModified = false
+			ui.SetStatus(fmt.Sprintf("File %s successfully saved", scr.current.fName))
+			scr.current.buffer.IsModified = false
 			if currentFlow == FLOW_CLOSE {
 				CloseCurrentFile()
 			} else {
 				var n = -1
-				for i, f := range openFiles {
-					if f.fName == currentFile.fName {
+				for i, f := range scr.files {
+					if f.fName == scr.current.fName {
 						n = i
 						break
 					}
 				}
-				copy(openFiles[n:], openFiles[n+1:])
-				openFiles = openFiles[:len(openFiles)-1]
+				old := scr.files[n].fName
+				copy(scr.files[n:], scr.files[n+1:])
+				scr.files = scr.files[:len(scr.files)-1]
+				dropTemporary(old)
 				OpenFile(newName)
 			}
 		} else {
@@ -318,7 +460,7 @@ func confirmSaveAs(rc dialog.DlgButton, idx int) {
 	}
 	if rc == dialog.BUTTON_CANCEL {
 		if currentFlow == FLOW_CLOSE {
-			openFiles[idx].buffer.IsModified = false
+			scr.files[idx].buffer.IsModified = false
 			CloseCurrentFile()
 		}
 	}
@@ -329,9 +471,9 @@ func confirmSaveAs(rc dialog.DlgButton, idx int) {
 // CheckOpenFilesForSaving()
 // ****************************************************************************
 func CheckOpenFilesForSaving() {
-	for i, f := range openFiles {
+	for _, f := range allFiles() {
 		if f.buffer.Modified() {
-			proposeToSaveFile(i, FLOW_QUIT)
+			proposeToSaveFile(f, FLOW_QUIT)
 			break
 		}
 	}
@@ -341,26 +483,29 @@ func CheckOpenFilesForSaving() {
 // CloseCurrentFile()
 // ****************************************************************************
 func CloseCurrentFile() {
+	scr := current()
 	var n = -1
 	var d = ""
-	for i, f := range openFiles {
-		if f.fName == currentFile.fName {
+	for i, f := range scr.files {
+		if f.fName == scr.current.fName {
 			n = i
 			d = filepath.Dir(f.fName)
 			break
 		}
 	}
 	if n >= 0 {
-		if currentFile.buffer.IsModified {
-			proposeToSaveFile(n, FLOW_CLOSE)
+		if scr.current.buffer.IsModified {
+			proposeToSaveFile(scr.files[n], FLOW_CLOSE)
 		} else {
-			copy(openFiles[n:], openFiles[n+1:])
-			openFiles = openFiles[:len(openFiles)-1]
+			fName := scr.files[n].fName
+			copy(scr.files[n:], scr.files[n+1:])
+			scr.files = scr.files[:len(scr.files)-1]
+			dropTemporary(fName)
 			if n > 0 {
-				currentFile = openFiles[n-1]
-				SwitchOpenFile(currentFile.fName)
+				scr.current = scr.files[n-1]
+				SwitchOpenFile(scr.current.fName)
 			} else {
-				NewFile(d)
+				newFileHere(d)
 			}
 		}
 	}
@@ -371,8 +516,9 @@ func CloseCurrentFile() {
 // ****************************************************************************
 func ShowTreeDir(rootDir string) {
 	root := tview.NewTreeNode(rootDir).
-		SetColor(tcell.ColorYellow)
+		SetColor(theme.Highlight)
 	ui.TrvExplorer.SetRoot(root).SetCurrentNode(root)
+	current().root = root
 
 	// A helper function which adds the files and directories of the given path
 	// to the given target node.
@@ -391,7 +537,7 @@ func ShowTreeDir(rootDir string) {
 						SetReference(filepath.Join(path, file.Name())).
 						SetSelectable(file.IsDir() || file.Type().IsRegular())
 					if file.IsDir() {
-						node.SetColor(tcell.ColorGreen)
+						node.SetColor(theme.Folder)
 					}
 					target.AddChild(node)
 				}
@@ -434,12 +580,11 @@ func ShowTreeDir(rootDir string) {
 // ****************************************************************************
 func SelfInit(a any) {
 	if ui.CurrentMode == ui.ModeFiles {
-		idx, _ := ui.TblFiles.GetSelection()
-		fName := filepath.Join(conf.Cwd, strings.TrimSpace(ui.TblFiles.GetCell(idx, 2).Text))
+		fName := ui.SelectedFile()
 		mtype := utils.GetMimeType(fName)
 		if len(mtype) > 3 {
 			if mtype[:4] == "text" {
-				SwitchToEditor(fName)
+				openHere(fName)
 			} else {
 				NewFileOrLastFile(conf.Cwd)
 			}
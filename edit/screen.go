@@ -0,0 +1,126 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package edit
+
+// ****************************************************************************
+// screen keeps the open files of each Editor screen. All the screens share
+// the editor of ui, given the buffer of a screen when it is shown, and a file
+// open in two screens has one buffer. The files of a closed screen go on in
+// the next Editor screen opened.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/ui"
+
+	"github.com/pgavlin/femto"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type screen struct {
+	files   []editfile
+	current editfile
+	root    *tview.TreeNode // Folder of the explorer
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	screens = make(map[string]*screen) // By screen, see ui.GetCurrentScreen
+	cur     *screen
+	orphans []editfile // Files of the closed screens
+)
+
+// ****************************************************************************
+// current()
+// current returns the state of the Editor screen shown
+// ****************************************************************************
+func current() *screen {
+	if cur == nil {
+		cur = &screen{}
+	}
+	return cur
+}
+
+// ****************************************************************************
+// ShowScreen()
+// ShowScreen gives the files of the Editor screen shown to the editor,
+// keeping the files of the screens which are closed
+// ****************************************************************************
+func ShowScreen() {
+	for id, s := range screens {
+		if ui.IsScreenOpen(id) {
+			continue
+		}
+		delete(screens, id)
+		for _, f := range s.files {
+			if findBuffer(f.fName) == nil {
+				orphans = append(orphans, f)
+			}
+		}
+		if s == cur {
+			cur = nil
+		}
+	}
+	if ui.CurrentMode != ui.ModeTextEdit {
+		return
+	}
+	id := ui.GetCurrentScreen()
+	s, ok := screens[id]
+	if !ok {
+		s = &screen{}
+		screens[id] = s
+	}
+	cur = s
+	if s.current.buffer != nil {
+		ui.EdtMain.OpenBuffer(s.current.buffer)
+		go focusOpenFile(s.current.fName)
+	} else {
+		ui.EdtMain.OpenBuffer(femto.NewBufferFromString("", ""))
+		ui.TxtEditName.Clear()
+		ui.TblOpenFiles.Clear()
+	}
+	ui.TrvExplorer.SetRoot(s.root).SetCurrentNode(s.root)
+	ui.TblOpenFiles.SetTitle(fmt.Sprintf("Open Files (%d)", len(s.files)))
+}
+
+// ****************************************************************************
+// findBuffer()
+// findBuffer returns the buffer of fName when a screen, or a closed one, has
+// it open, or nil
+// ****************************************************************************
+func findBuffer(fName string) *femto.Buffer {
+	for _, f := range allFiles() {
+		if f.fName == fName {
+			return f.buffer
+		}
+	}
+	return nil
+}
+
+// ****************************************************************************
+// allFiles()
+// allFiles returns the files of all the screens, the closed ones included
+// ****************************************************************************
+func allFiles() []editfile {
+	files := append([]editfile(nil), orphans...)
+	for _, s := range screens {
+		files = append(files, s.files...)
+	}
+	return files
+}
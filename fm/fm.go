@@ -16,16 +16,18 @@ package fm
 
 import (
 	"fmt"
+	"gosh/audit"
 	"gosh/conf"
 	"gosh/dialog"
 	"gosh/edit"
 	"gosh/menu"
 	"gosh/preview"
 	"gosh/sq3"
+	"gosh/sudo"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 	"io/fs"
-	"log"
 	"os"
 	"sort"
 	"strconv"
@@ -34,7 +36,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -71,27 +72,23 @@ const (
 // ****************************************************************************
 // GLOBALS
 // ****************************************************************************
-var sortColumn = SORT_NAME
-var sortOrder = SORT_ASCENDING
 var (
-	Hidden       bool
 	MnuFiles     *menu.Menu
 	MnuFilesSort *menu.Menu
 	DlgConfirm   *dialog.Dialog
-	sel          []selecao
 )
-var pasteMode = PASTE_DEFAULT
-var pasteSource string
-var pasteTarget string
+var clip *pane   // The pane whose selection was copied or cut
+var forever bool // Deletes without the trash, until confirmed
 
 // ****************************************************************************
 // SetFilesMenu()
 // ****************************************************************************
 func SetFilesMenu() {
-	MnuFiles = MnuFiles.New("Actions", ui.GetCurrentScreen(), ui.TblFiles)
+	MnuFiles = MnuFiles.New("Actions", ui.GetCurrentScreen(), ui.TblFilesActive)
 	MnuFiles.AddItem("mnuEdit", "Edit", DoEdit, nil, true, false)
 	MnuFiles.AddItem("mnuSelect", "Select / Unselect All", SelectAll, nil, true, false)
-	MnuFiles.AddItem("mnuDelete", "Delete", DoDelete, nil, true, false)
+	MnuFiles.AddItem("mnuDelete", "Move to Trash", DoDelete, nil, true, false)
+	MnuFiles.AddItem("mnuDeleteForever", "Delete permanently", DoDeleteForever, nil, true, false)
 	MnuFiles.AddItem("mnuRename", "Rename", DoRename, nil, true, false)
 	MnuFiles.AddItem("mnuCopy", "Copy", DoCopy, nil, true, false)
 	MnuFiles.AddItem("mnuCut", "Cut", DoCut, nil, true, false)
@@ -101,9 +98,12 @@ func SetFilesMenu() {
 	MnuFiles.AddItem("mnuZip", "Zip", DoZip, nil, true, false)
 	MnuFiles.AddItem("mnuSnapshot", "Snapshot", DoSnapshot, nil, true, false)
 	MnuFiles.AddItem("mnuShowHiddenFiles", "Show hidden files", DoSwitchHiddenFiles, nil, true, false)
+	MnuFiles.AddItem("mnuDualPane", "Dual pane", DoSwitchDual, nil, true, false)
+	MnuFiles.AddItem("mnuTrash", "Trash", ShowTrash, nil, true, false)
+	MnuFiles.AddItem("mnuUndo", "Undo last operation", DoUndo, nil, len(journal) > 0, false)
 	ui.PgsApp.AddPage("dlgFileAction", MnuFiles.Popup(), true, false)
 
-	MnuFilesSort = MnuFilesSort.New("Sort by", ui.GetCurrentScreen(), ui.TblFiles)
+	MnuFilesSort = MnuFilesSort.New("Sort by", ui.GetCurrentScreen(), ui.TblFilesActive)
 	MnuFilesSort.AddItem("mnuSortNameA", "Name Ascending", doSortNameA, nil, false, true)
 	MnuFilesSort.AddItem("mnuSortNameD", "Name Descending", doSortNameD, nil, true, false)
 	MnuFilesSort.AddItem("mnuSortSizeA", "Size Ascending", doSortSizeA, nil, true, false)
@@ -118,8 +118,9 @@ func SetFilesMenu() {
 // ShowMenu()
 // ****************************************************************************
 func ShowMenu() {
-	idx, _ := ui.TblFiles.GetSelection()
-	targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
+	act := active()
+	idx, _ := act.table.GetSelection()
+	targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
 	// fName := filepath.Join(Cwd, ui.TblFiles.GetCell(idx, 1).Text)
 	if targetType == "FOLDER" {
 		MnuFiles.SetEnabled("mnuEdit", false)
@@ -127,7 +128,7 @@ func ShowMenu() {
 		MnuFiles.SetEnabled("mnuEncrypt", false)
 	}
 	if targetType == "FILE" {
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		mtype, xtype := preview.DisplayFilePreview(fName)
 		if mtype[:4] == "text" || strings.HasSuffix(xtype, "sqlite3") {
 			MnuFiles.SetEnabled("mnuEdit", true)
@@ -137,11 +138,17 @@ func ShowMenu() {
 		// MnuFiles.SetEnabled("mnuOpen", true)
 		// MnuFiles.SetEnabled("mnuEncrypt", true)
 	}
-	if Hidden {
+	if cur.hidden {
 		MnuFiles.SetLabel("mnuShowHiddenFiles", "Hide hidden files")
 	} else {
 		MnuFiles.SetLabel("mnuShowHiddenFiles", "Show hidden files")
 	}
+	MnuFiles.SetEnabled("mnuUndo", len(journal) > 0)
+	if cur.dual {
+		MnuFiles.SetLabel("mnuDualPane", "Single pane")
+	} else {
+		MnuFiles.SetLabel("mnuDualPane", "Dual pane")
+	}
 	ui.PgsApp.ShowPage("dlgFileAction")
 }
 
@@ -149,32 +156,52 @@ func ShowMenu() {
 // ShowMenuSort()
 // ****************************************************************************
 func ShowMenuSort() {
+	checkSort()
 	ui.PgsApp.ShowPage("dlgFileSort")
 }
 
 // ****************************************************************************
 // DoDelete()
+// DoDelete moves the selection, or the file, to the trash
 // ****************************************************************************
 func DoDelete(p any) {
-	if len(sel) == 0 {
-		idx, _ := ui.TblFiles.GetSelection()
-		if ui.TblFiles.GetCell(idx, 3).Text != conf.LABEL_PARENT_FOLDER {
-			targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	forever = false
+	askDelete("Trash", "Move %s to the trash ?")
+}
+
+// ****************************************************************************
+// DoDeleteForever()
+// DoDeleteForever deletes the selection, or the file, without the trash
+// ****************************************************************************
+func DoDeleteForever(p any) {
+	forever = true
+	askDelete("Delete", "Are you sure you want to delete %s for good ?")
+}
+
+// ****************************************************************************
+// askDelete()
+// ****************************************************************************
+func askDelete(verb string, question string) {
+	act := active()
+	if len(act.sel) == 0 {
+		idx, _ := act.table.GetSelection()
+		if act.table.GetCell(idx, 3).Text != conf.LABEL_PARENT_FOLDER {
+			targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
+			fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 			if targetType == "FILE" {
-				DlgConfirm = DlgConfirm.YesNoCancel(fmt.Sprintf("Delete File %s", fName), // Title
-					"Are you sure you want to delete this file ?", // Message
+				DlgConfirm = DlgConfirm.YesNoCancel(fmt.Sprintf("%s File %s", verb, fName), // Title
+					fmt.Sprintf(question, "this file"), // Message
 					DeleteFile,
 					idx,
-					ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+					ui.GetCurrentScreen(), act.table) // Focus return
 				ui.PgsApp.AddPage("dlgConfirmDeleteFile", DlgConfirm.Popup(), true, false)
 				ui.PgsApp.ShowPage("dlgConfirmDeleteFile")
 			} else {
-				DlgConfirm = DlgConfirm.YesNoCancel(fmt.Sprintf("Delete Folder %s", fName), // Title
-					"Are you sure you want to delete this folder and all its content ?", // Message
+				DlgConfirm = DlgConfirm.YesNoCancel(fmt.Sprintf("%s Folder %s", verb, fName), // Title
+					fmt.Sprintf(question, "this folder and all its content"), // Message
 					DeleteFolder,
 					idx,
-					ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+					ui.GetCurrentScreen(), act.table) // Focus return
 				ui.PgsApp.AddPage("dlgConfirmDeleteFolder", DlgConfirm.Popup(), true, false)
 				ui.PgsApp.ShowPage("dlgConfirmDeleteFolder")
 			}
@@ -182,35 +209,40 @@ func DoDelete(p any) {
 			ui.SetStatus("Can't delete parent folder")
 		}
 	} else {
-		DlgConfirm = DlgConfirm.YesNoCancel("Delete Selection", // Title
-			"Are you sure you want to delete all of these files ?", // Message
+		DlgConfirm = DlgConfirm.YesNoCancel(verb+" Selection", // Title
+			fmt.Sprintf(question, "all of these files"), // Message
 			DeleteSelection,
 			0,
-			ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+			ui.GetCurrentScreen(), act.table) // Focus return
 		ui.PgsApp.AddPage("dlgConfirmDeleteSelection", DlgConfirm.Popup(), true, false)
 		ui.PgsApp.ShowPage("dlgConfirmDeleteSelection")
 	}
 }
 
+// ****************************************************************************
+// deleteKind()
+// ****************************************************************************
+func deleteKind() opKind {
+	if forever {
+		return OP_DELETE
+	}
+	return OP_TRASH
+}
+
 // ****************************************************************************
 // DeleteFile()
 // ****************************************************************************
 func DeleteFile(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_YES {
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-		err := os.Remove(fName)
-		if err != nil {
-			ui.SetStatus(err.Error())
-		} else {
-			ui.SetStatus("Deleting file " + fName)
-			RefreshMe()
-		}
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+		enqueue(&operation{kind: deleteKind(), names: []string{fName}})
 	}
 	if button == dialog.BUTTON_NO {
-		ui.SetStatus("Aborting deletion of file " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Aborting deletion of file " + act.table.GetCell(idx, 2).Text)
 	}
 	if button == dialog.BUTTON_CANCEL {
-		ui.SetStatus("Cancelling deletion of file " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Cancelling deletion of file " + act.table.GetCell(idx, 2).Text)
 	}
 }
 
@@ -218,22 +250,16 @@ func DeleteFile(button dialog.DlgButton, idx int) {
 // DeleteFolder()
 // ****************************************************************************
 func DeleteFolder(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_YES {
-		ui.SetStatus("Deleting folder " + ui.TblFiles.GetCell(idx, 2).Text)
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-		err := os.RemoveAll(fName)
-		if err != nil {
-			ui.SetStatus(err.Error())
-		} else {
-			ui.SetStatus("Deleting folder " + fName)
-			RefreshMe()
-		}
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+		enqueue(&operation{kind: deleteKind(), names: []string{fName}})
 	}
 	if button == dialog.BUTTON_NO {
-		ui.SetStatus("Aborting deletion of folder " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Aborting deletion of folder " + act.table.GetCell(idx, 2).Text)
 	}
 	if button == dialog.BUTTON_CANCEL {
-		ui.SetStatus("Cancelling deletion of folder " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Cancelling deletion of folder " + act.table.GetCell(idx, 2).Text)
 	}
 }
 
@@ -241,27 +267,15 @@ func DeleteFolder(button dialog.DlgButton, idx int) {
 // DeleteSelection()
 // ****************************************************************************
 func DeleteSelection(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_YES {
-		for _, s := range sel {
-			if s.fType == "FOLDER" {
-				ui.SetStatus("Deleting folder " + s.fName)
-				err := os.RemoveAll(s.fName)
-				if err != nil {
-					ui.SetStatus(err.Error())
-				} else {
-					ui.SetStatus("Deleting folder " + s.fName)
-				}
-			} else {
-				err := os.Remove(s.fName)
-				if err != nil {
-					ui.SetStatus(err.Error())
-				} else {
-					ui.SetStatus("Deleting file " + s.fName)
-				}
-			}
+		var names []string
+		for _, s := range act.sel {
+			names = append(names, s.fName)
 		}
-		sel = nil
-		RefreshMe()
+		act.sel = nil
+		displaySelection()
+		enqueue(&operation{kind: deleteKind(), names: names})
 	}
 	if button == dialog.BUTTON_NO {
 		ui.SetStatus("Aborting deletion of selection")
@@ -271,21 +285,73 @@ func DeleteSelection(button dialog.DlgButton, idx int) {
 	}
 }
 
+// ****************************************************************************
+// deleteElevated()
+// deleteElevated deletes again with sudo what was refused
+// ****************************************************************************
+func deleteElevated(fNames []string, msg string) {
+	what := "delete " + fNames[0]
+	if len(fNames) > 1 {
+		what = fmt.Sprintf("delete %d files and folders", len(fNames))
+	}
+	sudo.Elevate(what,
+		func() error {
+			return sudo.Run("rm", append([]string{"-rf", "--"}, fNames...)...)
+		},
+		func(err error) {
+			for _, fName := range fNames {
+				audit.LogElevated(audit.ACTION_DELETE, fName, err)
+			}
+			if err != nil {
+				ui.SetStatus(err.Error())
+			} else {
+				ui.SetStatus(msg)
+			}
+			RefreshMe()
+		})
+}
+
+// ****************************************************************************
+// askDeleteElevated()
+// askDeleteElevated offers to delete for good with sudo what could not be
+// moved to the trash
+// ****************************************************************************
+func askDeleteElevated(fNames []string) {
+	what := filepath.Base(fNames[0])
+	if len(fNames) > 1 {
+		what = fmt.Sprintf("%d files and folders", len(fNames))
+	}
+	DlgConfirm = DlgConfirm.YesNo("Permission denied", // Title
+		fmt.Sprintf("%s can't be moved to the trash.\nDelete it for good with sudo ?", what), // Message
+		func(button dialog.DlgButton, idx int) {
+			if button == dialog.BUTTON_YES {
+				deleteElevated(fNames, fmt.Sprintf("Deleted %s for good", what))
+			} else {
+				ui.SetStatus("Aborting deletion of " + what)
+			}
+		},
+		0,
+		ui.GetCurrentScreen(), ui.App.GetFocus()) // Focus return
+	ui.PgsApp.AddPage("dlgConfirmDeleteElevated", DlgConfirm.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConfirmDeleteElevated")
+}
+
 // ****************************************************************************
 // DoRename(p any)
 // ****************************************************************************
 func DoRename(p any) {
-	if len(sel) == 0 {
-		idx, _ := ui.TblFiles.GetSelection()
-		targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	act := active()
+	if len(act.sel) == 0 {
+		idx, _ := act.table.GetSelection()
+		targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		if targetType == "FILE" {
 			DlgConfirm = DlgConfirm.Input(fmt.Sprintf("Rename File %s", fName), // Title
 				"Please, enter the new name :", // Message
 				filepath.Base(fName),
 				RenameFile,
 				idx,
-				ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+				ui.GetCurrentScreen(), act.table) // Focus return
 			ui.PgsApp.AddPage("dlgConfirmRenameFile", DlgConfirm.Popup(), true, false)
 			ui.PgsApp.ShowPage("dlgConfirmRenameFile")
 		} else {
@@ -294,7 +360,7 @@ func DoRename(p any) {
 				filepath.Base(fName),
 				RenameFolder,
 				idx,
-				ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+				ui.GetCurrentScreen(), act.table) // Focus return
 			ui.PgsApp.AddPage("dlgConfirmRenameFolder", DlgConfirm.Popup(), true, false)
 			ui.PgsApp.ShowPage("dlgConfirmRenameFolder")
 		}
@@ -307,21 +373,30 @@ func DoRename(p any) {
 // RenameFile()
 // ****************************************************************************
 func RenameFile(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_OK {
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-		fNew := filepath.Join(conf.Cwd, DlgConfirm.Value)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+		fNew := filepath.Join(act.dir, DlgConfirm.Value)
+		// os.Rename would replace it, and the undo could not bring it back
+		if _, err := os.Lstat(fNew); err == nil && fNew != fName {
+			ui.SetStatus(fmt.Sprintf("Can't rename %s, %s already exists", fName, fNew))
+			focusOn(fName)
+			return
+		}
 		err := os.Rename(fName, fNew)
+		audit.Log(audit.ACTION_RENAME, fName+" -> "+fNew, err)
 		if err != nil {
 			ui.SetStatus(err.Error())
 			focusOn(fName)
 		} else {
 			ui.SetStatus(fmt.Sprintf("Renaming file %s to %s", fName, fNew))
+			journalize("Rename "+filepath.Base(fName), change{kind: CHANGE_RENAME, from: fName, to: fNew})
 			RefreshMe()
 			focusOn(fNew)
 		}
 	}
 	if button == dialog.BUTTON_CANCEL {
-		ui.SetStatus("Cancelling renaming of file " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Cancelling renaming of file " + act.table.GetCell(idx, 2).Text)
 	}
 }
 
@@ -329,21 +404,30 @@ func RenameFile(button dialog.DlgButton, idx int) {
 // RenameFolder()
 // ****************************************************************************
 func RenameFolder(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_OK {
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-		fNew := filepath.Join(conf.Cwd, DlgConfirm.Value)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+		fNew := filepath.Join(act.dir, DlgConfirm.Value)
+		// os.Rename would replace it, and the undo could not bring it back
+		if _, err := os.Lstat(fNew); err == nil && fNew != fName {
+			ui.SetStatus(fmt.Sprintf("Can't rename %s, %s already exists", fName, fNew))
+			focusOn(fName)
+			return
+		}
 		err := os.Rename(fName, fNew)
+		audit.Log(audit.ACTION_RENAME, fName+" -> "+fNew, err)
 		if err != nil {
 			ui.SetStatus(err.Error())
 			focusOn(fName)
 		} else {
 			ui.SetStatus(fmt.Sprintf("Renaming folder %s to %s", fName, fNew))
+			journalize("Rename "+filepath.Base(fName), change{kind: CHANGE_RENAME, from: fName, to: fNew})
 			RefreshMe()
 			focusOn(fNew)
 		}
 	}
 	if button == dialog.BUTTON_CANCEL {
-		ui.SetStatus("Cancelling renaming of folder " + ui.TblFiles.GetCell(idx, 2).Text)
+		ui.SetStatus("Cancelling renaming of folder " + act.table.GetCell(idx, 2).Text)
 	}
 }
 
@@ -351,16 +435,18 @@ func RenameFolder(button dialog.DlgButton, idx int) {
 // DoTimestamp(p any)
 // ****************************************************************************
 func DoTimestamp(p any) {
-	if len(sel) == 0 {
-		idx, _ := ui.TblFiles.GetSelection()
-		targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	act := active()
+	if len(act.sel) == 0 {
+		idx, _ := act.table.GetSelection()
+		targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		current := time.Now()
 		if targetType == "FILE" {
 			fNew := utils.FilenameWithoutExtension(fName) + current.Format("_20060102-150405") + filepath.Ext(fName)
 			err := utils.CopyFile(fName, fNew)
 			if err == nil {
 				ui.SetStatus("File timestamped successfully")
+				journalize("Timestamp "+filepath.Base(fName), change{kind: CHANGE_CREATE, to: fNew})
 				RefreshMe()
 				focusOn(fNew)
 			} else {
@@ -373,6 +459,7 @@ func DoTimestamp(p any) {
 			err := utils.CopyDir(fName, fNew)
 			if err == nil {
 				ui.SetStatus("Folder timestamped successfully")
+				journalize("Timestamp "+filepath.Base(fName), change{kind: CHANGE_CREATE, to: fNew})
 				RefreshMe()
 				focusOn(fNew)
 			} else {
@@ -390,10 +477,11 @@ func DoTimestamp(p any) {
 // DoSnapshot(p any)
 // ****************************************************************************
 func DoSnapshot(p any) {
-	if len(sel) == 0 {
-		idx, _ := ui.TblFiles.GetSelection()
-		targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	act := active()
+	if len(act.sel) == 0 {
+		idx, _ := act.table.GetSelection()
+		targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		current := time.Now()
 		if targetType == "FILE" {
 			fNew := utils.FilenameWithoutExtension(fName) + current.Format("_20060102-150405") + filepath.Ext(fName)
@@ -403,6 +491,7 @@ func DoSnapshot(p any) {
 				utils.ZipFile(fArchive, fNew)
 				os.Remove(fNew)
 				ui.SetStatus("File snapshoted successfully")
+				journalize("Snapshot "+filepath.Base(fName), change{kind: CHANGE_CREATE, to: fArchive})
 				RefreshMe()
 				focusOn(fArchive)
 			} else {
@@ -418,6 +507,7 @@ func DoSnapshot(p any) {
 				utils.ZipFolder(fArchive, fNew)
 				os.RemoveAll(fNew)
 				ui.SetStatus("Folder snapshoted successfully")
+				journalize("Snapshot "+filepath.Base(fName), change{kind: CHANGE_CREATE, to: fArchive})
 				RefreshMe()
 				focusOn(fArchive)
 			} else {
@@ -435,46 +525,31 @@ func DoSnapshot(p any) {
 // DoZip(p any)
 // ****************************************************************************
 func DoZip(p any) {
-	if len(sel) == 0 {
-		idx, _ := ui.TblFiles.GetSelection()
-		targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	act := active()
+	if len(act.sel) == 0 {
+		idx, _ := act.table.GetSelection()
+		targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		if targetType == "FILE" {
 			fArchive := utils.FilenameWithoutExtension(fName) + ".zip"
 			fArchive = utils.GetFilenameWhichDoesntExist(fArchive)
-			utils.ZipFile(fArchive, fName)
-			ui.SetStatus("File zipped successfully")
-			RefreshMe()
-			focusOn(fArchive)
+			enqueue(&operation{kind: OP_ZIP, names: []string{fName}, dir: act.dir, archive: fArchive, to: act})
 		} else {
+			// The content of the folder is at the root of the archive
 			fArchive := fName + ".zip"
 			fArchive = utils.GetFilenameWhichDoesntExist(fArchive)
-			utils.ZipFolder(fArchive, fName)
-			ui.SetStatus("Folder zipped successfully")
-			RefreshMe()
-			focusOn(fArchive)
+			enqueue(&operation{kind: OP_ZIP, names: []string{fName}, dir: fName, archive: fArchive, to: act})
 		}
 	} else {
-		dirTemp, err := os.MkdirTemp("", "temp")
-		if err != nil {
-			log.Fatal(err)
+		var names []string
+		for _, s := range act.sel {
+			names = append(names, s.fName)
 		}
-		defer os.RemoveAll(dirTemp)
-		for _, s := range sel {
-			if s.fType == "FOLDER" {
-				_ = utils.CopyFolderIntoFolder(s.fName, dirTemp)
-			}
-			if s.fType == "FILE" {
-				_ = utils.CopyFileIntoFolder(s.fName, dirTemp)
-			}
-		}
-		fArchive := sel[0].fName + ".zip"
+		fArchive := act.sel[0].fName + ".zip"
 		fArchive = utils.GetFilenameWhichDoesntExist(fArchive)
-		utils.ZipFolder(fArchive, dirTemp)
-		ui.SetStatus(fmt.Sprintf("Selection zipped successfully to file %s", fArchive))
-		sel = nil
-		RefreshMe()
-		focusOn(fArchive)
+		act.sel = nil
+		displaySelection()
+		enqueue(&operation{kind: OP_ZIP, names: names, dir: act.dir, archive: fArchive, to: act})
 	}
 }
 
@@ -482,12 +557,13 @@ func DoZip(p any) {
 // DoNewFile(p any)
 // ****************************************************************************
 func DoNewFile(p any) {
+	act := active()
 	DlgConfirm = DlgConfirm.Input("Create New File", // Title
 		"Please, enter the name for this new file :", // Message
 		"new_file",
 		CreateNewFile,
 		0,
-		ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+		ui.GetCurrentScreen(), act.table) // Focus return
 	ui.PgsApp.AddPage("dlgCreateNewFile", DlgConfirm.Popup(), true, false)
 	ui.PgsApp.ShowPage("dlgCreateNewFile")
 
@@ -497,12 +573,13 @@ func DoNewFile(p any) {
 // DoNewFolder(p any)
 // ****************************************************************************
 func DoNewFolder(p any) {
+	act := active()
 	DlgConfirm = DlgConfirm.Input("Create New Folder", // Title
 		"Please, enter the name for this new folder :", // Message
 		"new_folder",
 		CreateNewFolder,
 		0,
-		ui.GetCurrentScreen(), ui.TblFiles) // Focus return
+		ui.GetCurrentScreen(), act.table) // Focus return
 	ui.PgsApp.AddPage("dlgCreateNewFolder", DlgConfirm.Popup(), true, false)
 	ui.PgsApp.ShowPage("dlgCreateNewFolder")
 
@@ -512,8 +589,9 @@ func DoNewFolder(p any) {
 // CreateNewFile()
 // ****************************************************************************
 func CreateNewFile(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_OK {
-		fNew := filepath.Join(conf.Cwd, DlgConfirm.Value)
+		fNew := filepath.Join(act.dir, DlgConfirm.Value)
 		if utils.IsFileExist(fNew) {
 			ui.SetStatus(fmt.Sprintf("File %s already exists", fNew))
 		} else {
@@ -522,6 +600,7 @@ func CreateNewFile(button dialog.DlgButton, idx int) {
 			} else {
 				f.Close()
 				ui.SetStatus(fmt.Sprintf("File %s successfully created", fNew))
+				journalize("New file "+filepath.Base(fNew), change{kind: CHANGE_CREATE, to: fNew})
 				RefreshMe()
 				focusOn(fNew)
 			}
@@ -536,8 +615,9 @@ func CreateNewFile(button dialog.DlgButton, idx int) {
 // CreateNewFolder()
 // ****************************************************************************
 func CreateNewFolder(button dialog.DlgButton, idx int) {
+	act := active()
 	if button == dialog.BUTTON_OK {
-		fNew := filepath.Join(conf.Cwd, DlgConfirm.Value)
+		fNew := filepath.Join(act.dir, DlgConfirm.Value)
 		if utils.IsFileExist(fNew) {
 			ui.SetStatus(fmt.Sprintf("Folder %s already exists", fNew))
 		} else {
@@ -545,6 +625,7 @@ func CreateNewFolder(button dialog.DlgButton, idx int) {
 				ui.SetStatus(err.Error())
 			} else {
 				ui.SetStatus(fmt.Sprintf("Folder %s successfully created", fNew))
+				journalize("New folder "+filepath.Base(fNew), change{kind: CHANGE_CREATE, to: fNew})
 				RefreshMe()
 				focusOn(fNew)
 			}
@@ -559,31 +640,33 @@ func CreateNewFolder(button dialog.DlgButton, idx int) {
 // DoCopy(p any)
 // ****************************************************************************
 func DoCopy(p any) {
-	idx, _ := ui.TblFiles.GetSelection()
-	if ui.TblFiles.GetCell(idx, 0).Text == "   " {
-		if strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text) == "FILE" {
+	act := active()
+	idx, _ := act.table.GetSelection()
+	if act.table.GetCell(idx, 0).Text == "   " {
+		if strings.TrimSpace(act.table.GetCell(idx, 4).Text) == "FILE" {
 			// SELECT FILE
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-			fSize, _ := strconv.Atoi(ui.TblFiles.GetCell(idx, 6).Text)
+			fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+			fSize, _ := strconv.Atoi(act.table.GetCell(idx, 6).Text)
 			ui.SetStatus(fName)
-			ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-			ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-			sel = append(sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
+			act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+			act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+			act.sel = append(act.sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
 		} else {
 			// SELECT FOLDER
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+			fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 			fSize, _ := utils.DirSize(fName)
 			ui.SetStatus(fName)
-			ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-			ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-			sel = append(sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
+			act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+			act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+			act.sel = append(act.sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
 		}
-		pasteMode = PASTE_COPY
-		pasteSource = conf.Cwd
+		act.mode = PASTE_COPY
+		act.from = act.dir
+		clip = act
 		displaySelection()
 	}
 }
@@ -592,31 +675,33 @@ func DoCopy(p any) {
 // DoCut(p any)
 // ****************************************************************************
 func DoCut(p any) {
-	idx, _ := ui.TblFiles.GetSelection()
-	if ui.TblFiles.GetCell(idx, 0).Text == "   " {
-		if strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text) == "FILE" {
+	act := active()
+	idx, _ := act.table.GetSelection()
+	if act.table.GetCell(idx, 0).Text == "   " {
+		if strings.TrimSpace(act.table.GetCell(idx, 4).Text) == "FILE" {
 			// SELECT FILE
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-			fSize, _ := strconv.Atoi(ui.TblFiles.GetCell(idx, 6).Text)
+			fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+			fSize, _ := strconv.Atoi(act.table.GetCell(idx, 6).Text)
 			ui.SetStatus(fName)
-			ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-			ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-			sel = append(sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
+			act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+			act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+			act.sel = append(act.sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
 		} else {
 			// SELECT FOLDER
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+			fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 			fSize, _ := utils.DirSize(fName)
 			ui.SetStatus(fName)
-			ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-			ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-			ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-			sel = append(sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
+			act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+			act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+			act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+			act.sel = append(act.sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
 		}
-		pasteMode = PASTE_CUT
-		pasteSource = conf.Cwd
+		act.mode = PASTE_CUT
+		act.from = act.dir
+		clip = act
 		displaySelection()
 	}
 }
@@ -625,56 +710,29 @@ func DoCut(p any) {
 // DoPaste(p any)
 // ****************************************************************************
 func DoPaste(p any) {
-	var fName string
-	if conf.Cwd == pasteSource {
+	act := active()
+	src := act
+	if clip != nil {
+		src = clip
+	}
+	if act.dir == src.from {
 		ui.SetStatus("Can't paste into the same folder")
 	} else {
-		pasteTarget = conf.Cwd
-		if pasteMode == PASTE_COPY || pasteMode == PASTE_DEFAULT {
-			ui.PleaseWait()
-			for _, s := range sel {
-				if s.fType == "FOLDER" {
-					_ = utils.CopyFolderIntoFolder(s.fName, pasteTarget)
-				}
-				if s.fType == "FILE" {
-					_ = utils.CopyFileIntoFolder(s.fName, pasteTarget)
-				}
-				fName = s.fName
-			}
-			sel = nil
-			RefreshMe()
-			                ui.JobsDone()
-			focusOn(fName)
+		var names []string
+		for _, s := range src.sel {
+			names = append(names, s.fName)
 		}
-		if pasteMode == PASTE_CUT {
-			ui.PleaseWait()
-			for _, s := range sel {
-				if s.fType == "FOLDER" {
-					err := utils.CopyFolderIntoFolder(s.fName, pasteTarget)
-					if err != nil {
-						os.RemoveAll(s.fName)
-					} else {
-						ui.SetStatus(err.Error())
-					}
-				}
-				if s.fType == "FILE" {
-					err := utils.CopyFileIntoFolder(s.fName, pasteTarget)
-					if err == nil {
-						err := os.Remove(s.fName)
-						if err != nil {
-							ui.SetStatus(err.Error())
-						}
-					} else {
-						ui.SetStatus(err.Error())
-					}
-				}
-				fName = s.fName
-			}
-			sel = nil
-			RefreshMe()
-			                ui.JobsDone()
-			focusOn(fName)
+		if names == nil {
+			return
+		}
+		kind := OP_COPY
+		if src.mode == PASTE_CUT {
+			kind = OP_MOVE
 		}
+		src.sel = nil
+		clip = nil
+		displaySelection()
+		enqueue(&operation{kind: kind, names: names, dir: act.dir, to: act})
 	}
 }
 
@@ -682,7 +740,11 @@ func DoPaste(p any) {
 // DoSwitchHiddenFiles(p any)
 // ****************************************************************************
 func DoSwitchHiddenFiles(p any) {
-	Hidden = !Hidden
+	active()
+	cur.hidden = !cur.hidden
+	if IsDual() {
+		opposite().refresh()
+	}
 	RefreshMe()
 }
 
@@ -690,99 +752,147 @@ func DoSwitchHiddenFiles(p any) {
 // ShowFiles()
 // ****************************************************************************
 func ShowFiles() {
+	active().show()
+}
+
+// ****************************************************************************
+// show() pane
+// show fills the table of p with the files of its folder
+// ****************************************************************************
+func (p *pane) show() {
 	// ui.TxtSelection.Clear()
-	files, err := os.ReadDir(conf.Cwd)
+	files, err := os.ReadDir(p.dir)
 	if err != nil {
 		ui.SetStatus(err.Error())
 	}
 
-	ui.TblFiles.Clear()
+	p.table.Clear()
 	ui.TxtFileInfo.Clear()
 	iStart := 0
 	iFile := 0
-	if conf.Cwd != "/" {
-		ui.TblFiles.SetCell(0, 0, tview.NewTableCell("   "))
-		ui.TblFiles.SetCell(0, 1, tview.NewTableCell(" "))
-		ui.TblFiles.SetCell(0, 2, tview.NewTableCell("..").SetTextColor(tcell.ColorYellow))
-		ui.TblFiles.SetCell(0, 3, tview.NewTableCell(conf.LABEL_PARENT_FOLDER))
-		ui.TblFiles.SetCell(0, 4, tview.NewTableCell(" "))
-		ui.TblFiles.SetCell(0, 5, tview.NewTableCell(" "))
-		ui.TblFiles.SetCell(0, 6, tview.NewTableCell(" "))
-		ui.TblFiles.SetCell(0, 7, tview.NewTableCell(" "))
+	if p.dir != "/" {
+		p.table.SetCell(0, 0, tview.NewTableCell("   "))
+		p.table.SetCell(0, 1, tview.NewTableCell(" "))
+		p.table.SetCell(0, 2, tview.NewTableCell("..").SetTextColor(theme.File))
+		p.table.SetCell(0, 3, tview.NewTableCell(conf.LABEL_PARENT_FOLDER))
+		p.table.SetCell(0, 4, tview.NewTableCell(" "))
+		p.table.SetCell(0, 5, tview.NewTableCell(" "))
+		p.table.SetCell(0, 6, tview.NewTableCell(" "))
+		p.table.SetCell(0, 7, tview.NewTableCell(" "))
 		iStart = 1
 	}
-	ui.TxtPath.SetText(conf.Cwd)
-	switch sortColumn {
+	p.path.SetText(p.dir)
+	switch p.sortColumn {
 	case SORT_NAME:
-		if sortOrder == SORT_ASCENDING {
+		if p.sortOrder == SORT_ASCENDING {
 			SortFileNameAscend(files)
 		} else {
 			SortFileNameDescend(files)
 		}
 	case SORT_SIZE:
-		if sortOrder == SORT_ASCENDING {
+		if p.sortOrder == SORT_ASCENDING {
 			SortFileSizeAscend(files)
 		} else {
 			SortFileSizeDescend(files)
 		}
 	case SORT_TIME:
-		if sortOrder == SORT_ASCENDING {
+		if p.sortOrder == SORT_ASCENDING {
 			SortFileModAscend(files)
 		} else {
 			SortFileModDescend(files)
 		}
 	}
 	for _, file := range files {
-		if !Hidden && file.Name()[0] == '.' { // Don't want to see hidden files ?
+		if !cur.hidden && file.Name()[0] == '.' { // Don't want to see hidden files ?
 			continue
 		}
-		ui.TblFiles.SetCell(iFile+iStart, 0, tview.NewTableCell("   "))
-		ui.TblFiles.SetCell(iFile+iStart, 1, tview.NewTableCell(" "))
-		ui.TblFiles.SetCell(iFile+iStart, 2, tview.NewTableCell(file.Name()).SetTextColor(tcell.ColorYellow))
+		p.table.SetCell(iFile+iStart, 0, tview.NewTableCell("   "))
+		p.table.SetCell(iFile+iStart, 1, tview.NewTableCell(" "))
+		p.table.SetCell(iFile+iStart, 2, tview.NewTableCell(file.Name()).SetTextColor(theme.File))
 		fi, err := file.Info()
 		if err == nil {
-			ui.TblFiles.SetCell(iFile+iStart, 3, tview.NewTableCell(fi.ModTime().String()[0:19]))
+			p.table.SetCell(iFile+iStart, 3, tview.NewTableCell(fi.ModTime().String()[0:19]))
 			if fi.IsDir() {
-				ui.TblFiles.SetCell(iFile+iStart, 4, tview.NewTableCell("  FOLDER"))
-				ui.TblFiles.SetCell(0, 7, tview.NewTableCell(" "))
-				ui.TblFiles.GetCell(iFile+iStart, 2).SetTextColor(tcell.ColorLightGreen)
+				p.table.SetCell(iFile+iStart, 4, tview.NewTableCell("  FOLDER"))
+				p.table.SetCell(0, 7, tview.NewTableCell(" "))
+				p.table.GetCell(iFile+iStart, 2).SetTextColor(theme.Folder)
 			} else {
 				if fi.Mode().String()[0] == 'L' {
-					ui.TblFiles.SetCell(iFile+iStart, 1, tview.NewTableCell("🔗"))
-					ui.TblFiles.SetCell(iFile+iStart, 4, tview.NewTableCell("  LINK"))
+					p.table.SetCell(iFile+iStart, 1, tview.NewTableCell("🔗"))
+					p.table.SetCell(iFile+iStart, 4, tview.NewTableCell("  LINK"))
 
-					lnk, err := os.Readlink(filepath.Join(conf.Cwd, ui.TblFiles.GetCell(iFile+iStart, 2).Text))
+					lnk, err := os.Readlink(filepath.Join(p.dir, p.table.GetCell(iFile+iStart, 2).Text))
 					if err == nil {
-						ui.TblFiles.SetCell(iFile+iStart, 7, tview.NewTableCell(lnk))
+						p.table.SetCell(iFile+iStart, 7, tview.NewTableCell(lnk))
 					} else {
-						ui.TblFiles.SetCell(iFile+iStart, 7, tview.NewTableCell(err.Error()))
+						p.table.SetCell(iFile+iStart, 7, tview.NewTableCell(err.Error()))
 					}
 				} else {
-					ui.TblFiles.SetCell(iFile+iStart, 4, tview.NewTableCell("  FILE"))
-					ui.TblFiles.SetCell(0, 7, tview.NewTableCell(" "))
+					p.table.SetCell(iFile+iStart, 4, tview.NewTableCell("  FILE"))
+					p.table.SetCell(0, 7, tview.NewTableCell(" "))
 					// Is the file executable ?
 					if fi.Mode()&0111 != 0 {
-						ui.TblFiles.SetCell(iFile+iStart, 1, tview.NewTableCell("⚙"))
-						ui.TblFiles.GetCell(iFile+iStart, 2).SetTextColor(tcell.ColorLightYellow)
+						p.table.SetCell(iFile+iStart, 1, tview.NewTableCell("⚙"))
+						p.table.GetCell(iFile+iStart, 2).SetTextColor(theme.Executable)
 					}
 				}
 			}
-			ui.TblFiles.SetCell(iFile+iStart, 5, tview.NewTableCell(fi.Mode().String()))
-			ui.TblFiles.SetCell(iFile+iStart, 6, tview.NewTableCell(strconv.FormatInt(fi.Size(), 10)).SetAlign(tview.AlignRight))
+			p.table.SetCell(iFile+iStart, 5, tview.NewTableCell(fi.Mode().String()))
+			p.table.SetCell(iFile+iStart, 6, tview.NewTableCell(strconv.FormatInt(fi.Size(), 10)).SetAlign(tview.AlignRight))
 		}
 		iFile++
 	}
-	ui.TblFiles.Select(0, 0)
+	// The cursor stays on its row when the folder is read again
+	row, _ := p.table.GetSelection()
+	if p.shown != p.dir || row >= p.table.GetRowCount() {
+		row = 0
+	}
+	p.table.Select(row, 0)
+	p.shown = p.dir
+}
+
+// ****************************************************************************
+// refresh() pane
+// ****************************************************************************
+func (p *pane) refresh() {
+	p.show()
+	p.applySelection()
+}
+
+// ****************************************************************************
+// setDir() pane
+// setDir changes the folder of p, the one of the prompt when p is in use
+// ****************************************************************************
+func (p *pane) setDir(dir string) {
+	p.dir = dir
+	if p == active() {
+		conf.Cwd = dir
+	}
 }
 
 // ****************************************************************************
 // RefreshMe()
 // ****************************************************************************
 func RefreshMe() {
-	ShowFiles()
-	applySelection()
+	act := active()
+	act.refresh()
 	displaySelection()
-	ui.App.SetFocus(ui.TblFiles)
+	ui.App.SetFocus(act.table)
+}
+
+// ****************************************************************************
+// SetCwd()
+// SetCwd changes the current folder, keeping the files list in sync
+// ****************************************************************************
+func SetCwd(dir string) {
+	if cur == nil {
+		conf.Cwd = dir
+		return
+	}
+	// The active pane of the last Files screen shown follows the prompt
+	act := active()
+	act.setDir(dir)
+	act.refresh()
 }
 
 // ****************************************************************************
@@ -851,145 +961,93 @@ func SortFileModDescend(files []fs.DirEntry) {
 // doSortNameA(p any)
 // ****************************************************************************
 func doSortNameA(p any) {
-	sortColumn = SORT_NAME
-	sortOrder = SORT_ASCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", false)
-	MnuFilesSort.SetEnabled("mnuSortNameD", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", true)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", true)
-	MnuFilesSort.SetChecked("mnuSortNameD", false)
-	MnuFilesSort.SetChecked("mnuSortSizeA", false)
-	MnuFilesSort.SetChecked("mnuSortSizeD", false)
-	MnuFilesSort.SetChecked("mnuSortTimeA", false)
-	MnuFilesSort.SetChecked("mnuSortTimeD", false)
-	RefreshMe()
+	setSort(SORT_NAME, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // doSortNameD(p any)
 // ****************************************************************************
 func doSortNameD(p any) {
-	sortColumn = SORT_NAME
-	sortOrder = SORT_DESCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", true)
-	MnuFilesSort.SetEnabled("mnuSortNameD", false)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", true)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", false)
-	MnuFilesSort.SetChecked("mnuSortNameD", true)
-	MnuFilesSort.SetChecked("mnuSortSizeA", false)
-	MnuFilesSort.SetChecked("mnuSortSizeD", false)
-	MnuFilesSort.SetChecked("mnuSortTimeA", false)
-	MnuFilesSort.SetChecked("mnuSortTimeD", false)
-	RefreshMe()
+	setSort(SORT_NAME, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // doSortSizeA(p any)
 // ****************************************************************************
 func doSortSizeA(p any) {
-	sortColumn = SORT_SIZE
-	sortOrder = SORT_ASCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", true)
-	MnuFilesSort.SetEnabled("mnuSortNameD", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", false)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", true)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", false)
-	MnuFilesSort.SetChecked("mnuSortNameD", false)
-	MnuFilesSort.SetChecked("mnuSortSizeA", true)
-	MnuFilesSort.SetChecked("mnuSortSizeD", false)
-	MnuFilesSort.SetChecked("mnuSortTimeA", false)
-	MnuFilesSort.SetChecked("mnuSortTimeD", false)
-	RefreshMe()
+	setSort(SORT_SIZE, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // doSortSizeD(p any)
 // ****************************************************************************
 func doSortSizeD(p any) {
-	sortColumn = SORT_SIZE
-	sortOrder = SORT_DESCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", true)
-	MnuFilesSort.SetEnabled("mnuSortNameD", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", false)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", true)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", false)
-	MnuFilesSort.SetChecked("mnuSortNameD", false)
-	MnuFilesSort.SetChecked("mnuSortSizeA", false)
-	MnuFilesSort.SetChecked("mnuSortSizeD", true)
-	MnuFilesSort.SetChecked("mnuSortTimeA", false)
-	MnuFilesSort.SetChecked("mnuSortTimeD", false)
-	RefreshMe()
+	setSort(SORT_SIZE, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // doSortTimeA(p any)
 // ****************************************************************************
 func doSortTimeA(p any) {
-	sortColumn = SORT_TIME
-	sortOrder = SORT_ASCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", true)
-	MnuFilesSort.SetEnabled("mnuSortNameD", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", false)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", true)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", false)
-	MnuFilesSort.SetChecked("mnuSortNameD", false)
-	MnuFilesSort.SetChecked("mnuSortSizeA", false)
-	MnuFilesSort.SetChecked("mnuSortSizeD", false)
-	MnuFilesSort.SetChecked("mnuSortTimeA", true)
-	MnuFilesSort.SetChecked("mnuSortTimeD", false)
-	RefreshMe()
+	setSort(SORT_TIME, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // doSortTimeD(p any)
 // ****************************************************************************
 func doSortTimeD(p any) {
-	sortColumn = SORT_TIME
-	sortOrder = SORT_DESCENDING
-	MnuFilesSort.SetEnabled("mnuSortNameA", true)
-	MnuFilesSort.SetEnabled("mnuSortNameD", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeA", true)
-	MnuFilesSort.SetEnabled("mnuSortSizeD", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeA", true)
-	MnuFilesSort.SetEnabled("mnuSortTimeD", false)
-
-	MnuFilesSort.SetChecked("mnuSortNameA", false)
-	MnuFilesSort.SetChecked("mnuSortNameD", false)
-	MnuFilesSort.SetChecked("mnuSortSizeA", false)
-	MnuFilesSort.SetChecked("mnuSortSizeD", false)
-	MnuFilesSort.SetChecked("mnuSortTimeA", false)
-	MnuFilesSort.SetChecked("mnuSortTimeD", true)
+	setSort(SORT_TIME, SORT_DESCENDING)
+}
+
+// ****************************************************************************
+// setSort()
+// setSort sorts the active pane, each pane having its own sort
+// ****************************************************************************
+func setSort(column SortColumn, order int) {
+	act := active()
+	act.sortColumn = column
+	act.sortOrder = order
+	checkSort()
 	RefreshMe()
 }
 
+// ****************************************************************************
+// checkSort()
+// checkSort checks the sort of the active pane in the menu
+// ****************************************************************************
+func checkSort() {
+	act := active()
+	items := []struct {
+		name   string
+		column SortColumn
+		order  int
+	}{
+		{"mnuSortNameA", SORT_NAME, SORT_ASCENDING},
+		{"mnuSortNameD", SORT_NAME, SORT_DESCENDING},
+		{"mnuSortSizeA", SORT_SIZE, SORT_ASCENDING},
+		{"mnuSortSizeD", SORT_SIZE, SORT_DESCENDING},
+		{"mnuSortTimeA", SORT_TIME, SORT_ASCENDING},
+		{"mnuSortTimeD", SORT_TIME, SORT_DESCENDING},
+	}
+	for _, item := range items {
+		current := item.column == act.sortColumn && item.order == act.sortOrder
+		MnuFilesSort.SetEnabled(item.name, !current)
+		MnuFilesSort.SetChecked(item.name, current)
+	}
+}
+
 // ****************************************************************************
 // ProceedFileAction()
 // ****************************************************************************
 func ProceedFileAction() {
+	act := active()
 	ui.PleaseWait()
-	idx, _ := ui.TblFiles.GetSelection()
+	idx, _ := act.table.GetSelection()
 	// TODO : manage LINK
-	targetType := strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text)
+	targetType := strings.TrimSpace(act.table.GetCell(idx, 4).Text)
 	if targetType == "LINK" {
 		targetType = "FILE"
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		fFile, err := os.Readlink(fName)
 		if err == nil {
 			info, err := os.Stat(fFile)
@@ -1005,17 +1063,21 @@ func ProceedFileAction() {
 		}
 	}
 	if targetType == "FILE" { // or type(readlink)==file
-		fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+		fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 		ui.FrmFileInfo.Clear()
 
-		mtype, xmtype := preview.DisplayFilePreview(fName)
-		size, _ := strconv.ParseFloat(ui.TblFiles.GetCell(idx, 6).Text, 64)
-		if size <= conf.HASH_THRESHOLD_SIZE || true { // !!! TEST SKIPPED !!!
+		size, _ := strconv.ParseFloat(act.table.GetCell(idx, 6).Text, 64)
+		threshold := ui.MyConfig.Files.HashThresholdSize
+		if threshold <= 0 {
+			threshold = conf.HASH_THRESHOLD_SIZE
+		}
+		if size <= float64(threshold) {
+			mtype, xmtype := preview.DisplayFilePreview(fName)
 			infos := map[string]string{
-				"00Name":          ui.TblFiles.GetCell(idx, 2).Text,
-				"01Change Date":   ui.TblFiles.GetCell(idx, 3).Text,
-				"02Access":        ui.TblFiles.GetCell(idx, 5).Text,
-				"03Size":          ui.TblFiles.GetCell(idx, 6).Text + " Bytes (" + utils.HumanFileSize(size) + ")",
+				"00Name":          act.table.GetCell(idx, 2).Text,
+				"01Change Date":   act.table.GetCell(idx, 3).Text,
+				"02Access":        act.table.GetCell(idx, 5).Text,
+				"03Size":          act.table.GetCell(idx, 6).Text + " Bytes (" + utils.HumanFileSize(size) + ")",
 				"04Mime Type":     mtype,
 				"05Extended Mime": xmtype,
 			}
@@ -1023,111 +1085,113 @@ func ProceedFileAction() {
 
 		} else {
 			infos := map[string]string{
-				"00Name":        ui.TblFiles.GetCell(idx, 2).Text,
-				"01Change Date": ui.TblFiles.GetCell(idx, 3).Text,
-				"02Access":      ui.TblFiles.GetCell(idx, 5).Text,
-				"03Size":        ui.TblFiles.GetCell(idx, 6).Text + " Bytes (" + utils.HumanFileSize(size) + ")",
+				"00Name":        act.table.GetCell(idx, 2).Text,
+				"01Change Date": act.table.GetCell(idx, 3).Text,
+				"02Access":      act.table.GetCell(idx, 5).Text,
+				"03Size":        act.table.GetCell(idx, 6).Text + " Bytes (" + utils.HumanFileSize(size) + ")",
 			}
 			ui.DisplayMap(ui.FrmFileInfo, infos)
 			ui.TxtFileInfo.SetText("VERY BIG FILE, can't display a preview.")
 		}
 	} else { //  or type(readlink)==folder
-		conf.Cwd = filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+		act.setDir(filepath.Join(act.dir, act.table.GetCell(idx, 2).Text))
 		ui.FrmFileInfo.Clear()
-		nFiles, nFolders, err := utils.NumberOfFilesAndFolders(conf.Cwd)
+		nFiles, nFolders, err := utils.NumberOfFilesAndFolders(act.dir)
 		if err != nil {
 			ui.SetStatus(err.Error())
 		}
 		infos := map[string]string{
-			"00Name":    conf.Cwd,
+			"00Name":    act.dir,
 			"01Files":   strconv.Itoa(nFiles),
 			"02Folders": strconv.Itoa(nFolders),
 		}
 		ui.DisplayMap(ui.FrmFileInfo, infos)
 		ShowFiles()
-		ui.App.SetFocus(ui.TblFiles)
+		ui.App.SetFocus(act.table)
 	}
 	// ui.App.Sync()
-	                ui.JobsDone()
+	ui.JobsDone()
 }
 
 // ****************************************************************************
 // ProceedFileSelect()
 // ****************************************************************************
 func ProceedFileSelect() {
+	act := active()
 	ui.PleaseWait()
-	idx, _ := ui.TblFiles.GetSelection()
-	if ui.TblFiles.GetCell(idx, 3).Text != conf.LABEL_PARENT_FOLDER {
-		if strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text) == "FILE" {
-			if ui.TblFiles.GetCell(idx, 0).Text == "   " {
+	idx, _ := act.table.GetSelection()
+	if act.table.GetCell(idx, 3).Text != conf.LABEL_PARENT_FOLDER {
+		if strings.TrimSpace(act.table.GetCell(idx, 4).Text) == "FILE" {
+			if act.table.GetCell(idx, 0).Text == "   " {
 				// SELECT FILE
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-				fSize, _ := strconv.Atoi(ui.TblFiles.GetCell(idx, 6).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+				fSize, _ := strconv.Atoi(act.table.GetCell(idx, 6).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-				sel = append(sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
+				act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+				act.sel = append(act.sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
 				displaySelection()
 			} else {
 				// UNSELECT FILE
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell("   "))
-				if ui.TblFiles.GetCell(idx, 1).Text == "⚙" {
-					ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_EXECUTABLE)
-					ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_EXECUTABLE)
-					ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_EXECUTABLE)
+				act.table.SetCell(idx, 0, tview.NewTableCell("   "))
+				if act.table.GetCell(idx, 1).Text == "⚙" {
+					act.table.GetCell(idx, 0).SetTextColor(theme.Executable)
+					act.table.GetCell(idx, 1).SetTextColor(theme.Executable)
+					act.table.GetCell(idx, 2).SetTextColor(theme.Executable)
 				} else {
-					ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_FILE)
-					ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_FILE)
-					ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_FILE)
+					act.table.GetCell(idx, 0).SetTextColor(theme.File)
+					act.table.GetCell(idx, 1).SetTextColor(theme.File)
+					act.table.GetCell(idx, 2).SetTextColor(theme.File)
 				}
-				sel = findAndDelete(sel, selecao{fName: fName, fSize: 0, fType: "FILE"})
+				act.sel = findAndDelete(act.sel, selecao{fName: fName, fSize: 0, fType: "FILE"})
 				displaySelection()
 			}
 		} else {
-			if ui.TblFiles.GetCell(idx, 0).Text == "   " {
+			if act.table.GetCell(idx, 0).Text == "   " {
 				// SELECT FOLDER
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				fSize, _ := utils.DirSize(fName)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-				sel = append(sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
+				act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+				act.sel = append(act.sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
 				displaySelection()
 			} else {
 				// UNSELECT FOLDER
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell("   "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_FOLDER)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_FOLDER)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_FOLDER)
-				sel = findAndDelete(sel, selecao{fName: fName, fSize: 0, fType: "FOLDER"})
+				act.table.SetCell(idx, 0, tview.NewTableCell("   "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Folder)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Folder)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Folder)
+				act.sel = findAndDelete(act.sel, selecao{fName: fName, fSize: 0, fType: "FOLDER"})
 				displaySelection()
 			}
 		}
 		// Move cursor to next line
-		if idx < ui.TblFiles.GetRowCount()-1 {
-			ui.TblFiles.Select(idx+1, 0)
+		if idx < act.table.GetRowCount()-1 {
+			act.table.Select(idx+1, 0)
 		}
 	}
-	                ui.JobsDone()
+	ui.JobsDone()
 }
 
 // ****************************************************************************
 // displaySelection()
 // ****************************************************************************
 func displaySelection() {
-	if len(sel) > 0 {
+	act := active()
+	if len(act.sel) > 0 {
 		nFiles := 0
 		nFolders := 0
 		nSize := 0
-		for _, s := range sel {
+		for _, s := range act.sel {
 			nSize += int(s.fSize)
 			if s.fType == "FILE" {
 				nFiles++
@@ -1141,13 +1205,22 @@ func displaySelection() {
 			"02Size":    fmt.Sprintf("%d Bytes (%s)", nSize, utils.HumanFileSize(float64(nSize))),
 		}
 		ui.DisplayMap(ui.TxtSelection, infos)
-		MnuFiles.SetEnabled("mnuPaste", true)
 	} else {
 		ui.TxtSelection.Clear()
-		MnuFiles.SetEnabled("mnuPaste", false)
-		pasteMode = PASTE_DEFAULT
+		if clip == nil || clip == act {
+			act.mode = PASTE_DEFAULT
+			clip = nil
+		}
+	}
+	// What was copied or cut in the other pane can be pasted here
+	if MnuFiles != nil {
+		MnuFiles.SetEnabled("mnuPaste", len(act.sel) > 0 || clip != nil)
 	}
-	switch pasteMode {
+	mode := act.mode
+	if clip != act {
+		mode = PASTE_DEFAULT
+	}
+	switch mode {
 	case PASTE_DEFAULT:
 		ui.TxtSelection.SetTitle("Selection")
 	case PASTE_COPY:
@@ -1172,18 +1245,18 @@ func findAndDelete(s []selecao, item selecao) []selecao {
 }
 
 // ****************************************************************************
-// applySelection()
+// applySelection() pane
 // ****************************************************************************
-func applySelection() {
-	if len(sel) > 0 {
-		for idx := 0; idx < ui.TblFiles.GetRowCount(); idx++ {
-			fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-			for _, s := range sel {
-				if s.fName == fName && s.fType == strings.Trim(ui.TblFiles.GetCell(idx, 4).Text, " ") {
-					ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-					ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-					ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-					ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
+func (p *pane) applySelection() {
+	if len(p.sel) > 0 {
+		for idx := 0; idx < p.table.GetRowCount(); idx++ {
+			fName := filepath.Join(p.dir, p.table.GetCell(idx, 2).Text)
+			for _, s := range p.sel {
+				if s.fName == fName && s.fType == strings.Trim(p.table.GetCell(idx, 4).Text, " ") {
+					p.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+					p.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+					p.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+					p.table.GetCell(idx, 2).SetTextColor(theme.Selected)
 				}
 			}
 		}
@@ -1194,10 +1267,17 @@ func applySelection() {
 // focusOn()
 // ****************************************************************************
 func focusOn(fName string) {
-	for idx := 0; idx < ui.TblFiles.GetRowCount(); idx++ {
+	active().focusOn(fName)
+}
+
+// ****************************************************************************
+// focusOn() pane
+// ****************************************************************************
+func (p *pane) focusOn(fName string) {
+	for idx := 0; idx < p.table.GetRowCount(); idx++ {
 		fBase := filepath.Base(fName)
-		if ui.TblFiles.GetCell(idx, 2).Text == fBase {
-			ui.TblFiles.Select(idx, 0)
+		if p.table.GetCell(idx, 2).Text == fBase {
+			p.table.Select(idx, 0)
 			break
 		}
 	}
@@ -1207,74 +1287,104 @@ func focusOn(fName string) {
 // SelectAll(p any)
 // ****************************************************************************
 func SelectAll(p any) {
+	act := active()
 	ui.PleaseWait()
-	if len(sel) == 0 {
-		for idx := 1; idx < ui.TblFiles.GetRowCount(); idx++ {
-			if strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text) == "FILE" {
+	if len(act.sel) == 0 {
+		for idx := 1; idx < act.table.GetRowCount(); idx++ {
+			if strings.TrimSpace(act.table.GetCell(idx, 4).Text) == "FILE" {
 				// SELECT FILE
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
-				fSize, _ := strconv.Atoi(ui.TblFiles.GetCell(idx, 6).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
+				fSize, _ := strconv.Atoi(act.table.GetCell(idx, 6).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-				sel = append(sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
+				act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+				act.sel = append(act.sel, selecao{fName: fName, fSize: int64(fSize), fType: "FILE"})
 			} else {
 				// SELECT FOLDER
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				fSize, _ := utils.DirSize(fName)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_SELECTED)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_SELECTED)
-				sel = append(sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
+				act.table.SetCell(idx, 0, tview.NewTableCell(" ✓ "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Selected)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Selected)
+				act.sel = append(act.sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
 			}
 		}
 		RefreshMe()
 		displaySelection()
 	} else {
-		for idx := 1; idx < ui.TblFiles.GetRowCount(); idx++ {
-			if strings.TrimSpace(ui.TblFiles.GetCell(idx, 4).Text) == "FILE" {
+		for idx := 1; idx < act.table.GetRowCount(); idx++ {
+			if strings.TrimSpace(act.table.GetCell(idx, 4).Text) == "FILE" {
 				// UNSELECT FILE
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell("   "))
-				if ui.TblFiles.GetCell(idx, 1).Text == "⚙" {
-					ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_EXECUTABLE)
-					ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_EXECUTABLE)
-					ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_EXECUTABLE)
+				act.table.SetCell(idx, 0, tview.NewTableCell("   "))
+				if act.table.GetCell(idx, 1).Text == "⚙" {
+					act.table.GetCell(idx, 0).SetTextColor(theme.Executable)
+					act.table.GetCell(idx, 1).SetTextColor(theme.Executable)
+					act.table.GetCell(idx, 2).SetTextColor(theme.Executable)
 				} else {
-					ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_FILE)
-					ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_FILE)
-					ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_FILE)
+					act.table.GetCell(idx, 0).SetTextColor(theme.File)
+					act.table.GetCell(idx, 1).SetTextColor(theme.File)
+					act.table.GetCell(idx, 2).SetTextColor(theme.File)
 				}
-				sel = findAndDelete(sel, selecao{fName: fName, fSize: 0, fType: "FILE"})
+				act.sel = findAndDelete(act.sel, selecao{fName: fName, fSize: 0, fType: "FILE"})
 			} else {
 				// UNSELECT FOLDER
-				fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+				fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 				ui.SetStatus(fName)
-				ui.TblFiles.SetCell(idx, 0, tview.NewTableCell("   "))
-				ui.TblFiles.GetCell(idx, 0).SetTextColor(conf.COLOR_FOLDER)
-				ui.TblFiles.GetCell(idx, 1).SetTextColor(conf.COLOR_FOLDER)
-				ui.TblFiles.GetCell(idx, 2).SetTextColor(conf.COLOR_FOLDER)
-				sel = findAndDelete(sel, selecao{fName: fName, fSize: 0, fType: "FOLDER"})
+				act.table.SetCell(idx, 0, tview.NewTableCell("   "))
+				act.table.GetCell(idx, 0).SetTextColor(theme.Folder)
+				act.table.GetCell(idx, 1).SetTextColor(theme.Folder)
+				act.table.GetCell(idx, 2).SetTextColor(theme.Folder)
+				act.sel = findAndDelete(act.sel, selecao{fName: fName, fSize: 0, fType: "FOLDER"})
 			}
 		}
-		sel = nil
+		act.sel = nil
 		RefreshMe()
 		displaySelection()
 	}
-	                ui.JobsDone()
+	ui.JobsDone()
+}
+
+// ****************************************************************************
+// SetSelection()
+// SetSelection replaces the selection by the files and folders of paths,
+// wherever they are, and returns their number
+// ****************************************************************************
+func SetSelection(paths []string) int {
+	act := active()
+	act.sel = nil
+	for _, fName := range paths {
+		fi, err := os.Stat(fName)
+		if err != nil {
+			continue
+		}
+		if fi.IsDir() {
+			fSize, _ := utils.DirSize(fName)
+			act.sel = append(act.sel, selecao{fName: fName, fSize: fSize, fType: "FOLDER"})
+		} else {
+			act.sel = append(act.sel, selecao{fName: fName, fSize: fi.Size(), fType: "FILE"})
+		}
+	}
+	// The selection doesn't come from a folder, it can be pasted anywhere
+	act.mode = PASTE_DEFAULT
+	act.from = ""
+	clip = act
+	RefreshMe()
+	return len(act.sel)
 }
 
 // ****************************************************************************
 // DoEdit(p any)
 // ****************************************************************************
 func DoEdit(p any) {
-	idx, _ := ui.TblFiles.GetSelection()
-	fName := filepath.Join(conf.Cwd, ui.TblFiles.GetCell(idx, 2).Text)
+	act := active()
+	idx, _ := act.table.GetSelection()
+	fName := filepath.Join(act.dir, act.table.GetCell(idx, 2).Text)
 	mtype, xtype := preview.DisplayFilePreview(fName)
 	if mtype[:4] == "text" {
 		edit.SwitchToEditor(fName)
@@ -1289,8 +1399,6 @@ func DoEdit(p any) {
 // SelfInit()
 // ****************************************************************************
 func SelfInit(a any) {
-	SetFilesMenu()
-	ShowFiles()
+	ShowScreen()
 	ui.App.Sync()
-	ui.App.SetFocus(ui.TblFiles)
 }
@@ -0,0 +1,161 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package fm
+
+// ****************************************************************************
+// journal keeps the last operations of the File Manager, for the session,
+// with what it takes to reverse them : a rename or a move is done back, a
+// file created (copy, new file or folder, timestamp, zip) goes to the trash,
+// and a file trashed is restored. Only the files which did not exist before
+// are recorded, so that an undo never takes away what was there.
+// The undo runs in the queue of the file operations.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"gosh/audit"
+	"gosh/trash"
+	"gosh/ui"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type changeKind int
+
+const (
+	CHANGE_RENAME changeKind = iota
+	CHANGE_MOVE
+	CHANGE_CREATE
+	CHANGE_TRASH
+)
+
+type change struct {
+	kind changeKind
+	from string // Renamed or moved
+	to   string // Renamed, moved or created
+	item trash.Item
+}
+
+type record struct {
+	title   string
+	changes []change
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const JOURNAL_MAX = 100
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var journal []record // Only used on the UI goroutine, the last one at the end
+
+// ****************************************************************************
+// journalize()
+// ****************************************************************************
+func journalize(title string, changes ...change) {
+	if len(changes) == 0 {
+		return
+	}
+	journal = append(journal, record{title: title, changes: changes})
+	if len(journal) > JOURNAL_MAX {
+		journal = journal[len(journal)-JOURNAL_MAX:]
+	}
+}
+
+// ****************************************************************************
+// DoUndo(p any)
+// DoUndo reverses the last operation recorded
+// ****************************************************************************
+func DoUndo(p any) {
+	if len(journal) == 0 {
+		ui.SetStatus("Nothing to undo")
+		return
+	}
+	last := journal[len(journal)-1]
+	journal = journal[:len(journal)-1]
+	op := &operation{kind: OP_UNDO, label: last.title, reverse: last.changes, to: active()}
+	for _, c := range last.changes {
+		if c.kind == CHANGE_TRASH {
+			op.names = append(op.names, c.item.File())
+		} else {
+			op.names = append(op.names, c.to)
+		}
+	}
+	enqueue(op)
+}
+
+// ****************************************************************************
+// undo() operation
+// undo reverses the changes of a record, the last one first
+// ****************************************************************************
+func (op *operation) undo() {
+	for i := len(op.reverse) - 1; i >= 0; i-- {
+		if op.cancel.Load() {
+			break
+		}
+		c := op.reverse[i]
+		switch c.kind {
+		case CHANGE_RENAME, CHANGE_MOVE:
+			var err error
+			if _, errExist := os.Lstat(c.from); errExist == nil {
+				err = errors.New(filepath.Base(c.from) + " exists again")
+			} else {
+				err = op.move(c.to, c.from)
+			}
+			audit.Log(audit.ACTION_RENAME, c.to+" -> "+c.from, err)
+			if err != nil {
+				op.fail(c.to, err)
+				continue
+			}
+			op.completed++
+			op.last = c.from
+		case CHANGE_CREATE:
+			op.trash(c.to)
+		case CHANGE_TRASH:
+			op.restore(c.item)
+		}
+	}
+}
+
+// ****************************************************************************
+// record() operation
+// record journals a change of the operation, unless it is an undo
+// ****************************************************************************
+func (op *operation) record(c change) {
+	if op.kind != OP_UNDO {
+		op.changes = append(op.changes, c)
+	}
+}
+
+// ****************************************************************************
+// journalTitle() operation
+// ****************************************************************************
+func (op *operation) journalTitle() string {
+	switch op.kind {
+	case OP_MOVE:
+		return "Move " + op.what()
+	case OP_ZIP:
+		return "Zip " + op.what()
+	case OP_TRASH:
+		return "Trash " + op.what()
+	default:
+		return "Copy " + op.what()
+	}
+}
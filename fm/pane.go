@@ -0,0 +1,330 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package fm
+
+// ****************************************************************************
+// pane keeps the layout of each Files screen : one pane, or two side by side
+// like Midnight Commander, each one with its own folder, sort and selection,
+// and whether the hidden files are shown.
+// All the screens share the two tables of ui, filled again with the panes of
+// a screen when it is shown. The active pane follows the focus and gives its
+// folder to the prompt.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"path/filepath"
+
+	"gosh/conf"
+	"gosh/dialog"
+	"gosh/theme"
+	"gosh/ui"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type pane struct {
+	table      *tview.Table
+	path       *tview.TextView
+	dir        string
+	shown      string // Folder in the table, to keep the cursor on refresh
+	sortColumn SortColumn
+	sortOrder  int
+	sel        []selecao
+	mode       PasteMode // Copy or cut of the selection
+	from       string    // Folder of the selection, "" when from anywhere
+	row        int       // Cursor while the screen is hidden
+}
+
+type layout struct {
+	dual   bool
+	hidden bool // Shows the hidden files in both panes
+	panes  [2]*pane
+	active int
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	layouts = make(map[string]*layout) // By screen, see ui.GetCurrentScreen
+	cur     *layout
+	shown   *layout // Layout whose cursors are in the panes, nil when hidden
+	moving  bool    // Until the transfer is confirmed
+)
+
+// ****************************************************************************
+// newLayout()
+// ****************************************************************************
+func newLayout(dir string) *layout {
+	l := &layout{dual: ui.MyConfig.Files.DualPane, hidden: ui.MyConfig.Files.ShowHidden}
+	l.panes[0] = &pane{table: ui.TblFiles, path: ui.TxtPath, dir: dir}
+	l.panes[1] = &pane{table: ui.TblFiles2, path: ui.TxtPath2, dir: dir}
+	return l
+}
+
+// ****************************************************************************
+// active()
+// active returns the pane in use
+// ****************************************************************************
+func active() *pane {
+	if cur == nil {
+		cur = newLayout(conf.Cwd)
+	}
+	return cur.panes[cur.active]
+}
+
+// ****************************************************************************
+// opposite()
+// opposite returns the other pane of the dual-pane layout
+// ****************************************************************************
+func opposite() *pane {
+	active()
+	return cur.panes[1-cur.active]
+}
+
+// ****************************************************************************
+// IsDual()
+// IsDual tells if the Files screen shown has two panes
+// ****************************************************************************
+func IsDual() bool {
+	return ui.CurrentMode == ui.ModeFiles && cur != nil && cur.dual
+}
+
+// ****************************************************************************
+// InPane()
+// InPane tells if one of the panes has the focus
+// ****************************************************************************
+func InPane() bool {
+	return ui.TblFiles.HasFocus() || ui.TblFiles2.HasFocus()
+}
+
+// ****************************************************************************
+// ShowScreen()
+// ShowScreen fills the panes with the ones of the Files screen shown,
+// forgetting the screens which are closed
+// ****************************************************************************
+func ShowScreen() {
+	// The cursors of the screen left, whatever the mode of the next one
+	if shown != nil {
+		for _, p := range shown.panes {
+			p.row, _ = p.table.GetSelection()
+		}
+		shown = nil
+	}
+	for id := range layouts {
+		if !ui.IsScreenOpen(id) {
+			delete(layouts, id)
+		}
+	}
+	if ui.CurrentMode != ui.ModeFiles {
+		return
+	}
+	id := ui.GetCurrentScreen()
+	l, ok := layouts[id]
+	if !ok {
+		l = newLayout(conf.Cwd)
+		layouts[id] = l
+	}
+	cur, shown = l, l
+	// The menus go back to the page of this screen
+	SetFilesMenu()
+	ui.SetFilesLayout(cur.dual)
+	for i, p := range cur.panes {
+		if i == 0 || cur.dual {
+			p.shown = ""
+			p.show()
+			p.table.Select(p.row, 0)
+		}
+	}
+	activate(cur.active)
+	ui.App.SetFocus(active().table)
+}
+
+// ****************************************************************************
+// activate()
+// activate makes the pane i the one in use, its folder becoming the one of
+// the prompt
+// ****************************************************************************
+func activate(i int) {
+	active()
+	cur.active = i
+	p := cur.panes[i]
+	conf.Cwd = p.dir
+	ui.TblFilesActive = p.table
+	for j, q := range cur.panes {
+		if j == i || !cur.dual {
+			q.table.SetBorderColor(theme.Border)
+		} else {
+			q.table.SetBorderColor(theme.Dimmed)
+		}
+	}
+	displaySelection()
+	if MnuFiles != nil {
+		MnuFiles.SetFocus(p.table)
+		MnuFilesSort.SetFocus(p.table)
+	}
+}
+
+// ****************************************************************************
+// Focused()
+// Focused makes the pane of table the one in use, when it gets the focus
+// ****************************************************************************
+func Focused(table *tview.Table) {
+	// The focus can come before ShowScreen, with the panes of another screen
+	if cur == nil || ui.CurrentMode != ui.ModeFiles || layouts[ui.GetCurrentScreen()] != cur {
+		return
+	}
+	for i, p := range cur.panes {
+		if p.table == table && i != cur.active {
+			activate(i)
+		}
+	}
+}
+
+// ****************************************************************************
+// SwitchPane()
+// SwitchPane goes to the other pane
+// ****************************************************************************
+func SwitchPane() {
+	if !IsDual() {
+		return
+	}
+	ui.App.SetFocus(opposite().table)
+}
+
+// ****************************************************************************
+// DoSwitchDual(p any)
+// DoSwitchDual shows one pane or two
+// ****************************************************************************
+func DoSwitchDual(p any) {
+	act := active()
+	cur.dual = !cur.dual
+	if !cur.dual && cur.active == 1 {
+		// The left pane goes on with the folder of the right one
+		left := cur.panes[0]
+		left.dir, left.sortColumn, left.sortOrder, left.sel = act.dir, act.sortColumn, act.sortOrder, act.sel
+		left.row, _ = act.table.GetSelection()
+		cur.active = 0
+	}
+	ui.SetFilesLayout(cur.dual)
+	for i, q := range cur.panes {
+		if i == 0 || cur.dual {
+			q.shown = ""
+			row := q.row
+			if i == cur.active {
+				row, _ = act.table.GetSelection()
+			}
+			q.show()
+			q.table.Select(row, 0)
+		}
+	}
+	activate(cur.active)
+	ui.App.SetFocus(active().table)
+	if cur.dual {
+		ui.SetStatus("Dual-pane layout")
+	} else {
+		ui.SetStatus("Single-pane layout")
+	}
+}
+
+// ****************************************************************************
+// RefreshPanes()
+// RefreshPanes reads again the folders of the panes shown
+// ****************************************************************************
+func RefreshPanes() {
+	if IsDual() {
+		opposite().refresh()
+	}
+	RefreshMe()
+}
+
+// ****************************************************************************
+// DoTransfer()
+// DoTransfer copies, or moves, the selection of the active pane, or the file
+// under its cursor, into the folder of the other pane
+// ****************************************************************************
+func DoTransfer(move bool) {
+	if !IsDual() {
+		return
+	}
+	src, dst := active(), opposite()
+	if src.dir == dst.dir {
+		ui.SetStatus("Both panes show the same folder")
+		return
+	}
+	names := src.transferNames()
+	if len(names) == 0 {
+		ui.SetStatus("Nothing to transfer")
+		return
+	}
+	what := filepath.Base(names[0])
+	if len(names) > 1 {
+		what = fmt.Sprintf("%d files and folders", len(names))
+	}
+	verb := "Copy"
+	if move {
+		verb = "Move"
+	}
+	moving = move
+	DlgConfirm = DlgConfirm.YesNo(fmt.Sprintf("%s %s", verb, what), // Title
+		fmt.Sprintf("%s to %s ?", verb, dst.dir), // Message
+		confirmTransfer,
+		0,
+		ui.GetCurrentScreen(), src.table) // Focus return
+	ui.PgsApp.AddPage("dlgConfirmTransfer", DlgConfirm.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConfirmTransfer")
+}
+
+// ****************************************************************************
+// confirmTransfer()
+// ****************************************************************************
+func confirmTransfer(button dialog.DlgButton, idx int) {
+	if button != dialog.BUTTON_YES {
+		ui.SetStatus("Cancelling the transfer")
+		return
+	}
+	src, dst := active(), opposite()
+	names := src.transferNames()
+	kind := OP_COPY
+	if moving {
+		kind = OP_MOVE
+	}
+	src.sel = nil
+	src.refresh()
+	displaySelection()
+	enqueue(&operation{kind: kind, names: names, dir: dst.dir, to: dst})
+}
+
+// ****************************************************************************
+// transferNames() pane
+// transferNames returns the selection of p, or the file under the cursor
+// ****************************************************************************
+func (p *pane) transferNames() []string {
+	var names []string
+	for _, s := range p.sel {
+		names = append(names, s.fName)
+	}
+	if names != nil {
+		return names
+	}
+	idx, _ := p.table.GetSelection()
+	if idx < 0 || idx >= p.table.GetRowCount() || p.table.GetCell(idx, 3).Text == conf.LABEL_PARENT_FOLDER {
+		return nil
+	}
+	return []string{filepath.Join(p.dir, p.table.GetCell(idx, 2).Text)}
+}
@@ -0,0 +1,992 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package fm
+
+// ****************************************************************************
+// queue runs the copies, moves, deletions, zips and trash operations of the
+// File Manager one after the other in a background worker, so that the
+// screens stay usable.
+// A dialog shows the progress of the operation running, and lets hide it or
+// cancel it. When a target already exists, the worker waits for the user to
+// overwrite it, skip it or rename the copy. The failures are summed up at
+// the end of each operation.
+// The worker never touches the widgets : it goes through QueueUpdateDraw.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gosh/audit"
+	"gosh/dialog"
+	"gosh/logger"
+	"gosh/trash"
+	"gosh/ui"
+	"gosh/utils"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type opKind int
+
+const (
+	OP_COPY opKind = iota
+	OP_MOVE
+	OP_DELETE
+	OP_ZIP
+	OP_TRASH
+	OP_RESTORE
+	OP_PURGE
+	OP_UNDO
+)
+
+type resolution int
+
+const (
+	RESOLVE_ASK resolution = iota
+	RESOLVE_OVERWRITE
+	RESOLVE_SKIP
+	RESOLVE_RENAME
+	RESOLVE_CANCEL
+)
+
+type answer struct {
+	resolution resolution
+	all        bool
+}
+
+type tree struct {
+	size  int64
+	files int
+	dirs  int
+}
+
+type operation struct {
+	kind      opKind
+	names     []string
+	items     []trash.Item // Restored or purged, their files being the names
+	label     string       // Record of the journal undone
+	reverse   []change     // Changes undone
+	changes   []change     // Changes to journal
+	dir       string       // Target of a copy or move, root of the names in a zip
+	archive   string
+	to        *pane // Pane to focus on the last target
+	cancel    atomic.Bool
+	policy    resolution // Answer applied to all the conflicts
+	trees     map[string]tree
+	total     int64
+	files     int
+	done      int64
+	count     int
+	completed int
+	skipped   int
+	current   string
+	started   time.Time
+	reported  time.Time
+	failed    []string
+	denied    []string // Deletions refused, to do again with sudo
+	last      string
+}
+
+type progress struct {
+	title   string
+	current string
+	done    int64
+	total   int64
+	count   int
+	files   int
+	elapsed time.Duration
+	queued  int
+}
+
+const (
+	COPY_BUFFER    = 1024 * 1024
+	PROGRESS_EVERY = 200 * time.Millisecond
+	PROGRESS_WIDTH = 60
+	FAILURES_SHOWN = 10
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	DlgProgress *dialog.Dialog
+	DlgConflict *dialog.Dialog
+	DlgFailures *dialog.Dialog
+)
+var (
+	queueLock sync.Mutex
+	pending   []*operation
+	busy      bool // A worker is running
+)
+var (
+	// Only used on the UI goroutine
+	progressShown  bool
+	progressHidden bool // Until the queue is empty
+	progressParent string
+	progressFocus  tview.Primitive
+)
+var (
+	errCancelled  = errors.New("cancelled")
+	errIncomplete = errors.New("incomplete copy, source kept")
+)
+
+// ****************************************************************************
+// enqueue()
+// enqueue adds op to the operations run by the worker, starting it if needed
+// ****************************************************************************
+func enqueue(op *operation) {
+	queueLock.Lock()
+	pending = append(pending, op)
+	start := !busy
+	busy = true
+	n := len(pending)
+	queueLock.Unlock()
+	if start {
+		go worker()
+	} else {
+		ui.SetStatus(fmt.Sprintf("%s queued, %d waiting", op.title(), n))
+	}
+}
+
+// ****************************************************************************
+// queued()
+// queued returns the number of operations waiting for the worker
+// ****************************************************************************
+func queued() int {
+	queueLock.Lock()
+	defer queueLock.Unlock()
+	return len(pending)
+}
+
+// ****************************************************************************
+// worker()
+// ****************************************************************************
+func worker() {
+	for {
+		queueLock.Lock()
+		if len(pending) == 0 {
+			busy = false
+			queueLock.Unlock()
+			return
+		}
+		op := pending[0]
+		pending = pending[1:]
+		queueLock.Unlock()
+
+		op.started = time.Now()
+		ui.App.QueueUpdateDraw(func() { showProgress(op) })
+		op.run()
+		ui.App.QueueUpdateDraw(func() { finish(op) })
+	}
+}
+
+// ****************************************************************************
+// run() operation
+// ****************************************************************************
+func (op *operation) run() {
+	op.measure()
+	switch op.kind {
+	case OP_COPY, OP_MOVE:
+		for _, fName := range op.names {
+			if op.cancel.Load() {
+				break
+			}
+			op.transfer(fName)
+		}
+	case OP_DELETE:
+		for _, fName := range op.names {
+			if op.cancel.Load() {
+				break
+			}
+			op.delete(fName)
+		}
+	case OP_ZIP:
+		op.zip()
+	case OP_TRASH:
+		for _, fName := range op.names {
+			if op.cancel.Load() {
+				break
+			}
+			op.trash(fName)
+		}
+	case OP_RESTORE, OP_PURGE:
+		for _, item := range op.items {
+			if op.cancel.Load() {
+				break
+			}
+			if op.kind == OP_RESTORE {
+				op.restore(item)
+			} else {
+				op.purge(item)
+			}
+		}
+	case OP_UNDO:
+		op.undo()
+	}
+	op.report(true)
+}
+
+// ****************************************************************************
+// title() operation
+// ****************************************************************************
+func (op *operation) title() string {
+	what := op.what()
+	switch op.kind {
+	case OP_MOVE:
+		return "Moving " + what
+	case OP_DELETE:
+		return "Deleting " + what
+	case OP_ZIP:
+		return "Zipping " + what
+	case OP_TRASH:
+		return "Trashing " + what
+	case OP_RESTORE:
+		return "Restoring " + what
+	case OP_PURGE:
+		return "Purging " + what
+	case OP_UNDO:
+		return "Undoing " + op.label
+	default:
+		return "Copying " + what
+	}
+}
+
+// ****************************************************************************
+// what() operation
+// ****************************************************************************
+func (op *operation) what() string {
+	if len(op.names) > 1 {
+		return fmt.Sprintf("%d files and folders", len(op.names))
+	}
+	return filepath.Base(op.names[0])
+}
+
+// ****************************************************************************
+// measure() operation
+// measure counts the bytes and files to process, for the progress
+// ****************************************************************************
+func (op *operation) measure() {
+	op.trees = make(map[string]tree)
+	for _, fName := range op.names {
+		t := measureTree(fName)
+		op.trees[fName] = t
+		op.total += t.size
+		op.files += t.files
+	}
+}
+
+// ****************************************************************************
+// measureTree()
+// ****************************************************************************
+func measureTree(fName string) tree {
+	var t tree
+	filepath.WalkDir(fName, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			t.dirs++
+			return nil
+		}
+		t.files++
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			t.size += info.Size()
+		}
+		return nil
+	})
+	return t
+}
+
+// ****************************************************************************
+// advance() operation
+// advance counts as done a tree which was moved or skipped at once
+// ****************************************************************************
+func (op *operation) advance(t tree) {
+	op.done += t.size
+	op.count += t.files
+	op.report(false)
+}
+
+// ****************************************************************************
+// fail() operation
+// ****************************************************************************
+func (op *operation) fail(fName string, err error) {
+	if errors.Is(err, errCancelled) || errors.Is(err, errIncomplete) {
+		return
+	}
+	if pathErr, ok := err.(*fs.PathError); ok {
+		err = pathErr.Err
+	}
+	logger.Warning("fm.go: %s %s: %v", op.title(), fName, err)
+	op.failed = append(op.failed, fmt.Sprintf("%s: %v", filepath.Base(fName), err))
+}
+
+// ****************************************************************************
+// transfer() operation
+// transfer copies, or moves, fName into the target folder
+// ****************************************************************************
+func (op *operation) transfer(fName string) {
+	if op.dir == fName || strings.HasPrefix(op.dir, fName+string(filepath.Separator)) {
+		op.fail(fName, errors.New("can't copy a folder into itself"))
+		return
+	}
+	target := filepath.Join(op.dir, filepath.Base(fName))
+	dst, err := os.Lstat(target)
+	fresh := err != nil
+	// Overwriting the file with itself would delete it
+	if src, errSrc := os.Lstat(fName); !fresh && errSrc == nil && os.SameFile(src, dst) {
+		if op.kind == OP_MOVE {
+			op.fail(fName, errors.New("same file"))
+			op.advance(measureTree(fName))
+			return
+		}
+		target, fresh = utils.GetFilenameWhichDoesntExist(target), true
+	}
+	target, ok := op.resolve(fName, target)
+	if !ok {
+		return
+	}
+	// A target merged or overwritten can't be undone
+	fresh = fresh || target != filepath.Join(op.dir, filepath.Base(fName))
+	if op.kind == OP_MOVE {
+		err := op.move(fName, target)
+		audit.Log(audit.ACTION_RENAME, fName+" -> "+target, err)
+		if err != nil {
+			op.fail(fName, err)
+			return
+		}
+		if fresh {
+			op.record(change{kind: CHANGE_MOVE, from: fName, to: target})
+		}
+	} else {
+		failed := len(op.failed)
+		op.copyTree(fName, target)
+		if fresh && utils.IsFileExist(target) {
+			op.record(change{kind: CHANGE_CREATE, to: target})
+		}
+		// What the user skipped doesn't make the copy fail
+		if len(op.failed) != failed || op.cancel.Load() {
+			return
+		}
+	}
+	op.completed++
+	op.last = target
+}
+
+// ****************************************************************************
+// move() operation
+// move renames fName to target, or copies it then deletes it when target is
+// on another file system or is a folder to merge with. The source is kept
+// when the copy is not complete.
+// ****************************************************************************
+func (op *operation) move(fName string, target string) error {
+	if !utils.IsFileExist(target) {
+		err := os.Rename(fName, target)
+		if err == nil {
+			t, ok := op.trees[fName]
+			if !ok {
+				t = measureTree(target)
+			}
+			op.advance(t)
+			return nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+	}
+	if !op.copyTree(fName, target) {
+		return errIncomplete
+	}
+	return os.RemoveAll(fName)
+}
+
+// ****************************************************************************
+// trash() operation
+// trash moves fName into the trash, beside its .trashinfo. When the move
+// fails with fName left whole, the copy in the trash is dropped. Once a part
+// of fName is gone, the item is kept in the trash to be restored.
+// ****************************************************************************
+func (op *operation) trash(fName string) {
+	item, err := trash.Reserve(fName)
+	if err == nil {
+		before, ok := op.trees[fName]
+		if !ok {
+			before = measureTree(fName)
+		}
+		if err = op.move(fName, item.File()); err != nil {
+			if _, errExist := os.Lstat(fName); errExist == nil && measureTree(fName) == before {
+				os.RemoveAll(item.File())
+				trash.Forget(item)
+			} else {
+				err = fmt.Errorf("partly moved to the trash: %w", err)
+			}
+		}
+	}
+	audit.Log(audit.ACTION_TRASH, fName, err)
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		op.denied = append(op.denied, fName)
+	case err != nil:
+		op.fail(fName, err)
+	default:
+		op.record(change{kind: CHANGE_TRASH, item: item})
+		op.completed++
+	}
+}
+
+// ****************************************************************************
+// restore() operation
+// restore moves an item of the trash back where it was deleted from
+// ****************************************************************************
+func (op *operation) restore(item trash.Item) {
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+		op.fail(item.Path, err)
+		return
+	}
+	target, ok := op.resolve(item.File(), item.Path)
+	if !ok {
+		return
+	}
+	err := op.move(item.File(), target)
+	if err == nil {
+		err = trash.Forget(item)
+	}
+	audit.Log(audit.ACTION_RESTORE, target, err)
+	if err != nil {
+		op.fail(target, err)
+		return
+	}
+	op.completed++
+	op.last = target
+}
+
+// ****************************************************************************
+// purge() operation
+// purge deletes an item of the trash for good
+// ****************************************************************************
+func (op *operation) purge(item trash.Item) {
+	err := op.remove(item.File())
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		err = trash.Forget(item)
+	}
+	audit.Log(audit.ACTION_DELETE, item.File(), err)
+	if err != nil {
+		op.fail(item.Name, err)
+		return
+	}
+	op.completed++
+}
+
+// ****************************************************************************
+// resolve() operation
+// resolve returns the name to copy fName to when target already exists, or
+// false when it is skipped. Two folders are merged when overwriting.
+// ****************************************************************************
+func (op *operation) resolve(fName string, target string) (string, bool) {
+	dst, err := os.Lstat(target)
+	if err != nil {
+		return target, true
+	}
+	r := op.policy
+	if r == RESOLVE_ASK {
+		a := op.ask(target)
+		r = a.resolution
+		if a.all && r != RESOLVE_CANCEL {
+			op.policy = r
+		}
+	}
+	switch r {
+	case RESOLVE_OVERWRITE:
+		if src, err := os.Lstat(fName); err == nil && src.IsDir() && dst.IsDir() {
+			return target, true
+		}
+		if err := os.RemoveAll(target); err != nil {
+			op.fail(fName, err)
+			op.advance(measureTree(fName))
+			return "", false
+		}
+		return target, true
+	case RESOLVE_RENAME:
+		return utils.GetFilenameWhichDoesntExist(target), true
+	case RESOLVE_SKIP:
+		op.skipped++
+		op.advance(measureTree(fName))
+		return "", false
+	default:
+		op.cancel.Store(true)
+		return "", false
+	}
+}
+
+// ****************************************************************************
+// ask() operation
+// ask waits for the user to tell what to do with a target which exists
+// ****************************************************************************
+func (op *operation) ask(target string) answer {
+	c := make(chan answer, 1)
+	ui.App.QueueUpdateDraw(func() { askConflict(target, c) })
+	return <-c
+}
+
+// ****************************************************************************
+// copyTree() operation
+// copyTree copies fName to target, and tells if nothing was left behind
+// ****************************************************************************
+func (op *operation) copyTree(fName string, target string) bool {
+	fi, err := os.Lstat(fName)
+	if err != nil {
+		op.fail(fName, err)
+		return false
+	}
+	switch {
+	case fi.IsDir():
+		if err := os.MkdirAll(target, fi.Mode().Perm()); err != nil {
+			op.fail(fName, err)
+			op.advance(measureTree(fName))
+			return false
+		}
+		entries, err := os.ReadDir(fName)
+		if err != nil {
+			op.fail(fName, err)
+			return false
+		}
+		complete := true
+		for _, e := range entries {
+			if op.cancel.Load() {
+				return false
+			}
+			src := filepath.Join(fName, e.Name())
+			dst := filepath.Join(target, e.Name())
+			// The folders inside a folder already there are merged silently
+			if fi, err := os.Lstat(dst); err != nil || !fi.IsDir() || !e.IsDir() {
+				var ok bool
+				if dst, ok = op.resolve(src, dst); !ok {
+					complete = false
+					continue
+				}
+			}
+			complete = op.copyTree(src, dst) && complete
+		}
+		return complete
+	case fi.Mode()&fs.ModeSymlink != 0:
+		op.current = fName
+		link, err := os.Readlink(fName)
+		if err == nil {
+			err = os.Symlink(link, target)
+		}
+		op.count++
+		if err != nil {
+			op.fail(fName, err)
+			return false
+		}
+		return true
+	case fi.Mode().IsRegular():
+		op.current = fName
+		err := op.copyFile(fName, target, fi.Mode().Perm())
+		op.count++
+		if err != nil {
+			os.Remove(target)
+			op.fail(fName, err)
+			return false
+		}
+		return true
+	default:
+		op.count++
+		op.fail(fName, errors.New("not a regular file"))
+		return false
+	}
+}
+
+// ****************************************************************************
+// copyFile() operation
+// ****************************************************************************
+func (op *operation) copyFile(fName string, target string, perm fs.FileMode) error {
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	err = op.copyData(out, fName)
+	if errClose := out.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// ****************************************************************************
+// copyData() operation
+// copyData writes the content of fName to w, counting the bytes written and
+// stopping when the operation is cancelled
+// ****************************************************************************
+func (op *operation) copyData(w io.Writer, fName string) error {
+	in, err := os.Open(fName)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	buf := make([]byte, COPY_BUFFER)
+	for {
+		if op.cancel.Load() {
+			return errCancelled
+		}
+		n, err := in.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			op.done += int64(n)
+			op.report(false)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ****************************************************************************
+// delete() operation
+// ****************************************************************************
+func (op *operation) delete(fName string) {
+	err := op.remove(fName)
+	audit.Log(audit.ACTION_DELETE, fName, err)
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		op.denied = append(op.denied, fName)
+	case err != nil:
+		op.fail(fName, err)
+	default:
+		op.completed++
+	}
+}
+
+// ****************************************************************************
+// remove() operation
+// remove deletes fName and its content, file by file for the progress
+// ****************************************************************************
+func (op *operation) remove(fName string) error {
+	fi, err := os.Lstat(fName)
+	if err != nil {
+		return err
+	}
+	op.current = fName
+	if fi.IsDir() {
+		entries, err := os.ReadDir(fName)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if op.cancel.Load() {
+				return errCancelled
+			}
+			if err := op.remove(filepath.Join(fName, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Remove(fName); err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		op.count++
+		op.done += fi.Size()
+		op.report(false)
+	}
+	return nil
+}
+
+// ****************************************************************************
+// zip() operation
+// zip writes the names into the archive, with paths relative to the folder
+// of the operation. The archive is deleted when it is not complete.
+// ****************************************************************************
+func (op *operation) zip() {
+	arc, err := os.Create(op.archive)
+	if err != nil {
+		op.fail(op.archive, err)
+		return
+	}
+	w := zip.NewWriter(arc)
+	for _, fName := range op.names {
+		err = filepath.Walk(fName, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if op.cancel.Load() {
+				return errCancelled
+			}
+			rel, err := filepath.Rel(op.dir, path)
+			if err != nil || rel == "." {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if info.IsDir() {
+				_, err := w.Create(rel + "/")
+				return err
+			}
+			op.count++
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = rel
+			header.Method = zip.Deflate
+			dst, err := w.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			op.current = path
+			return op.copyData(dst, path)
+		})
+		if err != nil {
+			break
+		}
+	}
+	if errClose := w.Close(); err == nil {
+		err = errClose
+	}
+	if errClose := arc.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(op.archive)
+		op.fail(op.archive, err)
+		return
+	}
+	op.record(change{kind: CHANGE_CREATE, to: op.archive})
+	op.completed = len(op.names)
+	op.last = op.archive
+}
+
+// ****************************************************************************
+// report() operation
+// report shows the progress, at most every PROGRESS_EVERY unless forced
+// ****************************************************************************
+func (op *operation) report(force bool) {
+	now := time.Now()
+	if !force && now.Sub(op.reported) < PROGRESS_EVERY {
+		return
+	}
+	op.reported = now
+	p := progress{
+		title:   op.title(),
+		current: op.current,
+		done:    op.done,
+		total:   op.total,
+		count:   op.count,
+		files:   op.files,
+		elapsed: now.Sub(op.started),
+		queued:  queued(),
+	}
+	ui.App.QueueUpdateDraw(func() { updateProgress(p) })
+}
+
+// ****************************************************************************
+// text() progress
+// ****************************************************************************
+func (p progress) text() string {
+	current := p.current
+	if len(current) > PROGRESS_WIDTH {
+		current = "..." + current[len(current)-PROGRESS_WIDTH+3:]
+	}
+	eta := "-"
+	if p.done > 0 && p.total > 0 {
+		eta = (time.Duration(float64(p.elapsed) * float64(p.total-p.done) / float64(p.done))).Round(time.Second).String()
+	} else if p.total == 0 && p.count > 0 {
+		eta = (time.Duration(float64(p.elapsed) * float64(p.files-p.count) / float64(p.count))).Round(time.Second).String()
+	}
+	lines := []string{
+		current,
+		fmt.Sprintf("%s of %s", utils.HumanFileSize(float64(p.done)), utils.HumanFileSize(float64(p.total))),
+		fmt.Sprintf("%d of %d files", p.count, p.files),
+		fmt.Sprintf("Elapsed %s, ETA %s", p.elapsed.Round(time.Second), eta),
+		fmt.Sprintf("%d waiting", p.queued),
+	}
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%-*s", PROGRESS_WIDTH, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ****************************************************************************
+// showProgress()
+// ****************************************************************************
+func showProgress(op *operation) {
+	p := progress{title: op.title(), queued: queued()}
+	if progressHidden {
+		ui.SetStatus(p.title)
+		return
+	}
+	progressParent = ui.GetCurrentScreen()
+	progressFocus = ui.App.GetFocus()
+	DlgProgress = DlgProgress.Choice(p.title, // Title
+		p.text(), // Message
+		[]string{"Hide", "Cancel"},
+		"",
+		func(button dialog.DlgButton, idx int) {
+			progressShown = false
+			if button == dialog.BUTTON_CANCEL {
+				op.cancel.Store(true)
+				ui.SetStatus("Cancelling " + p.title)
+			} else {
+				progressHidden = true
+			}
+		},
+		0,
+		progressParent, progressFocus) // Focus return
+	ui.PgsApp.AddPage("dlgProgress", DlgProgress.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgProgress")
+	progressShown = true
+}
+
+// ****************************************************************************
+// updateProgress()
+// ****************************************************************************
+func updateProgress(p progress) {
+	if progressShown {
+		DlgProgress.SetMessage(p.text())
+		return
+	}
+	if p.total > 0 {
+		ui.SetProgress(fmt.Sprintf("%s, %d%%", p.title, p.done*100/p.total))
+	} else {
+		ui.SetProgress(fmt.Sprintf("%s, %d of %d files", p.title, p.count, p.files))
+	}
+}
+
+// ****************************************************************************
+// askConflict()
+// ****************************************************************************
+func askConflict(target string, c chan answer) {
+	DlgConflict = DlgConflict.Choice("Conflict", // Title
+		fmt.Sprintf("%s already exists", target), // Message
+		[]string{"Overwrite", "Skip", "Rename", "Cancel"},
+		"Apply to all",
+		func(button dialog.DlgButton, idx int) {
+			a := answer{resolution: RESOLVE_CANCEL, all: DlgConflict.Checked}
+			if button == dialog.BUTTON_OK {
+				switch DlgConflict.Value {
+				case "Overwrite":
+					a.resolution = RESOLVE_OVERWRITE
+				case "Skip":
+					a.resolution = RESOLVE_SKIP
+				case "Rename":
+					a.resolution = RESOLVE_RENAME
+				}
+			}
+			if progressShown {
+				ui.PgsApp.ShowPage("dlgProgress")
+			}
+			c <- a
+		},
+		0,
+		ui.GetCurrentScreen(), ui.App.GetFocus()) // Focus return
+	ui.PgsApp.AddPage("dlgConflict", DlgConflict.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConflict")
+}
+
+// ****************************************************************************
+// finish()
+// finish closes the progress, shows the result of op and the files changed
+// ****************************************************************************
+func finish(op *operation) {
+	if progressShown {
+		ui.PgsApp.SwitchToPage(progressParent)
+		ui.App.SetFocus(progressFocus)
+		progressShown = false
+	}
+	if queued() == 0 {
+		progressHidden = false
+	}
+	if ui.CurrentMode == ui.ModeFiles {
+		if IsDual() {
+			opposite().refresh()
+		}
+		active().refresh()
+		displaySelection()
+		if op.last != "" && op.to != nil && (op.to == cur.panes[0] || op.to == cur.panes[1]) {
+			op.to.focusOn(op.last)
+		}
+	}
+	reopenTrash()
+	journalize(op.journalTitle(), op.changes...)
+	switch {
+	case op.denied == nil:
+	case op.kind == OP_DELETE:
+		deleteElevated(op.denied, fmt.Sprintf("Deleting %d files and folders", len(op.denied)))
+	default:
+		askDeleteElevated(op.denied)
+	}
+
+	var status string
+	switch {
+	case op.cancel.Load():
+		status = fmt.Sprintf("%s cancelled, %d of %d done", op.title(), op.completed, len(op.names))
+	case op.kind == OP_COPY:
+		status = fmt.Sprintf("%d of %d copied to %s", op.completed, len(op.names), op.dir)
+	case op.kind == OP_MOVE:
+		status = fmt.Sprintf("%d of %d moved to %s", op.completed, len(op.names), op.dir)
+	case op.kind == OP_DELETE, op.kind == OP_PURGE:
+		status = fmt.Sprintf("%d of %d deleted", op.completed, len(op.names))
+	case op.kind == OP_TRASH:
+		status = fmt.Sprintf("%d of %d moved to the trash", op.completed, len(op.names))
+	case op.kind == OP_RESTORE:
+		status = fmt.Sprintf("%d of %d restored", op.completed, len(op.names))
+	case op.kind == OP_UNDO:
+		status = fmt.Sprintf("%s undone, %d of %d changes", op.label, op.completed, len(op.reverse))
+	case op.failed == nil:
+		status = "Zipped to " + op.archive
+	default:
+		status = "Zip failed"
+	}
+	if op.skipped > 0 {
+		status += fmt.Sprintf(", %d skipped", op.skipped)
+	}
+	if op.failed == nil {
+		ui.SetStatus(status)
+		return
+	}
+	ui.SetStatus(fmt.Sprintf("%s, %d failed", status, len(op.failed)))
+	failures := op.failed
+	if len(failures) > FAILURES_SHOWN {
+		failures = append(failures[:FAILURES_SHOWN:FAILURES_SHOWN], fmt.Sprintf("and %d more", len(op.failed)-FAILURES_SHOWN))
+	}
+	DlgFailures = DlgFailures.Choice(fmt.Sprintf("%s : %d failed", op.title(), len(op.failed)), // Title
+		strings.Join(failures, "\n"), // Message
+		[]string{"OK"},
+		"",
+		func(button dialog.DlgButton, idx int) { reopenTrash() },
+		0,
+		ui.GetCurrentScreen(), ui.App.GetFocus()) // Focus return
+	ui.PgsApp.AddPage("dlgFailures", DlgFailures.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgFailures")
+}
@@ -0,0 +1,203 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package fm
+
+// ****************************************************************************
+// trash shows the items of the freedesktop.org trash over the File Manager,
+// the last deleted first, to restore them where they were deleted from or
+// to purge them. Both run in the queue of the file operations.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"path/filepath"
+
+	"gosh/dialog"
+	"gosh/keymap"
+	"gosh/logger"
+	"gosh/theme"
+	"gosh/trash"
+	"gosh/ui"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	trashItems  []trash.Item // In the rows of ui.TblTrash, from the second one
+	trashScreen string       // Screen the trash is shown over, "" when closed
+	lblTrash    *tview.TextView
+)
+
+// ****************************************************************************
+// ShowTrash()
+// ****************************************************************************
+func ShowTrash(p any) {
+	if lblTrash == nil {
+		lblTrash = tview.NewTextView()
+		ui.PgsApp.AddPage("dlgTrash", tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(ui.TblTrash, 0, 6, true).
+				AddItem(lblTrash, 1, 0, false).
+				AddItem(nil, 0, 1, false), 0, 6, true).
+			AddItem(nil, 0, 1, false), true, false)
+	}
+	lblTrash.SetText(keymap.Labels(keymap.SCOPE_TRASH))
+	trashScreen = ui.GetCurrentScreen()
+	RefreshTrash()
+	ui.PgsApp.ShowPage("dlgTrash")
+	ui.App.SetFocus(ui.TblTrash)
+}
+
+// ****************************************************************************
+// CloseTrash()
+// ****************************************************************************
+func CloseTrash() {
+	trashScreen = ""
+	ui.PgsApp.HidePage("dlgTrash")
+	ui.App.SetFocus(active().table)
+}
+
+// ****************************************************************************
+// RefreshTrash()
+// RefreshTrash reads the trash again, keeping the cursor
+// ****************************************************************************
+func RefreshTrash() {
+	row, _ := ui.TblTrash.GetSelection()
+	items, problems := trash.List()
+	for _, p := range problems {
+		logger.Warning("fm.go: %s: %v", trash.Dir(), p)
+	}
+	trashItems = items
+	ui.TblTrash.Clear()
+	for i, c := range []string{"Name", "Deleted", "From"} {
+		ui.TblTrash.SetCell(0, i, tview.NewTableCell(c).
+			SetTextColor(theme.SecondaryText).
+			SetSelectable(false))
+	}
+	for i, item := range items {
+		ui.TblTrash.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(filepath.Base(item.Path))).SetTextColor(theme.File))
+		ui.TblTrash.SetCell(i+1, 1, tview.NewTableCell(item.Deleted.Format("2006-01-02 15:04:05")).SetTextColor(theme.Text))
+		ui.TblTrash.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(filepath.Dir(item.Path))).
+			SetTextColor(theme.Text).
+			SetExpansion(1))
+	}
+	ui.TblTrash.SetTitle(fmt.Sprintf("Trash [%d]", len(items)))
+	if row > len(items) {
+		row = len(items)
+	}
+	if row < 1 {
+		row = 1
+	}
+	ui.TblTrash.Select(row, 0)
+}
+
+// ****************************************************************************
+// ApplyTheme()
+// ApplyTheme renders the trash again in the colors of the current theme when
+// it is open, the panes being rendered with their screen
+// ****************************************************************************
+func ApplyTheme() {
+	if trashScreen != "" {
+		RefreshTrash()
+	}
+}
+
+// ****************************************************************************
+// reopenTrash()
+// reopenTrash shows the trash again over its screen after an operation
+// ****************************************************************************
+func reopenTrash() {
+	if trashScreen == "" || trashScreen != ui.GetCurrentScreen() {
+		return
+	}
+	RefreshTrash()
+	ui.PgsApp.ShowPage("dlgTrash")
+	ui.App.SetFocus(ui.TblTrash)
+}
+
+// ****************************************************************************
+// selectedItem()
+// ****************************************************************************
+func selectedItem() (trash.Item, bool) {
+	row, _ := ui.TblTrash.GetSelection()
+	if row < 1 || row > len(trashItems) {
+		return trash.Item{}, false
+	}
+	return trashItems[row-1], true
+}
+
+// ****************************************************************************
+// DoRestore()
+// ****************************************************************************
+func DoRestore() {
+	item, ok := selectedItem()
+	if !ok {
+		return
+	}
+	enqueue(&operation{kind: OP_RESTORE, names: []string{item.File()}, items: []trash.Item{item}})
+}
+
+// ****************************************************************************
+// DoPurge()
+// ****************************************************************************
+func DoPurge() {
+	item, ok := selectedItem()
+	if !ok {
+		return
+	}
+	DlgConfirm = DlgConfirm.YesNo("Purge "+filepath.Base(item.Path), // Title
+		"Are you sure you want to delete it for good ?", // Message
+		func(button dialog.DlgButton, idx int) {
+			if button == dialog.BUTTON_YES {
+				enqueue(&operation{kind: OP_PURGE, names: []string{item.File()}, items: []trash.Item{item}})
+			}
+			reopenTrash()
+		},
+		0,
+		ui.GetCurrentScreen(), ui.TblTrash) // Focus return
+	ui.PgsApp.AddPage("dlgConfirmPurge", DlgConfirm.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConfirmPurge")
+}
+
+// ****************************************************************************
+// DoEmptyTrash()
+// ****************************************************************************
+func DoEmptyTrash() {
+	if len(trashItems) == 0 {
+		ui.SetStatus("The trash is empty")
+		return
+	}
+	items := trashItems
+	DlgConfirm = DlgConfirm.YesNo("Empty Trash", // Title
+		fmt.Sprintf("Are you sure you want to delete the %d items for good ?", len(items)), // Message
+		func(button dialog.DlgButton, idx int) {
+			if button == dialog.BUTTON_YES {
+				var names []string
+				for _, item := range items {
+					names = append(names, item.File())
+				}
+				enqueue(&operation{kind: OP_PURGE, names: names, items: items})
+			}
+			reopenTrash()
+		},
+		0,
+		ui.GetCurrentScreen(), ui.TblTrash) // Focus return
+	ui.PgsApp.AddPage("dlgConfirmEmptyTrash", DlgConfirm.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgConfirmEmptyTrash")
+}
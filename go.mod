@@ -3,18 +3,20 @@ module gosh
 go 1.20
 
 require (
+	github.com/atotto/clipboard v0.1.2
+	github.com/creack/pty v1.1.21
 	github.com/gabriel-vasile/mimetype v1.4.3
 	github.com/gdamore/tcell/v2 v2.6.0
+	github.com/hinshun/vt10x v0.0.0-20220301184237-5011da428d02
 	github.com/mattn/go-sqlite3 v1.14.19
 	github.com/pgavlin/femto v0.0.0-20201224065653-0c9d20f9cac4
 	github.com/rivo/tview v0.0.0-20231126152417-33a1d271f2b6
+	golang.org/x/crypto v0.17.0
 	golang.org/x/sys v0.15.0
 )
 
 require (
-	github.com/atotto/clipboard v0.1.2 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/go-cmd/cmd v1.4.2
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.4 // indirect
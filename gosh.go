@@ -15,26 +15,34 @@ package main
 // ****************************************************************************
 import (
 	"bufio"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"gosh/audit"
 	"gosh/cmd"
 	"gosh/conf"
 	"gosh/edit"
 	"gosh/fm"
 	"gosh/help"
 	"gosh/hexedit"
+	"gosh/keymap"
+	"gosh/logger"
 	"gosh/menu"
 	"gosh/pm"
+	"gosh/settings"
 	"gosh/sq3"
+	"gosh/sudo"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 
@@ -46,11 +54,13 @@ import (
 // GLOBALS
 // ****************************************************************************
 var (
-	appDir   string
-	hostname string
-	greeting string
-	err      error
-	MnuMain  *menu.Menu
+	appDir        string
+	hostname      string
+	greeting      string
+	err           error
+	MnuMain       *menu.Menu
+	MnuCompletion *menu.Menu
+	MnuTheme      *menu.Menu
 )
 
 // ****************************************************************************
@@ -70,9 +80,10 @@ func init() {
 	cmd.CurrentUser = user.Username
 	greeting = cmd.CurrentUser + "@" + hostname + "⯈"
 
-	cmd.ICmd = 0
 	ui.App = tview.NewApplication()
 	ui.SetUI(appQuit, greeting)
+	ui.OnShowScreen = showScreen
+	ui.SetElevated(sudo.Elevated())
 
 	ui.PgsApp.AddPage("shell", ui.FlxShell, true, true)
 	ui.PgsApp.AddPage("dlgQuit", ui.DlgQuit, false, false)
@@ -83,7 +94,6 @@ func init() {
 	}
 	// Set the Current Working Directory
 	conf.Cwd, _ = os.Getwd()
-	fm.Hidden = false
 	appDir = filepath.Join(userDir, conf.APP_FOLDER)
 	if _, err := os.Stat(appDir); errors.Is(err, os.ErrNotExist) {
 		err := os.Mkdir(appDir, os.ModePerm)
@@ -92,34 +102,34 @@ func init() {
 		}
 	}
 
-	conf.LogFile, err = os.OpenFile(filepath.Join(appDir, conf.FILE_LOG), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
-	if err != nil {
+	if err := logger.Open(filepath.Join(appDir, conf.FILE_LOG), ui.SessionID); err != nil {
 		panic(err)
 	}
+	if err := audit.Open(filepath.Join(appDir, conf.FILE_AUDIT)); err != nil {
+		logger.Error("gosh.go: %s", err.Error())
+	}
 
-	jsonFile, err := os.Open(filepath.Join(appDir, conf.FILE_CONFIG))
-	if err == nil {
-		// Read config from json file
-		defer jsonFile.Close()
-		bValues, _ := ioutil.ReadAll(jsonFile)
-		json.Unmarshal(bValues, &ui.MyConfig)
-		ui.SetStatus("Reading config from json")
-	} else {
-		// Set default config (Sorry, default time and date formats are the French way ;)
-		ui.MyConfig.StartupScreen = ui.ModeShell
-		ui.MyConfig.FormatDate = "02/01/2006"
-		ui.MyConfig.FormatTime = "15:04:05"
-		ui.SetStatus("Set default config")
-		// Write config to json file
-		jsonFile, _ := json.MarshalIndent(ui.MyConfig, "", " ")
-		_ = ioutil.WriteFile(filepath.Join(appDir, conf.FILE_CONFIG), jsonFile, 0644)
+	// The themes are needed to check the settings
+	for _, p := range theme.Load(filepath.Join(appDir, conf.FOLDER_THEMES)) {
+		logger.Warning("gosh.go: %s: %v", conf.FOLDER_THEMES, p)
 	}
 
+	// Read the keys before the screens show them
+	for _, p := range keymap.Load(filepath.Join(appDir, conf.FILE_KEYS)) {
+		logger.Warning("gosh.go: %s: %v", conf.FILE_KEYS, p)
+	}
+
+	// Read the settings, the problems being shown at startup
+	settings.OnApply = applySettings
+	config, problems := settings.Load(filepath.Join(appDir, conf.FILE_CONFIG))
+	settings.Apply(config)
+	for _, p := range problems {
+		logger.Warning("gosh.go: %s: %v", conf.FILE_CONFIG, p)
+	}
 	ui.SetStatus(fmt.Sprintf("Starting session #%s", ui.SessionID))
 	readSettings()
-	pm.CurrentView = pm.VIEW_PROCESS
 	pm.InitSignals()
-	sq3.CurrentDatabaseName = ":memory:"
+	sq3.CurrentDatabaseName = ui.MyConfig.SQLite3.Database
 	sq3.SetSQLMenu()
 }
 
@@ -127,125 +137,241 @@ func init() {
 // main()
 // ****************************************************************************
 func main() {
+	// Without the user interface when there are arguments
+	if len(os.Args) > 1 {
+		os.Exit(runBatch(os.Args[1:]))
+	}
+
 	// Main keyboard's events manager
 	ui.App.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyF1:
+		// The program running in the terminal gets all the other keys
+		if ui.TrmConsole.HasFocus() {
+			switch keymap.Match(keymap.SCOPE_TERMINAL, event) {
+			case "terminal.prompt":
+				ui.App.SetFocus(ui.TxtPrompt)
+				return nil
+			case "terminal.stop":
+				cmd.StopCurrentCommand()
+				return nil
+			case "terminal.previous":
+				ui.ShowPreviousScreen()
+				return nil
+			case "terminal.next":
+				ui.ShowNextScreen()
+				return nil
+			}
+			return event
+		}
+		// The dual-pane File Manager takes its keys before the global ones
+		if fm.IsDual() && fm.InPane() {
+			switch keymap.Match(keymap.SCOPE_PANES, event) {
+			case "panes.copy":
+				fm.DoTransfer(false)
+				return nil
+			case "panes.move":
+				fm.DoTransfer(true)
+				return nil
+			case "panes.switch":
+				fm.SwitchPane()
+				return nil
+			case "panes.refresh":
+				fm.RefreshPanes()
+				return nil
+			}
+		}
+		switch keymap.Match(keymap.SCOPE_GLOBAL, event) {
+		case "global.help":
 			ui.AddNewScreen(ui.ModeHelp, help.SelfInit, nil)
 			return nil // Consume the event
-		case tcell.KeyF2:
+		case "global.prompt":
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil // Consume the event
-		case tcell.KeyF3:
+		case "global.close":
 			ui.CloseCurrentScreen()
 			return nil // Consume the event
-		case tcell.KeyF6:
+		case "global.previous":
 			ui.ShowPreviousScreen()
 			return nil // Consume the event
-		case tcell.KeyF7:
+		case "global.next":
 			ui.ShowNextScreen()
 			return nil // Consume the event
-		case tcell.KeyF10:
+		case "global.menu":
 			ShowMainMenu()
 			return nil // Consume the event
-		case tcell.KeyF12:
+		case "global.quit":
 			ShowQuitDialog(nil)
 			return nil // Consume the event
-		case tcell.KeyCtrlC:
-			return nil // Consume the event (standard Ctrl+C behavior)
-		case tcell.KeyCtrlO:
-			if ui.CurrentMode == ui.ModeSQLite3 {
-				sq3.DoOpenDB(conf.Cwd)
-			}
-			if ui.CurrentMode == ui.ModeHexEdit {
-				hexedit.DoOpen(conf.Cwd)
-			}
-			return nil // Consume the event
-		case tcell.KeyCtrlS:
-			if ui.CurrentMode == ui.ModeSQLite3 {
-				sq3.DoCloseDB()
-			}
-			if ui.CurrentMode == ui.ModeHexEdit {
-				hexedit.Close()
-			}
-			return nil // Consume the event
-		case tcell.KeyF4:
+		case "global.stop":
 			if ui.CurrentMode == ui.ModeShell {
 				cmd.StopCurrentCommand()
 			} else {
-				// Default behavior for Esc key when not in shell mode
-				ui.SetStatus("F4 key pressed (not in shell mode)")
+				ui.SetStatus("No command to stop (not in shell mode)")
 				ui.App.ForceDraw()
 			}
 			return nil
 		}
+		// The keys of the whole SQLite3 and HexEdit screens
+		if ui.CurrentMode == ui.ModeSQLite3 {
+			switch keymap.Match(keymap.SCOPE_SQLITE3, event) {
+			case "sqlite3.open":
+				sq3.DoOpenDB(conf.Cwd)
+				return nil
+			case "sqlite3.close":
+				sq3.DoCloseDB()
+				return nil
+			}
+		}
+		if ui.CurrentMode == ui.ModeHexEdit {
+			switch keymap.Match(keymap.SCOPE_HEXEDIT, event) {
+			case "hexedit.open":
+				hexedit.DoOpen(conf.Cwd)
+				return nil
+			case "hexedit.close":
+				hexedit.Close()
+				return nil
+			}
+		}
+		if event.Key() == tcell.KeyCtrlC {
+			// tview quits on the original Ctrl+C : the panels get a copy
+			return tcell.NewEventKey(event.Key(), event.Rune(), event.Modifiers())
+		}
 		return event // Pass on other events
 	})
 
-	// Files panel keyboard's events manager
-	ui.TblFiles.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
+	// Files panes keyboard's events manager
+	filesCapture := func(event *tcell.EventKey) *tcell.EventKey {
+		switch keymap.Match(keymap.SCOPE_FILES, event) {
+		case "files.open":
 			fm.ProceedFileAction()
 			return nil
-		case tcell.KeyF5:
+		case "files.refresh":
 			fm.RefreshMe()
-		case tcell.KeyF8:
+		case "files.menu":
 			fm.ShowMenu()
 			return nil
-		case tcell.KeyCtrlS:
+		case "files.sort":
 			fm.ShowMenuSort()
 			return nil
-		case tcell.KeyInsert:
+		case "files.select":
 			fm.ProceedFileSelect()
 			return nil
-		case tcell.KeyCtrlA:
+		case "files.select_all":
 			fm.SelectAll(nil)
 			return nil
-		case tcell.KeyCtrlC:
+		case "files.copy":
 			fm.DoCopy(nil)
 			return nil
-		case tcell.KeyCtrlX:
+		case "files.cut":
 			fm.DoCut(nil)
 			return nil
-		case tcell.KeyCtrlV:
+		case "files.paste":
 			fm.DoPaste(nil)
 			return nil
-		case tcell.KeyDelete:
+		case "files.delete":
 			fm.DoDelete(nil)
 			return nil
-		case tcell.KeyTab:
+		case "files.delete_forever":
+			fm.DoDeleteForever(nil)
+			return nil
+		case "files.dual":
+			fm.DoSwitchDual(nil)
+			return nil
+		case "files.trash":
+			fm.ShowTrash(nil)
+			return nil
+		case "files.undo":
+			fm.DoUndo(nil)
+			return nil
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			if ui.TxtPrompt.HasFocus() {
-				ui.App.SetFocus(ui.TblFiles)
+				ui.App.SetFocus(ui.TblFilesActive)
 			} else {
 				ui.App.SetFocus(ui.TxtFileInfo)
 			}
 			return nil
 		}
 		return event
+	}
+	for _, tbl := range []*tview.Table{ui.TblFiles, ui.TblFiles2} {
+		tbl := tbl
+		tbl.SetInputCapture(filesCapture)
+		// The pane getting the focus becomes the active one
+		tbl.SetFocusFunc(func() {
+			fm.Focused(tbl)
+		})
+	}
+
+	// Trash keyboard's events manager
+	ui.TblTrash.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch keymap.Match(keymap.SCOPE_TRASH, event) {
+		case "trash.restore":
+			fm.DoRestore()
+			return nil
+		case "trash.purge":
+			fm.DoPurge()
+			return nil
+		case "trash.empty":
+			fm.DoEmptyTrash()
+			return nil
+		case "trash.close":
+			fm.CloseTrash()
+			return nil
+		}
+		return event
+	})
+
+	// Audit panel keyboard's events manager
+	ui.TblAudit.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if keymap.Match(keymap.SCOPE_AUDIT, event) == "audit.refresh" {
+			audit.Refresh()
+			return nil
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
+			ui.App.SetFocus(ui.TxtPrompt)
+			return nil
+		}
+		return event
+	})
+
+	// Settings panel keyboard's events manager
+	ui.FrmSettings.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch keymap.Match(keymap.SCOPE_SETTINGS, event) {
+		case "settings.save":
+			settings.Submit()
+			return nil
+		case "settings.defaults":
+			settings.Defaults()
+			return nil
+		case "settings.reload":
+			settings.Reload()
+			return nil
+		}
+		return event
 	})
 
 	// Process panel keyboard's events manager
 	ui.TblProcess.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
+		switch keymap.Match(keymap.SCOPE_PROCESS, event) {
+		case "process.details":
 			pm.ProceedProcessAction()
 			return nil
-		case tcell.KeyF5:
+		case "process.refresh":
 			pm.RefreshMe()
-		case tcell.KeyF8:
+		case "process.menu":
 			pm.ShowMenu()
 			return nil
-		case tcell.KeyCtrlF:
+		case "process.find":
 			pm.DoFindProcess(nil)
 			return nil
-		case tcell.KeyCtrlS:
+		case "process.sort":
 			pm.ShowMenuSort()
 			return nil
-		case tcell.KeyCtrlV:
+		case "process.view":
 			pm.SwitchView()
 			return nil
-		case tcell.KeyTab:
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			if ui.TxtPrompt.HasFocus() {
 				ui.App.SetFocus(ui.TblProcess)
 				return nil
@@ -264,19 +390,14 @@ func main() {
 
 	// TblProcUsers panel keyboard's events manager
 	ui.TblProcUsers.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
+		if keymap.Match(keymap.SCOPE_USERS, event) == "users.filter" {
 			idx, _ := ui.TblProcUsers.GetSelection()
 			pm.ShowProcesses(ui.TblProcUsers.GetCell(idx, 1).Text)
 			ui.App.Sync()
 			ui.App.SetFocus(ui.TblProcess)
 			return nil
-		/*
-			case tcell.KeyF8:
-				fm.ShowMenu()
-				return nil
-		*/
-		case tcell.KeyTab:
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			if ui.TblProcUsers.HasFocus() {
 				ui.App.SetFocus(ui.TxtProcInfo)
 				return nil
@@ -287,8 +408,7 @@ func main() {
 
 	// ProcInfo keyboard's events manager
 	ui.TxtProcInfo.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTAB:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil
 		}
@@ -297,8 +417,7 @@ func main() {
 
 	// FileInfo keyboard's events manager
 	ui.TxtFileInfo.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTAB:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil
 		}
@@ -307,9 +426,12 @@ func main() {
 
 	// Prompt keyboard's events manager
 	ui.TxtPrompt.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
-			if ui.CurrentMode == ui.ModeSQLite3 {
+		switch keymap.Match(keymap.SCOPE_PROMPT, event) {
+		case "prompt.run":
+			if ui.CurrentMode == ui.ModeAudit {
+				// The prompt filters the entries, empty it shows them all
+				audit.SetFilter(ui.TxtPrompt.GetText())
+			} else if ui.CurrentMode == ui.ModeSQLite3 {
 				if ui.TxtPrompt.GetText() != "" {
 					sq3.Xeq(ui.TxtPrompt.GetText())
 				}
@@ -319,7 +441,7 @@ func main() {
 				}
 			}
 			return nil
-		case tcell.KeyUp:
+		case "prompt.previous":
 			if ui.CurrentMode == ui.ModeSQLite3 {
 				if len(sq3.ACmd) > 0 {
 					if sq3.ICmd < len(sq3.ACmd)-1 {
@@ -331,18 +453,13 @@ func main() {
 					ui.TxtPrompt.Select(0, ui.TxtPrompt.GetTextLength())
 				}
 			} else {
-				if len(cmd.ACmd) > 0 {
-					if cmd.ICmd < len(cmd.ACmd)-1 {
-						cmd.ICmd++
-					} else {
-						cmd.ICmd = 0
-					}
-					ui.TxtPrompt.SetText(cmd.ACmd[cmd.ICmd], true)
+				if c, ok := cmd.PreviousCommand(); ok {
+					ui.TxtPrompt.SetText(c, true)
 					ui.TxtPrompt.Select(0, ui.TxtPrompt.GetTextLength())
 				}
 			}
 			return nil
-		case tcell.KeyDown:
+		case "prompt.next":
 			if ui.CurrentMode == ui.ModeSQLite3 {
 				if len(sq3.ACmd) > 0 {
 					if sq3.ICmd > 0 {
@@ -354,23 +471,42 @@ func main() {
 					ui.TxtPrompt.Select(0, ui.TxtPrompt.GetTextLength())
 				}
 			} else {
-				if len(cmd.ACmd) > 0 {
-					if cmd.ICmd > 0 {
-						cmd.ICmd--
-					} else {
-						cmd.ICmd = len(cmd.ACmd) - 1
-					}
-					ui.TxtPrompt.SetText(cmd.ACmd[cmd.ICmd], true)
+				if c, ok := cmd.NextCommand(); ok {
+					ui.TxtPrompt.SetText(c, true)
 					ui.TxtPrompt.Select(0, ui.TxtPrompt.GetTextLength())
 				}
 			}
 			return nil
-		case tcell.KeyTab:
+		case "prompt.history":
+			if ui.CurrentMode == ui.ModeShell {
+				cmd.ShowHistorySearch()
+				return nil
+			}
+		case "prompt.search":
+			if ui.CurrentMode == ui.ModeShell {
+				ui.ShowConsoleSearch()
+				return nil
+			}
+		case "prompt.menu":
+			if ui.CurrentMode == ui.ModeShell {
+				cmd.ShowConsoleMenu()
+				return nil
+			}
+		case "prompt.complete":
+			// Complete what is typed, an empty prompt goes to the panels
+			if (ui.CurrentMode == ui.ModeShell || ui.CurrentMode == ui.ModeSQLite3) && strings.TrimSpace(ui.TxtPrompt.GetText()) != "" {
+				CompletePrompt()
+				return nil
+			}
 			if ui.CurrentMode == ui.ModeFiles {
-				ui.App.SetFocus(ui.TblFiles)
+				ui.App.SetFocus(ui.TblFilesActive)
 			}
 			if ui.CurrentMode == ui.ModeShell {
-				ui.App.SetFocus(ui.TxtConsole)
+				if ui.TerminalActive() {
+					ui.App.SetFocus(ui.TrmConsole)
+				} else {
+					ui.App.SetFocus(ui.TxtConsole)
+				}
 			}
 			if ui.CurrentMode == ui.ModeProcess {
 				ui.App.SetFocus(ui.TblProcess)
@@ -384,6 +520,9 @@ func main() {
 			if ui.CurrentMode == ui.ModeHexEdit {
 				ui.App.SetFocus(ui.TblHexEdit)
 			}
+			if ui.CurrentMode == ui.ModeAudit {
+				ui.App.SetFocus(ui.TblAudit)
+			}
 			return nil
 		}
 		return event
@@ -391,8 +530,7 @@ func main() {
 
 	// HexEdit keyboard's events manager
 	ui.TblHexEdit.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTAB:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtFileInfo)
 			return nil
 		}
@@ -401,8 +539,46 @@ func main() {
 
 	// Console keyboard's events manager
 	ui.TxtConsole.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
+		switch keymap.Match(keymap.SCOPE_CONSOLE, event) {
+		case "console.search":
+			ui.ShowConsoleSearch()
+			return nil
+		case "console.menu":
+			cmd.ShowConsoleMenu()
+			return nil
+		case "console.previous_block":
+			ui.SelectBlock(-1)
+			return nil
+		case "console.next_block":
+			ui.SelectBlock(1)
+			return nil
+		case "console.fold":
+			ui.ToggleBlock()
+			return nil
+		case "console.clear_search":
+			if ui.ClearConsoleSearch() {
+				return nil
+			}
+		case "console.next_match":
+			ui.NextMatch(1)
+			return nil
+		case "console.previous_match":
+			ui.NextMatch(-1)
+			return nil
+		case "console.unfold_all":
+			ui.CollapseAllBlocks(false)
+			return nil
+		case "console.fold_all":
+			ui.CollapseAllBlocks(true)
+			return nil
+		case "console.copy":
+			ui.CopyBlock()
+			return nil
+		case "console.save":
+			cmd.SaveConsoleBlock(nil)
+			return nil
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil
 		}
@@ -411,44 +587,41 @@ func main() {
 
 	// Editor keyboard's events manager
 	ui.EdtMain.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		evkSaveAs := tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModAlt)
-		if event.Key() == evkSaveAs.Key() && event.Rune() == evkSaveAs.Rune() && event.Modifiers() == evkSaveAs.Modifiers() {
+		switch keymap.Match(keymap.SCOPE_EDITOR, event) {
+		case "editor.save_as":
 			edit.SaveFileAs()
 			return nil
-		}
-		switch event.Key() {
-		case tcell.KeyCtrlS:
+		case "editor.save":
 			edit.SaveFile()
 			return nil
-		case tcell.KeyCtrlN:
+		case "editor.new":
 			edit.NewFile(conf.Cwd)
 			return nil
-		case tcell.KeyCtrlT:
+		case "editor.close":
 			edit.CloseCurrentFile()
 			return nil
-		case tcell.KeyEsc:
+		case "editor.files":
 			ui.App.SetFocus(ui.TblOpenFiles)
 			return nil
 		}
 		return event
 	})
 	ui.TblOpenFiles.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
-			ui.App.SetFocus(ui.TrvExplorer)
-			return nil
-		case tcell.KeyEnter:
+		if keymap.Match(keymap.SCOPE_EDITOR, event) == "editor.open_file" {
 			idx, _ := ui.TblOpenFiles.GetSelection()
 			fName := ui.TblOpenFiles.GetCell(idx, 3).Text
 			edit.SwitchOpenFile(fName)
 			ui.App.SetFocus(ui.EdtMain)
 			return nil
 		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
+			ui.App.SetFocus(ui.TrvExplorer)
+			return nil
+		}
 		return event
 	})
 	ui.TrvExplorer.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil
 		}
@@ -457,27 +630,25 @@ func main() {
 
 	// SQLite3 keyboard's events manager
 	ui.TblSQLOutput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyF8:
+		if keymap.Match(keymap.SCOPE_SQLITE3, event) == "sqlite3.menu" {
 			sq3.ShowMenu()
 			return nil
-		case tcell.KeyTab:
+		}
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TblSQLTables)
 			return nil
 		}
 		return event
 	})
 	ui.TblSQLTables.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TrvSQLDatabase)
 			return nil
 		}
 		return event
 	})
 	ui.TrvSQLDatabase.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
+		if keymap.Match(keymap.SCOPE_PANEL, event) == "panel.next" {
 			ui.App.SetFocus(ui.TxtPrompt)
 			return nil
 		}
@@ -501,15 +672,21 @@ func main() {
 		SwitchToHelp(nil)
 	case ui.ModeHexEdit:
 		SwitchToHexEdit(nil)
+	case ui.ModeAudit:
+		SwitchToAudit(nil)
+	case ui.ModeSettings:
+		SwitchToSettings(nil)
 	}
 	welcome()
+	showSettingsProblems()
+	showKeysProblems()
 
 	go ui.UpdateTime()
 	go utils.GetCpuUsage()
 	var initialFocus tview.Primitive
 	switch ui.MyConfig.StartupScreen {
 	case ui.ModeFiles:
-		initialFocus = ui.TblFiles
+		initialFocus = ui.TblFilesActive
 	case ui.ModeProcess:
 		initialFocus = ui.TblProcess
 	case ui.ModeTextEdit:
@@ -520,17 +697,47 @@ func main() {
 		initialFocus = ui.TblHexEdit
 	case ui.ModeHelp:
 		initialFocus = ui.TxtHelp
+	case ui.ModeAudit:
+		initialFocus = ui.TblAudit
+	case ui.ModeSettings:
+		initialFocus = ui.FrmSettings
 	case ui.ModeShell:
 		initialFocus = ui.FlxShell
 	default:
 		initialFocus = ui.FlxShell
 	}
 
+	// The startup script displays its output when the application is running
+	go cmd.RunStartupScript(filepath.Join(appDir, conf.FILE_RC))
 	if err := ui.App.SetRoot(ui.PgsApp, true).SetFocus(initialFocus).EnableMouse(true).Run(); err != nil {
 		panic(err)
 	}
 }
 
+// ****************************************************************************
+// runBatch()
+// runBatch runs gosh -c 'commands' [arguments] or gosh script [arguments]
+// and returns the exit code
+// ****************************************************************************
+func runBatch(args []string) int {
+	usage := "Usage: gosh [-c commands [name [arguments]] | script [arguments]]\n"
+	switch {
+	case args[0] == "-c":
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, "gosh: -c: option requires an argument\n"+usage)
+			return 2
+		}
+		return cmd.RunBatch(args[1], "", args[2:])
+	case args[0] == "-h" || args[0] == "--help":
+		fmt.Print(usage)
+		return 0
+	case strings.HasPrefix(args[0], "-"):
+		fmt.Fprintf(os.Stderr, "gosh: %s: invalid option\n"+usage, args[0])
+		return 2
+	}
+	return cmd.RunBatch("", args[0], args[1:])
+}
+
 // ****************************************************************************
 // ShowMainMenu()
 // ****************************************************************************
@@ -553,13 +760,113 @@ func ShowMainMenu() {
 	MnuMain.AddItem("mnuTextEdit", "Text Editor", SwitchToTextEdit, nil, true, false)
 	MnuMain.AddItem("mnuSQLite3", "SQLite3 Manager", SwitchToSQLite3, nil, true, false)
 	MnuMain.AddItem("mnuHexEdit", "Hexadecimal Editor", SwitchToHexEdit, nil, true, false)
+	MnuMain.AddItem("mnuAudit", "Audit Log", SwitchToAudit, nil, true, false)
+	MnuMain.AddItem("mnuSettings", "Settings", SwitchToSettings, nil, true, false)
+	MnuMain.AddItem("mnuTheme", "Theme", ShowThemeMenu, nil, true, false)
 	MnuMain.AddSeparator()
+	MnuMain.AddItem("mnuDropSudo", "Drop Elevation", func(p any) { sudo.Forget() }, nil, sudo.Elevated() && os.Geteuid() != 0, false)
 	MnuMain.AddItem("mnuQuit", "Quit", ShowQuitDialog, nil, true, false)
 
 	ui.PgsApp.AddPage("dlgMainMenu", MnuMain.Popup(), true, false)
 	ui.PgsApp.ShowPage("dlgMainMenu")
 }
 
+// ****************************************************************************
+// ShowThemeMenu()
+// ShowThemeMenu lists the themes, the one chosen being used at once
+// ****************************************************************************
+func ShowThemeMenu(p any) {
+	MnuTheme = MnuTheme.New(" Theme ", ui.GetCurrentScreen(), ui.TxtPrompt)
+	for _, name := range theme.Names() {
+		MnuTheme.AddItem("mnuTheme", name, func(p any) {
+			if err := settings.UseTheme(p.(string)); err != nil {
+				ui.SetStatus(err.Error())
+				return
+			}
+			ui.SetStatus(fmt.Sprintf("Theme %s", p.(string)))
+		}, name, true, name == theme.Current())
+	}
+	ui.PgsApp.AddPage("dlgTheme", MnuTheme.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgTheme")
+}
+
+// ****************************************************************************
+// CompletePrompt()
+// CompletePrompt completes the word before the cursor of the prompt, a popup
+// shows the candidates when there are several
+// ****************************************************************************
+func CompletePrompt() {
+	_, _, cursor := ui.TxtPrompt.GetSelection()
+	line := ui.TxtPrompt.GetText()
+	var start int
+	var candidates []string
+	if ui.CurrentMode == ui.ModeSQLite3 {
+		start, candidates = sq3.Complete(line, cursor)
+	} else {
+		start, candidates = cmd.Complete(line, cursor)
+	}
+	switch len(candidates) {
+	case 0:
+		ui.SetStatus("No completion")
+	case 1:
+		insertCompletion(start, cursor, candidates[0], true)
+	default:
+		// Complete as far as possible before asking
+		prefix := commonPrefix(candidates)
+		if len(prefix) > cursor-start {
+			insertCompletion(start, cursor, prefix, false)
+			return
+		}
+		ShowCompletionMenu(start, cursor, candidates)
+	}
+}
+
+// ****************************************************************************
+// ShowCompletionMenu()
+// ****************************************************************************
+func ShowCompletionMenu(start int, end int, candidates []string) {
+	MnuCompletion = MnuCompletion.New(fmt.Sprintf(" %d candidates ", len(candidates)), ui.GetCurrentScreen(), ui.TxtPrompt)
+	for _, c := range candidates {
+		// Only the last part of the paths is displayed
+		label := strings.TrimSuffix(c, "/")
+		label = c[strings.LastIndex(label, "/")+1:]
+		MnuCompletion.AddItem("mnuCompletion", tview.Escape(label), func(p any) {
+			insertCompletion(start, end, p.(string), true)
+		}, c, true, false)
+	}
+	ui.PgsApp.AddPage("dlgCompletion", MnuCompletion.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgCompletion")
+}
+
+// ****************************************************************************
+// insertCompletion()
+// insertCompletion replaces the text of the prompt between start and end, a
+// complete word is followed by a space
+// ****************************************************************************
+func insertCompletion(start int, end int, text string, complete bool) {
+	if complete && !strings.HasSuffix(text, "/") {
+		text += " "
+	}
+	ui.TxtPrompt.Replace(start, end, text)
+}
+
+// ****************************************************************************
+// commonPrefix()
+// ****************************************************************************
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// Don't cut a multibyte character
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
 // ****************************************************************************
 // SwitchToHelp(p any)
 // ****************************************************************************
@@ -609,6 +916,20 @@ func SwitchToHexEdit(p any) {
 	ui.AddNewScreen(ui.ModeHexEdit, hexedit.SelfInit, nil)
 }
 
+// ****************************************************************************
+// SwitchToAudit(p any)
+// ****************************************************************************
+func SwitchToAudit(p any) {
+	ui.AddNewScreen(ui.ModeAudit, audit.SelfInit, nil)
+}
+
+// ****************************************************************************
+// SwitchToSettings(p any)
+// ****************************************************************************
+func SwitchToSettings(p any) {
+	ui.AddNewScreen(ui.ModeSettings, settings.SelfInit, nil)
+}
+
 // ****************************************************************************
 // appQuit()
 // appQuit performs some cleanup and saves persistent data before quitting application
@@ -616,27 +937,110 @@ func SwitchToHexEdit(p any) {
 func appQuit() {
 	// TODO : Clean up gosh_edit_ null files
 	edit.CheckOpenFilesForSaving()
+	edit.RemoveTemporaries()
 	saveSettings()
 	ui.SetStatus(fmt.Sprintf("Quitting session #%s", ui.SessionID))
+	audit.Close()
+	logger.Close()
 	ui.App.Stop()
 	fmt.Printf("\n👻%s\n\n", conf.APP_STRING)
 }
 
+// ****************************************************************************
+// applySettings()
+// applySettings applies the settings saved from the Settings screen to the
+// modules which keep their own copy. The tables and trees are rendered again
+// for the colors of the theme, each module knowing the role of its colors.
+// ****************************************************************************
+func applySettings(c ui.Config) {
+	cmd.SetHistorySize(c.Shell.HistorySize)
+	edit.ApplyTheme()
+	sq3.ApplyTheme()
+	fm.ApplyTheme()
+	audit.ApplyTheme()
+	if len(ui.ArrScreens) > 0 {
+		showScreen()
+	}
+}
+
+// ****************************************************************************
+// showSettingsProblems()
+// showSettingsProblems lists the wrong settings of gosh.json in the console
+// ****************************************************************************
+func showSettingsProblems() {
+	problems := settings.Problems()
+	if len(problems) == 0 {
+		return
+	}
+	ui.OutConsole(fmt.Sprintf("[yellow]gosh: %d problems in %s, the defaults are used instead (F10 > Settings) :", len(problems), tview.Escape(settings.FileName)))
+	for _, p := range problems {
+		ui.OutConsole("  " + tview.Escape(p.Error()))
+	}
+	ui.OutConsole("[-]")
+	ui.SetStatus(fmt.Sprintf("%d problems in %s", len(problems), conf.FILE_CONFIG))
+}
+
+// ****************************************************************************
+// showKeysProblems()
+// showKeysProblems lists the wrong keys and the conflicts of keys.json in the
+// console
+// ****************************************************************************
+func showKeysProblems() {
+	problems := keymap.Problems()
+	if len(problems) == 0 {
+		return
+	}
+	ui.OutConsole(fmt.Sprintf("[yellow]gosh: %d problems in %s :", len(problems), tview.Escape(filepath.Join(appDir, conf.FILE_KEYS))))
+	for _, p := range problems {
+		ui.OutConsole("  " + tview.Escape(p.Error()))
+	}
+	ui.OutConsole("[-]")
+	ui.SetStatus(fmt.Sprintf("%d problems in %s", len(problems), conf.FILE_KEYS))
+}
+
+// ****************************************************************************
+// showScreen()
+// showScreen runs each time a screen is shown
+// ****************************************************************************
+func showScreen() {
+	cmd.ShowPrompt()
+	fm.ShowScreen()
+	pm.ShowScreen()
+	edit.ShowScreen()
+	sq3.ShowScreen()
+	hexedit.ShowScreen()
+}
+
 // ****************************************************************************
 // readSettings()
 // ****************************************************************************
 func readSettings() {
-	// Read commands history file
-	ui.SetStatus("Reading commands history")
-	fCmd, err := os.Open(filepath.Join(appDir, conf.FILE_HISTORY_CMD))
-	if err != nil {
-		return
+	// Read the environment variables exported in the previous sessions
+	if ui.MyConfig.Shell.SaveEnv {
+		ui.SetStatus("Reading environment")
+		fEnv, err := os.Open(filepath.Join(appDir, conf.FILE_ENV))
+		if err == nil {
+			defer fEnv.Close()
+			sEnv := bufio.NewScanner(fEnv)
+			for sEnv.Scan() {
+				name, value, found := strings.Cut(sEnv.Text(), "=")
+				if !found {
+					continue
+				}
+				if value, err = strconv.Unquote(value); err == nil {
+					cmd.Setenv(name, value)
+				}
+			}
+		}
 	}
-	defer fCmd.Close()
-	sCmd := bufio.NewScanner(fCmd)
-	for sCmd.Scan() {
-		cmd.ACmd = append(cmd.ACmd, sCmd.Text())
+	// Read the aliases and the functions shared by the team
+	ui.SetStatus("Reading aliases")
+	if err := cmd.LoadAliases(filepath.Join(appDir, conf.FILE_ALIASES)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("gosh.go: %s", err.Error())
 	}
+	// Read commands history file
+	ui.SetStatus("Reading commands history")
+	cmd.LoadHistory(filepath.Join(appDir, conf.FILE_HISTORY_CMD), ui.MyConfig.Shell.HistorySize)
 	// Read SQL history file
 	ui.SetStatus("Reading SQL history")
 	fSQL, err := os.Open(filepath.Join(appDir, conf.FILE_HISTORY_SQL))
@@ -654,18 +1058,9 @@ func readSettings() {
 // saveSettings()
 // ****************************************************************************
 func saveSettings() {
-	// Save commands history file
+	// The commands are saved as soon as they end, just trim the history file
 	ui.SetStatus("Saving commands history")
-	fCmd, err := os.Create(filepath.Join(appDir, conf.FILE_HISTORY_CMD))
-	if err != nil {
-		return
-	}
-	defer fCmd.Close()
-	wCmd := bufio.NewWriter(fCmd)
-	for _, line := range cmd.ACmd {
-		fmt.Fprintln(wCmd, line)
-	}
-	wCmd.Flush()
+	cmd.SaveHistory()
 	// Save SQL history file
 	ui.SetStatus("Saving SQL history")
 	fSQL, err := os.Create(filepath.Join(appDir, conf.FILE_HISTORY_SQL))
@@ -678,6 +1073,26 @@ func saveSettings() {
 		fmt.Fprintln(wSQL, line)
 	}
 	wSQL.Flush()
+	// Save the environment variables exported in this session
+	if ui.MyConfig.Shell.SaveEnv {
+		ui.SetStatus("Saving environment")
+		fEnv, err := os.Create(filepath.Join(appDir, conf.FILE_ENV))
+		if err != nil {
+			return
+		}
+		defer fEnv.Close()
+		vars := cmd.SessionVars()
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		wEnv := bufio.NewWriter(fEnv)
+		for _, name := range names {
+			fmt.Fprintf(wEnv, "%s=%s\n", name, strconv.Quote(vars[name]))
+		}
+		wEnv.Flush()
+	}
 }
 
 // ****************************************************************************
@@ -700,7 +1115,7 @@ func welcome() {
 			w2 = w2 + string(out)
 		}
 	}
-	ui.LblHostname.SetText("👻" + greeting)
+	cmd.ShowPrompt()
 	ui.HeaderConsole(w1)
 	ui.OutConsole(w2)
 }
@@ -10,7 +10,10 @@
 // ****************************************************************************
 package help
 
-import "gosh/ui"
+import (
+	"gosh/keymap"
+	"gosh/ui"
+)
 
 // ****************************************************************************
 // SelfInit()
@@ -29,122 +32,209 @@ func SetHelp() {
 		|_____|_____|_____|__|__|
         [red]Copyright jpl@ozf.fr 2024
 
-[white]Gosh is a TUI (Text User Interface) for common management functions on a Linux system.
-Gosh is written in Go. The main layout interface is inspired by [green]AS400[white] text console.
+[-]Gosh is a TUI (Text User Interface) for common management functions on a Linux system.
+Gosh is written in Go. The main layout interface is inspired by [green]AS400[-] text console.
 		
 	╔════╦═══════════╦═══════╗
-	║ [yellow]F1[white] ║ [red]This Help[white] ║ [yellow]!help[white] ║
+	║ [yellow]F1[-] ║ [red]This Help[-] ║ [yellow]!help[-] ║
 	╚════╩═══════════╩═══════╝
 	
-	╔═══════════════════════════════════════════════════╗
- 	║           [red]Fast access to common features[white]          ║
- 	╠═════╦══════════════════════════════╦══════════════╣
- 	║ [yellow]F1[white]  ║ This help                    ║ [yellow]!help[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F2[white]  ║ Shell                        ║ [yellow]!shel[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F3[white]  ║ Files Manager                ║ [yellow]!file[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F4[white]  ║ Process and Services Manager ║ [yellow]!proc[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F5[white]  ║ (refresh)                    ║              ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F6[white]  ║ Text Editor                  ║ [yellow]!edit[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F7[white]  ║ Network Manager              ║ [yellow]!net[white]         ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F8[white]  ║ (special functions)          ║              ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F9[white]  ║ SQLite3 Manager              ║ [yellow]!sql[white]         ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F10[white] ║ Users Manager                ║ [yellow]!user[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F11[white] ║ Dashboard                    ║ [yellow]!dash[white]        ║
- 	╠═════╬══════════════════════════════╬══════════════╣
- 	║ [yellow]F12[white] ║ Exit                         ║ [yellow]!quit[white], [yellow]!exit[white] ║
- 	╚═════╩══════════════════════════════╩══════════════╝
-
+	The keys of every screen (but a program running in the terminal) :
+` + keymap.Help(keymap.SCOPE_GLOBAL) + `
+	The keys are set in ~/.gosh/keys.json, each action (like "files.sort") with its keys separated
+	by spaces : F5, Ctrl+S, Alt+s, Shift+Up, Delete, Insert, Enter, Tab, Esc, Space or a character.
+	The part of the action before the dot tells the screen or the panel where it is read, the keys
+	above being read first. The file is written with the default keys when it is missing, and the
+	unknown actions, the wrong keys and the keys bound twice are reported in the console at startup.
+	The keys bar at the bottom of the screens follows these keys, like this help.
 
 	╔════╦═══════╦═══════╗
-	║ [yellow]F2[white] ║ [red]Shell[white] ║ [yellow]!shel[white] ║
+	║ [yellow]F2[-] ║ [red]Shell[-] ║ [yellow]!shel[-] ║
 	╚════╩═══════╩═══════╝
 
+	In the prompt, the completion covers the commands, the files, the $VARs and the !commands, and
+	the search of the history goes on with its key for an older match :
+` + keymap.Help(keymap.SCOPE_PROMPT) + `
+	[yellow]history [-cv[] [n[]    [-] : Show the last n commands with their date, RC and duration (or clear the history)
+	[yellow]!! !n !-n            [-] : Repeat the last command, the command number n, or the nth previous command
+	[yellow]cmd1 | cmd2          [-] : Send the output of cmd1 to the input of cmd2
+	[yellow]cmd < in > out       [-] : Read the input from file in, write the output to file out
+	[yellow]cmd >> out 2>&1      [-] : Append the output and the errors to file out
+	[yellow]cmd1 ; cmd2          [-] : Run cmd1 then cmd2
+	[yellow]cmd1 && cmd2         [-] : Run cmd2 only if cmd1 succeeds
+	[yellow]cmd1 || cmd2         [-] : Run cmd2 only if cmd1 fails
+	[yellow]'...' "..." \        [-] : Quote or escape special characters
+	[yellow]* ? [...[]            [-] : Expand file names matching the pattern
+	[yellow]~ ~user              [-] : Expand to the home folder of the current user or of user
+	[yellow]cd [dir[]             [-] : Change the current folder to dir (home folder by default)
+	[yellow]cd -                 [-] : Go back to the previous folder
+	[yellow]pushd [dir[]          [-] : Save the current folder on the stack and go to dir
+	[yellow]popd                 [-] : Go back to the folder on top of the stack
+	[yellow]dirs [-c[] [-v[] [-l[]  [-] : Show (or clear) the folders stack
+	[yellow]$VAR ${VAR:-default} [-] : Expand to the value of VAR (or to default if VAR is empty)
+	[yellow]$?                   [-] : Expand to the return code of the last command
+	[yellow]export VAR=value     [-] : Set the variable VAR for all the commands of the session
+	[yellow]unset VAR            [-] : Remove the variable VAR from the environment
+	[yellow]env                  [-] : Show the environment of the session
+	[yellow]cmd &                [-] : Run cmd in the background, the number of jobs is shown in the header
+	[yellow]jobs                 [-] : List the background jobs
+	[yellow]fg [%n[]              [-] : Bring the job n (the last one by default) to the console and wait for it
+	[yellow]wait [%n[]            [-] : Wait for the end of the job n (all the jobs by default)
+	[yellow]kill [-SIG[] %n|pid   [-] : Send a signal (TERM by default) to the job n or to a process
+	[yellow]alias name='cmd'     [-] : Define an alias (alias alone lists them)
+	[yellow]unalias [-a[] name    [-] : Remove an alias (or all of them)
+	[yellow]name() { cmd; }      [-] : Define a function, its arguments are $1 to $9, $# and $@
+	[yellow]functions [name[]     [-] : Show the functions (unset -f name removes one)
+
+	[yellow]!source file [args[]  [-] : Run the commands of file in the shell, its arguments are $1 to $9
+	[yellow]exit [n[]             [-] : Leave a script with the return code n (in the prompt, ask to quit)
+
+	The aliases and the functions (written on several lines if needed) of ~/.gosh/aliases are
+	loaded at startup, so that a team can share the same shortcuts.
+	The commands of ~/.gosh/goshrc are run at startup, like with !source.
+	Without the user interface, gosh -c 'commands' or gosh script runs the same commands and
+	returns the last return code.
+
+	[yellow]!ssh [-p port[] [-i key[] user@host[-] : Open a Shell screen on a remote host (F3 or exit closes it)
+	The keys of the SSH agent, ~/.ssh/id_ed25519, id_ecdsa and id_rsa are tried, and the host key
+	is checked with ~/.ssh/known_hosts : an unknown host is added when you trust it. Each command
+	runs from the current folder of the screen, without a terminal, and cd changes this folder.
+	The prompt is set by "shell.prompt" in ~/.gosh/gosh.json, with colors like [yellow[] and these
+	placeholders : {user} {host} {cwd} {dir} {git} (branch) {rc} {duration} (of the last
+	command) {time} {jobs} (running jobs) {sudo} (# when running as root).
+
+	In the console, each command and its output make a block, the last one being selected by default :
+` + keymap.Help(keymap.SCOPE_CONSOLE) + `	The console menu opens the output in the Editor, imports it as a table of the SQLite3 database
+	(columns, CSV or JSON), or selects the files it lists in the File Manager.
+	The console keeps the last "shell.console_max_lines" lines of ~/.gosh/gosh.json.
+	When a command runs longer than "shell.notify_after" seconds (-1 for never), its end is notified
+	over any screen with a toast and the bell, then "shell.notify_hook" is run if set, with the
+	command, its return code and its runtime in seconds as arguments.
+
+	The commands run in a terminal : full screen programs like top, vim or less are displayed
+	in place of the console while they are running, and receive all the keys but these ones :
+` + keymap.Help(keymap.SCOPE_TERMINAL) + `	The completion key on an empty prompt goes back to the program.
 
 	╔════╦═══════════════╦═══════╗
-	║ [yellow]F3[white] ║ [red]Files Manager[white] ║ [yellow]!file[white] ║
+	║ [yellow]F3[-] ║ [red]Files Manager[-] ║ [yellow]!file[-] ║
 	╚════╩═══════════════╩═══════╝
 
-	[yellow]TAB  [white]  : Move between panels
-	[yellow]Del  [white]  : Delete the file or folder highlighted or the selection
-	[yellow]Ins  [white]  : Add the current file or folder to the selection
-	[yellow]Ctrl+A[white] : Select or unselect all the files and folders in the current folder
-	[yellow]Ctrl+C[white] : Select or unselect all the files and folders in the current folder
+` + keymap.Help(keymap.SCOPE_FILES) + keymap.Help(keymap.SCOPE_PANEL) + `
+	The File Manager shows one pane, or two side by side with "Dual pane" in its menu. Each pane
+	has its own folder, sort and selection, kept by screen, and the active one gives its folder
+	to the prompt. New Files screens show two panes when "files.dual_pane" of ~/.gosh/gosh.json
+	is true. With two panes, these keys are read before the global ones :
+` + keymap.Help(keymap.SCOPE_PANES) + `	They hide the global keys and the panel key while a pane has the focus.
+	The copies, moves, deletions and zips run in the background, one after the other, with their
+	progress (bytes, files, time left) in a dialog : Hide keeps it in the status bar, Cancel or Esc
+	stops it. When a target already exists, choose Overwrite (two folders are merged), Skip or
+	Rename, for this file or, with "Apply to all", for the next ones. A moved file is deleted only
+	once copied, and the failures are listed at the end.
+	The files deleted go to the trash of the desktop (~/.local/share/Trash), where the other file
+	managers find them too, unless "Delete permanently" is used. A file which can't be moved there
+	for lack of rights can be deleted for good with sudo. "Trash" in the menu shows it :
+` + keymap.Help(keymap.SCOPE_TRASH) + `	An item is restored where it was deleted from, asking what to do when a file is there again.
+	"Undo last operation" in the menu reverses the renames, moves, copies, new files and folders,
+	timestamps, snapshots, zips and trashes of the session, the last one first : what was created
+	goes to the trash. A file which was merged or overwritten can't be undone.
 	
 	╔════╦══════════════════════════════╦═══════╗
-	║ [yellow]F4[white] ║ [red]Process and Services Manager[white] ║ [yellow]!proc[white] ║
+	║ [yellow]F4[-] ║ [red]Process and Services Manager[-] ║ [yellow]!proc[-] ║
 	╚════╩══════════════════════════════╩═══════╝
 
+` + keymap.Help(keymap.SCOPE_PROCESS) + `	In the list of the users :
+` + keymap.Help(keymap.SCOPE_USERS) + `
+	When killing a process of another user, managing a service or deleting a protected file is
+	refused, your password is asked to do it again with sudo. gosh doesn't keep it : sudo
+	remembers it for at most "sudo_timeout" minutes of ~/.gosh/gosh.json, while the header
+	shows[white:darkred] ⚡ELEVATED [-:-], or until "Drop Elevation" in the main menu (F10).
+
+	The deletions, renames, kills, renices, signals, service actions, SQL commands and file saves
+	are recorded with their result in ~/.gosh/audit.jsonl, one JSON object per line.
+	[yellow]!audit[-] or "Audit Log" in the main menu (F10) shows them from the newest one. Type a filter
+	in the prompt, each word matching any column or one of them : action:kill user:bob
+	screen:files result:failed.
+` + keymap.Help(keymap.SCOPE_AUDIT) + `	The other messages go to ~/.gosh/gosh.log, from the "log.level" of gosh.json (debug, info,
+	warning or error). Beyond "log.max_size" KB (0 for never), it is renamed gosh.log.1 and
+	the "log.max_files" older files are kept.
+
+	[yellow]!settings[-] or "Settings" in the main menu (F10) edits ~/.gosh/gosh.json, one section at a time :
+	the values are checked, then saved and applied at once with Save. A wrong value of gosh.json is
+	reported in the console at startup and replaced by its default. The file of an older gosh
+	is migrated to the current version, the old one being kept as gosh.json.v1 (or .v2...).
+` + keymap.Help(keymap.SCOPE_SETTINGS) + `
+	[yellow]!theme [name][-] or "Theme" in the main menu (F10) colors all the screens, the menus, the dialogs
+	and the editor with one of the themes of ~/.gosh/themes (dark, light, high-contrast, solarized
+	and yours), saved as "theme" in gosh.json. A theme is a JSON file giving a color to each
+	role (background, text, header, status_background, menu, folder...), the roles left out
+	taking the ones of dark, and "editor" naming the colorscheme of the editor (monokai...).
+	The "colors" of gosh.json, when set, replace the ones of the theme for the files.
+
 	╔════╦════════╦═══════╗
-	║ [yellow]F6[white] ║ [red]Editor[white] ║ [yellow]!edit[white] ║
+	║ [yellow]F6[-] ║ [red]Editor[-] ║ [yellow]!edit[-] ║
 	╚════╩════════╩═══════╝
-	
+
+` + keymap.Help(keymap.SCOPE_EDITOR) + `	
 	╔════╦═════════════════╦══════╗
-	║ [yellow]F7[white] ║ [red]Network Manager[white] ║ [yellow]!net[white] ║
+	║ [yellow]F7[-] ║ [red]Network Manager[-] ║ [yellow]!net[-] ║
 	╚════╩═════════════════╩══════╝
 
  	╔════╦═════════════════╦══════╗
- 	║ [yellow]F9[white] ║ [red]SQLite3 Manager[white] ║ [yellow]!sql[white] ║
+ 	║ [yellow]F9[-] ║ [red]SQLite3 Manager[-] ║ [yellow]!sql[-] ║
  	╚════╩═════════════════╩══════╝
 
+` + keymap.Help(keymap.SCOPE_SQLITE3) + `
+The completion key of the prompt completes the .commands and the names of the tables and of the columns.
+
 Here are the .commands available :
  	╔════════════════╦═══════════════════════════════════════════════════╗
- 	║ [yellow].OPEN database[white] ║ Open the database by its file name                ║
+ 	║ [yellow].OPEN database[-] ║ Open the database by its file name                ║
  	╠════════════════╬═══════════════════════════════════════════════════╣
- 	║ [yellow].TABLE[white]         ║ List all tables available in the current database ║
+ 	║ [yellow].TABLE[-]         ║ List all tables available in the current database ║
  	╠════════════════╬═══════════════════════════════════════════════════╣
- 	║ [yellow].DATABASE[white]      ║ List names and files of attached databases        ║
+ 	║ [yellow].DATABASE[-]      ║ List names and files of attached databases        ║
  	╠════════════════╬═══════════════════════════════════════════════════╣
- 	║ [yellow].SCHEMA table[white]  ║ Show the CREATE statements for the matching table ║
+ 	║ [yellow].SCHEMA table[-]  ║ Show the CREATE statements for the matching table ║
  	╠════════════════╬═══════════════════════════════════════════════════╣
- 	║ [yellow].COLUMNS table[white] ║ Show the columns types for the matching table     ║
+ 	║ [yellow].COLUMNS table[-] ║ Show the columns types for the matching table     ║
  	╚════════════════╩═══════════════════════════════════════════════════╝
 
 The common SQL statements are summarized as following :
 	╔════════════════════════════════════════╦════════════════════════════════════════════════════════════════════════════╗
 	║                                        ║ ANALYZE;                                                                   ║
 	║                                        ║ or                                                                         ║
-	║ SQLite [yellow]ANALYZE[white] Statement               ║ ANALYZE database_name;                                                     ║
+	║ SQLite [yellow]ANALYZE[-] Statement               ║ ANALYZE database_name;                                                     ║
 	║                                        ║ or                                                                         ║
 	║                                        ║ ANALYZE database_name.table_name;                                          ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2….columnN                                           ║
-	║ SQLite [yellow]AND/OR[white] Clause                   ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]AND/OR[-] Clause                   ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  CONDITION-1 {AND|OR} CONDITION-2;                                   ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]ALTER TABLE[white] Statement           ║ ALTER TABLE table_name ADD COLUMN column_def…;                             ║
+	║ SQLite [yellow]ALTER TABLE[-] Statement           ║ ALTER TABLE table_name ADD COLUMN column_def…;                             ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]ALTER TABLE[white] Statement (Rename)  ║ ALTER TABLE table_name RENAME TO new_table_name;                           ║
+	║ SQLite [yellow]ALTER TABLE[-] Statement (Rename)  ║ ALTER TABLE table_name RENAME TO new_table_name;                           ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]ATTACH DATABASE[white] Statement       ║ ATTACH DATABASE ‘DatabaseName’ As ‘Alias-Name’;                            ║
+	║ SQLite [yellow]ATTACH DATABASE[-] Statement       ║ ATTACH DATABASE ‘DatabaseName’ As ‘Alias-Name’;                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ BEGIN;                                                                     ║
-	║ SQLite [yellow]BEGIN TRANSACTION[white] Statement     ║ or                                                                         ║
+	║ SQLite [yellow]BEGIN TRANSACTION[-] Statement     ║ or                                                                         ║
 	║                                        ║ BEGIN EXCLUSIVE TRANSACTION;                                               ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2….columnN                                           ║
-	║ SQLite [yellow]BETWEEN[white] Clause                  ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]BETWEEN[-] Clause                  ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name BETWEEN val-1 AND val-2;                                ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]CREATE INDEX[white] Statement          ║ CREATE INDEX index_name                                                    ║
+	║ SQLite [yellow]CREATE INDEX[-] Statement          ║ CREATE INDEX index_name                                                    ║
 	║                                        ║ ON table_name ( column_name COLLATE NOCASE );                              ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]CREATE UNIQUE INDEX[white] Statement   ║ CREATE UNIQUE INDEX index_name                                             ║
+	║ SQLite [yellow]CREATE UNIQUE INDEX[-] Statement   ║ CREATE UNIQUE INDEX index_name                                             ║
 	║                                        ║ ON table_name ( column1, column2,…columnN);                                ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ CREATE TABLE table_name(                                                   ║
 	║                                        ║    column1 datatype,                                                       ║
 	║                                        ║    column2 datatype,                                                       ║
-	║ SQLite [yellow]CREATE TABLE[white] Statement          ║    column3 datatype,                                                       ║
+	║ SQLite [yellow]CREATE TABLE[-] Statement          ║    column3 datatype,                                                       ║
 	║                                        ║    …                                                                       ║
 	║                                        ║    columnN data type,                                                      ║
 	║                                        ║    PRIMARY KEY( one or more columns ));                                    ║
@@ -152,121 +242,123 @@ The common SQL statements are summarized as following :
 	║                                        ║ CREATE TRIGGER database_name.trigger_name                                  ║
 	║                                        ║ BEFORE INSERT ON table_name FOR EACH ROW                                   ║
 	║                                        ║ BEGIN                                                                      ║
-	║ SQLite [yellow]CREATE TRIGGER[white] Statement        ║    stmt1;                                                                  ║
+	║ SQLite [yellow]CREATE TRIGGER[-] Statement        ║    stmt1;                                                                  ║
 	║                                        ║    stmt2;                                                                  ║
 	║                                        ║    …                                                                       ║
 	║                                        ║ END;                                                                       ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]CREATE VIEW[white] Statement           ║ CREATE VIEW database_name.view_name  AS                                    ║
+	║ SQLite [yellow]CREATE VIEW[-] Statement           ║ CREATE VIEW database_name.view_name  AS                                    ║
 	║                                        ║ SELECT statement…;                                                         ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ CREATE VIRTUAL TABLE database_name.table_name USING weblog( access.log );  ║
-	║ SQLite [yellow]CREATE VIRTUAL TABLE[white] Statement  ║ or                                                                         ║
+	║ SQLite [yellow]CREATE VIRTUAL TABLE[-] Statement  ║ or                                                                         ║
 	║                                        ║ CREATE VIRTUAL TABLE database_name.table_name USING fts3( );               ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]COMMIT TRANSACTION[white] Statement    ║ COMMIT;                                                                    ║
+	║ SQLite [yellow]COMMIT TRANSACTION[-] Statement    ║ COMMIT;                                                                    ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT COUNT(column_name)                                                  ║
-	║ SQLite [yellow]COUNT[white] Clause                    ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]COUNT[-] Clause                    ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  CONDITION;                                                          ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DELETE FROM table_name                                                     ║
-	║ SQLite [yellow]DELETE[white] Statement                ║ WHERE  {CONDITION};                                                        ║
+	║ SQLite [yellow]DELETE[-] Statement                ║ WHERE  {CONDITION};                                                        ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DETACH DATABASE ‘Alias-Name’;                                              ║
-	║ SQLite [yellow]DETACH DATABASE[white] Statement       ║                                                                            ║
+	║ SQLite [yellow]DETACH DATABASE[-] Statement       ║                                                                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT DISTINCT column1, column2… columnN                                  ║
-	║ SQLite [yellow]DISTINCT[white] Clause                 ║ FROM   table_name;                                                         ║
+	║ SQLite [yellow]DISTINCT[-] Clause                 ║ FROM   table_name;                                                         ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DROP INDEX database_name.index_name;                                       ║
-	║ SQLite [yellow]DROP INDEX[white] Statement            ║                                                                            ║
+	║ SQLite [yellow]DROP INDEX[-] Statement            ║                                                                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DROP TABLE database_name.table_name;                                       ║
-	║ SQLite [yellow]DROP TABLE[white] Statement            ║                                                                            ║
+	║ SQLite [yellow]DROP TABLE[-] Statement            ║                                                                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DROP INDEX database_name.view_name;                                        ║
-	║ SQLite [yellow]DROP VIEW[white] Statement             ║                                                                            ║
+	║ SQLite [yellow]DROP VIEW[-] Statement             ║                                                                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ DROP INDEX database_name.trigger_name;                                     ║
-	║ SQLite [yellow]DROP TRIGGER[white] Statement          ║                                                                            ║
+	║ SQLite [yellow]DROP TRIGGER[-] Statement          ║                                                                            ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]EXISTS[white] Clause                   ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]EXISTS[-] Clause                   ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name EXISTS (SELECT * FROM   table_name );                   ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ EXPLAIN INSERT statement…;                                                 ║
-	║ SQLite [yellow]EXPLAIN[white] Statement               ║ or                                                                         ║
+	║ SQLite [yellow]EXPLAIN[-] Statement               ║ or                                                                         ║
 	║                                        ║ EXPLAIN QUERY PLAN SELECT statement…;                                      ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]GLOB[white] Clause                     ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]GLOB[-] Clause                     ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name GLOB { PATTERN };                                       ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT SUM(column_name)                                                    ║
-	║ SQLite [yellow]GROUP BY[white] Clause                 ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]GROUP BY[-] Clause                 ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  CONDITION GROUP BY column_name;                                     ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT SUM(column_name)                                                    ║
 	║                                        ║ FROM   table_name                                                          ║
-	║ SQLite [yellow]HAVING[white] Clause                   ║ WHERE  CONDITION                                                           ║
+	║ SQLite [yellow]HAVING[-] Clause                   ║ WHERE  CONDITION                                                           ║
 	║                                        ║ GROUP BY column_name                                                       ║
 	║                                        ║ HAVING (arithmetic function condition);                                    ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]INSERT INTO[white] Statement           ║ INSERT INTO table_name( column1, column2… columnN)                         ║
+	║ SQLite [yellow]INSERT INTO[-] Statement           ║ INSERT INTO table_name( column1, column2… columnN)                         ║
 	║                                        ║ VALUES ( value1, value2… valueN);                                          ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]IN[white] Clause                       ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]IN[-] Clause                       ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name IN (val-1, val-2,… val-N);                              ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]LIKE[white] Clause                     ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]LIKE[-] Clause                     ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name LIKE { PATTERN };                                       ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]NOT IN[white] Clause                   ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]NOT IN[-] Clause                   ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  column_name NOT IN (val-1, val-2,… val-N);                          ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]ORDER BY[white] Clause                 ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]ORDER BY[-] Clause                 ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  CONDITION                                                           ║
 	║                                        ║ ORDER BY column_name {ASC|DESC};                                           ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]PRAGMA[white] Statement                ║ PRAGMA pragma_name;                                                        ║
+	║ SQLite [yellow]PRAGMA[-] Statement                ║ PRAGMA pragma_name;                                                        ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]RELEASE[white] SAVEPOINT Statement     ║ RELEASE savepoint_name;                                                    ║
+	║ SQLite [yellow]RELEASE[-] SAVEPOINT Statement     ║ RELEASE savepoint_name;                                                    ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ REINDEX collation_name;                                                    ║
-	║ SQLite [yellow]REINDEX[white] Statement               ║ REINDEX database_name.index_name;                                          ║
+	║ SQLite [yellow]REINDEX[-] Statement               ║ REINDEX database_name.index_name;                                          ║
 	║                                        ║ REINDEX database_name.table_name;                                          ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ ROLLBACK;                                                                  ║
-	║ SQLite [yellow]ROLLBACK[white] Statement              ║ or                                                                         ║
+	║ SQLite [yellow]ROLLBACK[-] Statement              ║ or                                                                         ║
 	║                                        ║ ROLLBACK TO SAVEPOINT savepoint_name;                                      ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]SAVEPOINT[white] Statement             ║ SAVEPOINT savepoint_name;                                                  ║
+	║ SQLite [yellow]SAVEPOINT[-] Statement             ║ SAVEPOINT savepoint_name;                                                  ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]SELECT[white] Statement                ║ SELECT column1, column2… columnN                                           ║
+	║ SQLite [yellow]SELECT[-] Statement                ║ SELECT column1, column2… columnN                                           ║
 	║                                        ║ FROM   table_name;                                                         ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ UPDATE table_name                                                          ║
-	║ SQLite [yellow]UPDATE[white] Statement                ║ SET column1 = value1, column2 = value2… columnN=valueN                     ║
+	║ SQLite [yellow]UPDATE[-] Statement                ║ SET column1 = value1, column2 = value2… columnN=valueN                     ║
 	║                                        ║ [ WHERE  CONDITION ];                                                      ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
-	║ SQLite [yellow]VACUUM[white] Statement                ║ VACUUM;                                                                    ║
+	║ SQLite [yellow]VACUUM[-] Statement                ║ VACUUM;                                                                    ║
 	╠════════════════════════════════════════╬════════════════════════════════════════════════════════════════════════════╣
 	║                                        ║ SELECT column1, column2… columnN                                           ║
-	║ SQLite [yellow]WHERE[white] Clause                    ║ FROM   table_name                                                          ║
+	║ SQLite [yellow]WHERE[-] Clause                    ║ FROM   table_name                                                          ║
 	║                                        ║ WHERE  CONDITION;                                                          ║
 	╚════════════════════════════════════════╩════════════════════════════════════════════════════════════════════════════╝
 
+	In the Hexedit screen :
+` + keymap.Help(keymap.SCOPE_HEXEDIT) + `
 	╔═════╦═══════════════╦═══════╗
-	║ [yellow]F10[white] ║ [red]Users Manager[white] ║ [yellow]!user[white] ║
+	║ [yellow]F10[-] ║ [red]Users Manager[-] ║ [yellow]!user[-] ║
 	╚═════╩═══════════════╩═══════╝
 
 	╔═════╦═══════════╦═══════╗
-	║ [yellow]F11[white] ║ [red]Dashboard[white] ║ [yellow]!dash[white] ║
+	║ [yellow]F11[-] ║ [red]Dashboard[-] ║ [yellow]!dash[-] ║
 	╚═════╩═══════════╩═══════╝
 `)
 }
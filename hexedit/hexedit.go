@@ -17,36 +17,96 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
-	"gosh/conf"
 	"gosh/dialog"
 	"gosh/preview"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
 	"unicode"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type screen struct {
+	fName string
+	row   int // Cursor while the screen is hidden
+}
+
 // ****************************************************************************
 // VARS
 // ****************************************************************************
 var (
-	DlgOpen               *dialog.Dialog
-	headerBackgroundColor = tcell.ColorBlue
-	headerTextColor       = tcell.ColorWhite
-	CurrentHexFile        string
+	DlgOpen *dialog.Dialog
+	screens = make(map[string]*screen) // The file of each Hexedit screen
+	cur     *screen
 )
 
+// ****************************************************************************
+// current()
+// current returns the state of the Hexedit screen shown
+// ****************************************************************************
+func current() *screen {
+	if cur == nil {
+		cur = &screen{}
+	}
+	return cur
+}
+
+// ****************************************************************************
+// ShowScreen()
+// ShowScreen reads again the file of the Hexedit screen shown, forgetting the
+// screens which are closed
+// ****************************************************************************
+func ShowScreen() {
+	for id := range screens {
+		if !ui.IsScreenOpen(id) {
+			delete(screens, id)
+		}
+	}
+	if ui.CurrentMode != ui.ModeHexEdit {
+		return
+	}
+	if cur != nil {
+		cur.row, _ = ui.TblHexEdit.GetSelection()
+	}
+	id := ui.GetCurrentScreen()
+	s, ok := screens[id]
+	if !ok {
+		s = &screen{}
+		screens[id] = s
+	}
+	cur = s
+	if s.fName == "" {
+		clearScreen()
+		return
+	}
+	load(s.fName)
+	if s.row < ui.TblHexEdit.GetRowCount() {
+		ui.TblHexEdit.Select(s.row, 0)
+	}
+}
+
 // ****************************************************************************
 // OpenFile()
 // ****************************************************************************
 func OpenFile(fName string) {
-	CurrentHexFile = fName
+	load(fName)
+	ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
+	ui.App.SetFocus(ui.TxtPrompt)
+	ui.SetStatus(fmt.Sprintf("File %s successfully open", fName))
+}
+
+// ****************************************************************************
+// load()
+// load fills the table with the bytes of fName
+// ****************************************************************************
+func load(fName string) {
+	current().fName = fName
 	f, err := os.Open(fName)
 	if err != nil {
 		ui.SetStatus(err.Error())
@@ -59,12 +119,12 @@ func OpenFile(fName string) {
 	var ascii string
 	ui.TblHexEdit.Clear()
 
-	ui.TblHexEdit.SetCell(0, 0, tview.NewTableCell("offset").SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor).SetAlign(tview.AlignCenter))
+	ui.TblHexEdit.SetCell(0, 0, tview.NewTableCell("offset").SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground).SetAlign(tview.AlignCenter))
 	for i := 0; i < 16; i++ {
-		ui.TblHexEdit.SetCell(0, i+1, tview.NewTableCell(fmt.Sprintf("%02X", i)).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
+		ui.TblHexEdit.SetCell(0, i+1, tview.NewTableCell(fmt.Sprintf("%02X", i)).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
 	}
 	for i := 0; i < 16; i++ {
-		ui.TblHexEdit.SetCell(0, i+17, tview.NewTableCell(fmt.Sprintf("%01X", i)).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
+		ui.TblHexEdit.SetCell(0, i+17, tview.NewTableCell(fmt.Sprintf("%01X", i)).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
 	}
 
 	for {
@@ -77,7 +137,7 @@ func OpenFile(fName string) {
 		c = offset % 16
 		// Display the address in first column
 		if c == 0 {
-			ui.TblHexEdit.SetCell(r+1, c, tview.NewTableCell(fmt.Sprintf(" %08X", offset)).SetTextColor(tcell.ColorBlue))
+			ui.TblHexEdit.SetCell(r+1, c, tview.NewTableCell(fmt.Sprintf(" %08X", offset)).SetTextColor(theme.Keys))
 		}
 
 		// Display the Hex value
@@ -99,7 +159,7 @@ func OpenFile(fName string) {
 		// Display the ascii string
 		if c == 15 {
 			for i := 0; i < len(ascii); i++ {
-				ui.TblHexEdit.SetCell(r+1, c+2+i, tview.NewTableCell(string(ascii[i])).SetTextColor(tcell.ColorYellow))
+				ui.TblHexEdit.SetCell(r+1, c+2+i, tview.NewTableCell(string(ascii[i])).SetTextColor(theme.Highlight))
 			}
 			ascii = ""
 			r++
@@ -110,14 +170,11 @@ func OpenFile(fName string) {
 		}
 	}
 	offset--
-	ui.TxtHexName.SetText(fmt.Sprintf("[white]File [yellow]%s[white] (Size [yellow]%d[white] bytes, [yellow]%s[white])", fName, offset, utils.HumanFileSize(float64(offset))))
+	ui.TxtHexName.SetText(fmt.Sprintf("[-]File [yellow]%s[-] (Size [yellow]%d[-] bytes, [yellow]%s[-])", fName, offset, utils.HumanFileSize(float64(offset))))
 	ui.TblHexEdit.SetFixed(1, 0)
 	ui.TblHexEdit.Select(1, 0)
 	ui.TblHexEdit.ScrollToBeginning()
 	preview.DisplayExif(fName)
-	ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
-	ui.App.SetFocus(ui.TxtPrompt)
-	ui.SetStatus(fmt.Sprintf("File %s successfully open", fName))
 }
 
 // ****************************************************************************
@@ -149,32 +206,29 @@ func confirmOpen(rc dialog.DlgButton, idx int) {
 // ****************************************************************************
 func Close() {
 	// TODO : Save any modification on file
+	clearScreen()
+	current().fName = ""
+	ui.SetStatus("File closed")
+}
+
+// ****************************************************************************
+// clearScreen()
+// ****************************************************************************
+func clearScreen() {
 	ui.TblHexEdit.Clear()
 	ui.TxtHexName.Clear()
 	ui.TxtFileInfo.Clear()
-	CurrentHexFile = ""
-	ui.SetStatus("File closed")
 }
 
 // ****************************************************************************
 // SelfInit()
 // ****************************************************************************
 func SelfInit(a any) {
+	// The new screen is already shown : each one has its own file
 	if ui.CurrentMode == ui.ModeFiles {
-		if CurrentHexFile == "" {
-			idx, _ := ui.TblFiles.GetSelection()
-			fName := filepath.Join(conf.Cwd, strings.TrimSpace(ui.TblFiles.GetCell(idx, 2).Text))
-			ui.AddNewScreen(ui.ModeHexEdit, nil, nil)
-			OpenFile(fName)
-			ui.App.SetFocus(ui.TxtPrompt)
-		} else {
-			ui.AddNewScreen(ui.ModeHexEdit, nil, nil)
-			ui.App.SetFocus(ui.TxtPrompt)
-		}
-	} else {
-		ui.AddNewScreen(ui.ModeHexEdit, nil, nil)
-		ui.App.SetFocus(ui.TxtPrompt)
+		OpenFile(ui.SelectedFile())
 	}
+	ui.App.SetFocus(ui.TxtPrompt)
 }
 
 // ****************************************************************************
@@ -0,0 +1,118 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package keymap
+
+// ****************************************************************************
+// defaults holds the actions and their keys when ~/.gosh/keys.json does not
+// change them. The order of a scope is the order of its help and keys bar.
+// ****************************************************************************
+
+// ****************************************************************************
+// defaultBindings()
+// ****************************************************************************
+func defaultBindings() []Binding {
+	return []Binding{
+		// Every screen but the terminal
+		{"global.help", []string{"F1"}, "Help", "Open a new help screen"},
+		{"global.prompt", []string{"F2"}, "Prompt", "Go to the prompt"},
+		{"global.close", []string{"F3"}, "Close", "Close the current screen"},
+		{"global.stop", []string{"F4"}, "Stop Cmd", "Stop the running command (shell screen)"},
+		{"global.previous", []string{"F6"}, "Previous", "Show the previous screen"},
+		{"global.next", []string{"F7"}, "Next", "Show the next screen"},
+		{"global.menu", []string{"F10"}, "Main Menu", "Open the main menu"},
+		{"global.quit", []string{"F12"}, "Exit", "Quit gosh"},
+
+		// A program running in the terminal gets all the other keys
+		{"terminal.prompt", []string{"F2"}, "", "Go to the prompt"},
+		{"terminal.stop", []string{"F4"}, "", "Stop the program"},
+		{"terminal.previous", []string{"F6"}, "", "Show the previous screen"},
+		{"terminal.next", []string{"F7"}, "", "Show the next screen"},
+
+		{"prompt.run", []string{"Enter"}, "", "Run the command, the SQL request, or filter the audit"},
+		{"prompt.previous", []string{"Up"}, "", "Previous command of the history"},
+		{"prompt.next", []string{"Down"}, "", "Next command of the history"},
+		{"prompt.history", []string{"Ctrl+R"}, "", "Search the history (shell screen)"},
+		{"prompt.search", []string{"Ctrl+F"}, "", "Search the console (shell screen)"},
+		{"prompt.menu", []string{"F8"}, "", "Console menu (shell screen)"},
+		{"prompt.complete", []string{"Tab"}, "", "Complete the command, or go to the panels when the prompt is empty"},
+
+		{"panel.next", []string{"Tab"}, "", "Go to the next panel"},
+
+		{"console.search", []string{"Ctrl+F"}, "Find", "Search the console"},
+		{"console.menu", []string{"F8"}, "Context Menu", "Console menu"},
+		{"console.previous_block", []string{"Alt+Up"}, "Block ↑", "Select the previous command block"},
+		{"console.next_block", []string{"Alt+Down"}, "Block ↓", "Select the next command block"},
+		{"console.fold", []string{"Enter"}, "Fold/Unfold", "Fold or unfold the selected block"},
+		{"console.clear_search", []string{"Esc"}, "", "Clear the search"},
+		{"console.next_match", []string{"n"}, "", "Next match of the search"},
+		{"console.previous_match", []string{"N"}, "", "Previous match of the search"},
+		{"console.unfold_all", []string{"+"}, "Unfold All", "Unfold all the blocks"},
+		{"console.fold_all", []string{"-"}, "Fold All", "Fold all the blocks"},
+		{"console.copy", []string{"C", "c"}, "Copy", "Copy the selected block to the clipboard"},
+		{"console.save", []string{"S", "s"}, "Save", "Save the selected block to a file"},
+
+		{"files.open", []string{"Enter"}, "", "Open the folder, or the file in its application"},
+		{"files.refresh", []string{"F5"}, "Refresh", "Refresh the list"},
+		{"files.menu", []string{"F8"}, "Context Menu", "Files menu"},
+		{"files.delete", []string{"Delete"}, "Trash", "Move the selected files to the trash"},
+		{"files.delete_forever", []string{"Shift+Delete"}, "", "Delete the selected files for good, without the trash"},
+		{"files.select", []string{"Insert"}, "Select", "Select or unselect the file"},
+		{"files.select_all", []string{"Ctrl+A"}, "Select/Unselect All", "Select or unselect all the files"},
+		{"files.copy", []string{"Ctrl+C"}, "Copy", "Copy the selected files"},
+		{"files.cut", []string{"Ctrl+X"}, "Cut", "Cut the selected files"},
+		{"files.paste", []string{"Ctrl+V"}, "Paste", "Paste the files copied or cut"},
+		{"files.sort", []string{"Ctrl+S"}, "Sort", "Sort the files"},
+		{"files.dual", []string{"Alt+d"}, "", "Show one pane or two side by side"},
+		{"files.trash", []string{"Alt+t"}, "", "Open the trash"},
+		{"files.undo", []string{"Ctrl+Z"}, "Undo", "Undo the last operation"},
+
+		// The dual-pane File Manager gets them before the global keys
+		{"panes.copy", []string{"F5"}, "Copy", "Copy the selection, or the file, to the other pane"},
+		{"panes.move", []string{"F6"}, "Move", "Move the selection, or the file, to the other pane"},
+		{"panes.switch", []string{"Tab"}, "Other Pane", "Go to the other pane"},
+		{"panes.refresh", []string{"Ctrl+R"}, "Refresh", "Refresh both panes"},
+
+		// The trash is shown over the File Manager
+		{"trash.restore", []string{"Enter"}, "Restore", "Restore the item where it was deleted from"},
+		{"trash.purge", []string{"Delete"}, "Purge", "Delete the item for good"},
+		{"trash.empty", []string{"Ctrl+E"}, "Empty", "Delete all the items for good"},
+		{"trash.close", []string{"Esc"}, "Close", "Close the trash"},
+
+		{"process.details", []string{"Enter"}, "", "Show the details of the process"},
+		{"process.refresh", []string{"F5"}, "Refresh", "Refresh the list"},
+		{"process.menu", []string{"F8"}, "Context Menu", "Process menu"},
+		{"process.find", []string{"Ctrl+F"}, "Find", "Find a process"},
+		{"process.sort", []string{"Ctrl+S"}, "Sort", "Sort the processes"},
+		{"process.view", []string{"Ctrl+V"}, "Switch View", "Switch between the list and the tree"},
+
+		{"users.filter", []string{"Enter"}, "", "Show the processes of the user"},
+
+		{"editor.save", []string{"Ctrl+S"}, "Save", "Save the file"},
+		{"editor.save_as", []string{"Alt+s"}, "Save as…", "Save the file under another name"},
+		{"editor.new", []string{"Ctrl+N"}, "New", "New file"},
+		{"editor.close", []string{"Ctrl+T"}, "Close", "Close the file"},
+		{"editor.files", []string{"Esc"}, "", "Go to the list of the open files"},
+		{"editor.open_file", []string{"Enter"}, "", "Edit the file selected in the list"},
+
+		{"sqlite3.open", []string{"Ctrl+O"}, "Open", "Open a database"},
+		{"sqlite3.close", []string{"Ctrl+S"}, "Close", "Close the database"},
+		{"sqlite3.menu", []string{"F8"}, "Context Menu", "SQLite3 menu"},
+
+		{"hexedit.open", []string{"Ctrl+O"}, "Open", "Open a file"},
+		{"hexedit.close", []string{"Ctrl+S"}, "Close", "Close the file"},
+
+		{"audit.refresh", []string{"F5"}, "Reload", "Reload the audit log"},
+
+		{"settings.save", []string{"Ctrl+S"}, "Save", "Save the settings"},
+		{"settings.defaults", []string{"Ctrl+D"}, "Defaults", "Fill the form with the default settings"},
+		{"settings.reload", []string{"Ctrl+R"}, "Reload", "Reload the settings from gosh.json"},
+	}
+}
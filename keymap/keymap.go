@@ -0,0 +1,415 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package keymap
+
+// ****************************************************************************
+// keymap binds the keys to named actions, like files.sort. The part before
+// the dot is the scope : the panel or the screen where the key is read, the
+// global keys being read first on every screen but the terminal and the
+// dual-pane File Manager.
+// ~/.gosh/keys.json gives other keys to the actions, separated by spaces.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Binding struct {
+	Action      string // scope.name
+	Keys        []string
+	Label       string // Shown in the keys bar, empty to leave it out
+	Description string // Shown in the help
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	SCOPE_GLOBAL   = "global"
+	SCOPE_TERMINAL = "terminal"
+	SCOPE_PROMPT   = "prompt"
+	SCOPE_PANEL    = "panel"
+	SCOPE_CONSOLE  = "console"
+	SCOPE_FILES    = "files"
+	SCOPE_PANES    = "panes"
+	SCOPE_TRASH    = "trash"
+	SCOPE_PROCESS  = "process"
+	SCOPE_USERS    = "users"
+	SCOPE_EDITOR   = "editor"
+	SCOPE_SQLITE3  = "sqlite3"
+	SCOPE_HEXEDIT  = "hexedit"
+	SCOPE_AUDIT    = "audit"
+	SCOPE_SETTINGS = "settings"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	bindings = defaultBindings()
+	index    map[string]map[string]string // Scope -> key -> action
+	problems []error
+	// Names of the keys, lower case -> as written by KeyName
+	names = map[string]string{
+		"del":    "Delete",
+		"ins":    "Insert",
+		"escape": "Esc",
+		"return": "Enter",
+		"space":  "Space",
+	}
+	ctrlNames = make(map[string]string)
+)
+
+// ****************************************************************************
+// init()
+// ****************************************************************************
+func init() {
+	for _, n := range tcell.KeyNames {
+		if after, ok := strings.CutPrefix(n, "Ctrl-"); ok {
+			ctrlNames[strings.ToLower(after)] = after
+		} else {
+			names[strings.ToLower(n)] = n
+		}
+	}
+	problems = build()
+}
+
+// ****************************************************************************
+// Load()
+// Load reads the keys of fName, written with the default ones when it is
+// missing, and returns the problems found, the conflicts included
+// ****************************************************************************
+func Load(fName string) []error {
+	bindings = defaultBindings()
+	var found []error
+	b, err := os.ReadFile(fName)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = Save(fName)
+		problems = append(errorList(err), build()...)
+		return problems
+	}
+	if err != nil {
+		problems = append([]error{err}, build()...)
+		return problems
+	}
+	var keys map[string]string
+	if err := json.Unmarshal(b, &keys); err != nil {
+		problems = append([]error{fmt.Errorf("%v, the default keys are used", err)}, build()...)
+		return problems
+	}
+	actions := make([]string, 0, len(keys))
+	for action := range keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		i := find(action)
+		if i < 0 {
+			found = append(found, fmt.Errorf("%s: unknown action, ignored", action))
+			continue
+		}
+		var parsed []string
+		for _, k := range strings.Fields(keys[action]) {
+			name, err := ParseKey(k)
+			if err != nil {
+				found = append(found, fmt.Errorf("%s: %v", action, err))
+				continue
+			}
+			parsed = append(parsed, name)
+		}
+		// Wrong keys only leave the default ones, none unbinds the action
+		if len(parsed) > 0 || strings.TrimSpace(keys[action]) == "" {
+			bindings[i].Keys = parsed
+		}
+	}
+	problems = append(found, build()...)
+	return problems
+}
+
+// ****************************************************************************
+// Save()
+// Save writes the keys of all the actions into fName
+// ****************************************************************************
+func Save(fName string) error {
+	keys := make(map[string]string, len(bindings))
+	for _, b := range bindings {
+		keys[b.Action] = strings.Join(b.Keys, " ")
+	}
+	b, err := json.MarshalIndent(keys, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fName, b, 0644)
+}
+
+// ****************************************************************************
+// Problems()
+// Problems returns the problems found by the last Load
+// ****************************************************************************
+func Problems() []error {
+	return problems
+}
+
+// ****************************************************************************
+// Match()
+// Match returns the action of scope bound to the key of event, or ""
+// ****************************************************************************
+func Match(scope string, event *tcell.EventKey) string {
+	return index[scope][KeyName(event)]
+}
+
+// ****************************************************************************
+// Bindings()
+// Bindings returns the bindings of scope, in the order of the help
+// ****************************************************************************
+func Bindings(scope string) []Binding {
+	var list []Binding
+	for _, b := range bindings {
+		if scopeOf(b.Action) == scope {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// ****************************************************************************
+// Labels()
+// Labels returns the text of the keys bar for the scopes, like F1=Help
+// ****************************************************************************
+func Labels(scopes ...string) string {
+	var labels []string
+	shown := make(map[string]bool) // The first scope read gets the key
+	for _, scope := range scopes {
+		for _, b := range Bindings(scope) {
+			if keys := active(b); b.Label != "" && len(keys) > 0 && !shown[keys[0]] {
+				labels = append(labels, keys[0]+"="+b.Label)
+				shown[keys[0]] = true
+			}
+		}
+	}
+	return strings.Join(labels, " ")
+}
+
+// ****************************************************************************
+// Help()
+// Help returns the lines of the help for the keys of scope
+// ****************************************************************************
+func Help(scope string) string {
+	var text strings.Builder
+	for _, b := range Bindings(scope) {
+		keys := strings.Join(active(b), " / ")
+		if keys == "" {
+			keys = "(none)"
+		}
+		fmt.Fprintf(&text, "\t[yellow]%-21s[-] : %s\n", tview.Escape(keys), b.Description)
+	}
+	return text.String()
+}
+
+// ****************************************************************************
+// KeyName()
+// KeyName returns the name of the key of event, like Ctrl+S, Alt+s or F5
+// ****************************************************************************
+func KeyName(event *tcell.EventKey) string {
+	mods := event.Modifiers()
+	var base string
+	switch {
+	case event.Key() == tcell.KeyRune && event.Rune() == ' ':
+		base = "Space"
+		mods &^= tcell.ModShift
+	case event.Key() == tcell.KeyRune:
+		// The case of the letter tells the shift
+		base = string(event.Rune())
+		mods &^= tcell.ModShift
+	default:
+		n, ok := tcell.KeyNames[event.Key()]
+		if !ok {
+			return ""
+		}
+		if after, ok := strings.CutPrefix(n, "Ctrl-"); ok {
+			n = after
+			mods |= tcell.ModCtrl
+		}
+		base = n
+	}
+	return join(mods&tcell.ModCtrl != 0, mods&tcell.ModAlt != 0, mods&tcell.ModShift != 0, base)
+}
+
+// ****************************************************************************
+// ParseKey()
+// ParseKey returns the name of the key written s, as given by KeyName
+// ****************************************************************************
+func ParseKey(s string) (string, error) {
+	var ctrl, alt, shift bool
+	base := s
+	for {
+		// A + alone or at the end is the key
+		i := strings.Index(base, "+")
+		if i <= 0 || i == len(base)-1 {
+			break
+		}
+		switch strings.ToLower(base[:i]) {
+		case "ctrl":
+			ctrl = true
+		case "alt":
+			alt = true
+		case "shift":
+			shift = true
+		default:
+			return "", fmt.Errorf("%q: unknown modifier %q (Ctrl, Alt or Shift)", s, base[:i])
+		}
+		base = base[i+1:]
+	}
+	if utf8.RuneCountInString(base) == 1 {
+		r, _ := utf8.DecodeRuneInString(base)
+		switch {
+		case ctrl && unicode.IsLetter(r):
+			return join(true, alt, shift, strings.ToUpper(base)), nil
+		case ctrl || shift:
+			return "", fmt.Errorf("%q: Ctrl and Shift go with a letter or a named key", s)
+		}
+		return join(false, alt, false, base), nil
+	}
+	if n, ok := names[strings.ToLower(base)]; ok {
+		return join(ctrl, alt, shift, n), nil
+	}
+	if n, ok := ctrlNames[strings.ToLower(base)]; ok && ctrl {
+		return join(true, alt, shift, n), nil
+	}
+	return "", fmt.Errorf("%q: unknown key (like F5, Ctrl+S, Alt+s, Delete, Enter, Tab, Up or a character)", s)
+}
+
+// ****************************************************************************
+// join()
+// ****************************************************************************
+func join(ctrl bool, alt bool, shift bool, base string) string {
+	var parts []string
+	if ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if alt {
+		parts = append(parts, "Alt")
+	}
+	if shift {
+		parts = append(parts, "Shift")
+	}
+	return strings.Join(append(parts, base), "+")
+}
+
+// ****************************************************************************
+// build()
+// build indexes the keys by scope and returns the conflicts : a key bound
+// twice in a scope keeps its first action, and a global key hides the one
+// of another scope
+// ****************************************************************************
+func build() []error {
+	var conflicts []error
+	index = make(map[string]map[string]string)
+	for _, b := range bindings {
+		scope := scopeOf(b.Action)
+		if index[scope] == nil {
+			index[scope] = make(map[string]string)
+		}
+		for _, k := range b.Keys {
+			if other, ok := index[scope][k]; ok {
+				conflicts = append(conflicts, fmt.Errorf("%s: %s is already bound to %s, %s is ignored", scope, k, other, b.Action))
+				continue
+			}
+			index[scope][k] = b.Action
+		}
+	}
+	for _, b := range bindings {
+		scope := scopeOf(b.Action)
+		if readFirst(scope) {
+			continue
+		}
+		for _, k := range b.Keys {
+			if other, ok := index[SCOPE_GLOBAL][k]; ok && index[scope][k] == b.Action {
+				conflicts = append(conflicts, fmt.Errorf("%s: %s of %s is taken by %s first", scope, k, b.Action, other))
+			}
+		}
+	}
+	return conflicts
+}
+
+// ****************************************************************************
+// active()
+// active returns the keys of b which are not taken by another action
+// ****************************************************************************
+func active(b Binding) []string {
+	var keys []string
+	scope := scopeOf(b.Action)
+	for _, k := range b.Keys {
+		if index[scope][k] != b.Action {
+			continue
+		}
+		if _, ok := index[SCOPE_GLOBAL][k]; ok && !readFirst(scope) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ****************************************************************************
+// readFirst()
+// readFirst tells if the keys of scope are read before the global ones
+// ****************************************************************************
+func readFirst(scope string) bool {
+	return scope == SCOPE_GLOBAL || scope == SCOPE_TERMINAL || scope == SCOPE_PANES
+}
+
+// ****************************************************************************
+// find()
+// ****************************************************************************
+func find(action string) int {
+	for i, b := range bindings {
+		if b.Action == action {
+			return i
+		}
+	}
+	return -1
+}
+
+// ****************************************************************************
+// scopeOf()
+// ****************************************************************************
+func scopeOf(action string) string {
+	scope, _, _ := strings.Cut(action, ".")
+	return scope
+}
+
+// ****************************************************************************
+// errorList()
+// ****************************************************************************
+func errorList(err error) []error {
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
@@ -0,0 +1,221 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package logger
+
+// ****************************************************************************
+// logger writes the debug lines of gosh.log, the ones below the level set by
+// "log.level" in gosh.json being dropped. When the file reaches its maximum
+// size, it is renamed gosh.log.1, the older ones gosh.log.2 and so on.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Level int
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	LEVEL_DEBUG Level = iota
+	LEVEL_INFO
+	LEVEL_WARNING
+	LEVEL_ERROR
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	levelNames = []string{"debug", "info", "warning", "error"}
+	logFile    *os.File
+	fileName   string
+	session    string
+	level      = LEVEL_INFO
+	maxSize    int64 // Bytes, 0 for no rotation
+	maxFiles   = 1
+	size       int64
+	mutex      sync.Mutex
+)
+
+// ****************************************************************************
+// String() Level
+// ****************************************************************************
+func (l Level) String() string {
+	if l < LEVEL_DEBUG || l > LEVEL_ERROR {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ****************************************************************************
+// ParseLevel()
+// ParseLevel returns the level named name, "warn" being accepted too
+// ****************************************************************************
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warn" {
+		return LEVEL_WARNING, nil
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LEVEL_INFO, fmt.Errorf("unknown log level %q (debug, info, warning or error)", name)
+}
+
+// ****************************************************************************
+// Open()
+// Open appends the next lines to fName, prefixed with the session id
+// ****************************************************************************
+func Open(fName string, sessionID string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	f, err := os.OpenFile(fName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	logFile = f
+	fileName = fName
+	session = sessionID
+	size = info.Size()
+	return nil
+}
+
+// ****************************************************************************
+// Configure()
+// Configure sets the lowest level written, the size in KB from which the
+// file is rotated (0 never) and the number of old files kept
+// ****************************************************************************
+func Configure(lvl Level, maxKB int, files int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	level = lvl
+	maxSize = int64(maxKB) * 1024
+	if files < 1 {
+		files = 1
+	}
+	maxFiles = files
+}
+
+// ****************************************************************************
+// Close()
+// ****************************************************************************
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+}
+
+// ****************************************************************************
+// Debug()
+// ****************************************************************************
+func Debug(format string, args ...any) {
+	write(LEVEL_DEBUG, format, args...)
+}
+
+// ****************************************************************************
+// Info()
+// ****************************************************************************
+func Info(format string, args ...any) {
+	write(LEVEL_INFO, format, args...)
+}
+
+// ****************************************************************************
+// Warning()
+// ****************************************************************************
+func Warning(format string, args ...any) {
+	write(LEVEL_WARNING, format, args...)
+}
+
+// ****************************************************************************
+// Error()
+// ****************************************************************************
+func Error(format string, args ...any) {
+	write(LEVEL_ERROR, format, args...)
+}
+
+// ****************************************************************************
+// Writer()
+// Writer returns a writer logging each line written at the level lvl, for
+// the functions expecting an io.Writer for their errors
+// ****************************************************************************
+func Writer(lvl Level) io.Writer {
+	return levelWriter(lvl)
+}
+
+type levelWriter Level
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		write(Level(w), "%s", line)
+	}
+	return len(p), nil
+}
+
+// ****************************************************************************
+// write()
+// ****************************************************************************
+func write(lvl Level, format string, args ...any) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if logFile == nil || lvl < level {
+		return
+	}
+	line := fmt.Sprintf("%s [%s] %-7s %s\n", time.Now().Format("20060102-150405"), session, strings.ToUpper(lvl.String()), strings.TrimRight(fmt.Sprintf(format, args...), "\n"))
+	if maxSize > 0 && size > 0 && size+int64(len(line)) > maxSize {
+		rotate()
+	}
+	n, _ := logFile.WriteString(line)
+	size += int64(n)
+}
+
+// ****************************************************************************
+// rotate()
+// rotate shifts gosh.log.n to gosh.log.n+1, dropping the oldest, then starts
+// a new file, called with the mutex held
+// ****************************************************************************
+func rotate() {
+	logFile.Close()
+	os.Remove(fmt.Sprintf("%s.%d", fileName, maxFiles))
+	for i := maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", fileName, i), fmt.Sprintf("%s.%d", fileName, i+1))
+	}
+	os.Rename(fileName, fileName+".1")
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		logFile = nil
+		return
+	}
+	logFile = f
+	size = 0
+}
@@ -14,6 +14,7 @@ package menu
 // IMPORTS
 // ****************************************************************************
 import (
+	"gosh/theme"
 	"gosh/ui"
 	"strings"
 
@@ -41,8 +42,16 @@ type Menu struct {
 	focus  tview.Primitive
 	width  int
 	height int
+	colors int // Generation of the theme of the items
 }
 
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	MENU_MAX_HEIGHT = 22 // Longer menus scroll
+)
+
 // ****************************************************************************
 // New() MenuItem
 // ****************************************************************************
@@ -162,7 +171,10 @@ func (m *Menu) refresh() {
 	m.Table.SetBorder(true)
 	m.Table.SetTitle(m.title)
 	m.Table.SetSelectable(true, false)
-	m.Table.SetBackgroundColor(tcell.ColorBlue)
+	m.Table.SetBackgroundColor(theme.Menu)
+	m.Table.SetBorderColor(theme.Border)
+	m.Table.SetTitleColor(theme.Title)
+	m.colors = theme.Generation()
 	for i, item := range m.items {
 		prf := "  "
 		if item.Checked {
@@ -170,9 +182,9 @@ func (m *Menu) refresh() {
 		}
 		item.Label = prf + item.Label + "  "
 		if item.Enabled {
-			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(tcell.ColorYellow))
+			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(theme.Highlight))
 		} else {
-			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(tcell.ColorGray))
+			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(theme.Dimmed))
 		}
 		if len(item.Label) > m.width {
 			m.width = len(item.Label)
@@ -181,15 +193,37 @@ func (m *Menu) refresh() {
 	// Add some space around
 	m.width = m.width + 2
 	m.height = m.height + 2
+	if m.height > MENU_MAX_HEIGHT {
+		m.height = MENU_MAX_HEIGHT
+	}
 	// Adapt length separator (if any) to the menu width
 	for i, item := range m.items {
 		if item.Name == "SEPARATOR" {
 			item.Label = " " + strings.Repeat("─", m.width-4)
-			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(tcell.ColorGray))
+			m.Table.SetCell(i, 0, tview.NewTableCell(item.Label).SetTextColor(theme.Dimmed))
 		}
 	}
 }
 
+// ****************************************************************************
+// SetFocus() Menu
+// SetFocus sets the primitive focused again when the menu closes
+// ****************************************************************************
+func (m *Menu) SetFocus(focus tview.Primitive) {
+	m.focus = focus
+}
+
+// ****************************************************************************
+// Draw() Menu
+// Draw colors the menu again when the theme has changed since it was built
+// ****************************************************************************
+func (m *Menu) Draw(screen tcell.Screen) {
+	if m.colors != theme.Generation() {
+		m.refresh()
+	}
+	m.Table.Draw(screen)
+}
+
 // ****************************************************************************
 // Popup()
 // ****************************************************************************
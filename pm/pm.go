@@ -17,8 +17,12 @@ package pm
 import (
 	"bufio"
 	"fmt"
+	"gosh/audit"
 	"gosh/dialog"
+	"gosh/logger"
 	"gosh/menu"
+	"gosh/sudo"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 	"os/exec"
@@ -27,7 +31,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"golang.org/x/sys/unix"
 )
@@ -95,18 +98,11 @@ var Services []ServiceColumns
 var MnuProcess *menu.Menu
 var MnuProcessSort *menu.Menu
 var MnuService *menu.Menu
-var sortColumn = SORT_PID
-var sortOrder = SORT_ASCENDING
-var headerBackgroundColor = tcell.ColorDarkGreen
-var headerTextColor = tcell.ColorYellow
-var currentUser string
 var DlgRenice *dialog.Dialog
 var DlgSendSignal *dialog.Dialog
 var DlgKill *dialog.Dialog
 var DlgFind *dialog.Dialog
 var Signals []Signal
-var FindString string
-var CurrentView ViewType
 var DlgStartService *dialog.Dialog
 var DlgStopService *dialog.Dialog
 var DlgRestartService *dialog.Dialog
@@ -149,7 +145,8 @@ func SetProcessMenu() {
 // ShowMenu()
 // ****************************************************************************
 func ShowMenu() {
-	if CurrentView == VIEW_PROCESS {
+	scr := current()
+	if scr.view == VIEW_PROCESS {
 		ui.PgsApp.ShowPage("dlgProcessAction")
 	} else {
 		ui.PgsApp.ShowPage("dlgServiceAction")
@@ -160,7 +157,8 @@ func ShowMenu() {
 // ShowMenuSort()
 // ****************************************************************************
 func ShowMenuSort() {
-	if CurrentView == VIEW_PROCESS {
+	scr := current()
+	if scr.view == VIEW_PROCESS {
 		ui.PgsApp.ShowPage("dlgProcessSort")
 	} else {
 		ui.SetStatus("No sorting available for services")
@@ -171,28 +169,29 @@ func ShowMenuSort() {
 // ShowProcesses()
 // ****************************************************************************
 func ShowProcesses(user string) {
-	currentUser = user
+	scr := current()
+	scr.user = user
 	ui.TxtSelection.Clear()
-	ui.TxtProcess.SetText(fmt.Sprintf("Overall CPU usage is [yellow]%.2f%%[white]", utils.CpuUsage))
+	ui.TxtProcess.SetText(fmt.Sprintf("Overall CPU usage is [yellow]%.2f%%[-]", utils.CpuUsage))
 
 	Processes = readProcesses()
 	ui.TblProcess.Clear()
 	ui.TxtFileInfo.Clear()
 
 	// Column's Header
-	ui.TblProcess.SetCell(0, 0, tview.NewTableCell("PID").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 1, tview.NewTableCell("PRI").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 2, tview.NewTableCell("NI").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 3, tview.NewTableCell("S").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 4, tview.NewTableCell("%CPU").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 5, tview.NewTableCell("%MEM").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 6, tview.NewTableCell("VSZ").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 7, tview.NewTableCell("RSS").SetAlign(tview.AlignRight).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 8, tview.NewTableCell("TIME+").SetAlign(tview.AlignCenter).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 9, tview.NewTableCell("CMD").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
+	ui.TblProcess.SetCell(0, 0, tview.NewTableCell("PID").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 1, tview.NewTableCell("PRI").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 2, tview.NewTableCell("NI").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 3, tview.NewTableCell("S").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 4, tview.NewTableCell("%CPU").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 5, tview.NewTableCell("%MEM").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 6, tview.NewTableCell("VSZ").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 7, tview.NewTableCell("RSS").SetAlign(tview.AlignRight).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 8, tview.NewTableCell("TIME+").SetAlign(tview.AlignCenter).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 9, tview.NewTableCell("CMD").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
 
 	var sorted string
-	switch sortColumn {
+	switch scr.sortColumn {
 	case SORT_PCPU:
 		sorted = "%CPU"
 	case SORT_PID:
@@ -202,23 +201,23 @@ func ShowProcesses(user string) {
 	case SORT_TIME:
 		sorted = "Time"
 	}
-	switch sortOrder {
+	switch scr.sortOrder {
 	case SORT_ASCENDING:
 		sorted += " Ascending"
 	case SORT_DESCENDING:
 		sorted += " Descending"
 	}
-	if FindString != "" {
-		ui.TblProcess.SetTitle(fmt.Sprintf("[ %s, filtered on \"%s\", sorted by %s ]", user, FindString, sorted))
+	if scr.find != "" {
+		ui.TblProcess.SetTitle(fmt.Sprintf("[ %s, filtered on \"%s\", sorted by %s ]", user, scr.find, sorted))
 	} else {
 		ui.TblProcess.SetTitle(fmt.Sprintf("[ %s, sorted by %s ]", user, sorted))
 	}
 	// PID PRI NI S PCPU PMEM VSZ RSS TIME CMD
 	i := 0
 	for _, process := range Processes[user] {
-		if FindString != "" {
-			if strings.Contains(strings.ToUpper(process.command), strings.ToUpper(FindString)) {
-				ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(process.pid)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorYellow))
+		if scr.find != "" {
+			if strings.Contains(strings.ToUpper(process.command), strings.ToUpper(scr.find)) {
+				ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(process.pid)).SetAlign(tview.AlignRight).SetTextColor(theme.Highlight))
 				ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(process.priority)).SetAlign(tview.AlignRight))
 				ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(process.niceness)).SetAlign(tview.AlignRight))
 				ui.TblProcess.SetCell(i+1, 3, tview.NewTableCell(process.state))
@@ -231,7 +230,7 @@ func ShowProcesses(user string) {
 				i++
 			}
 		} else {
-			ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(process.pid)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorYellow))
+			ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(process.pid)).SetAlign(tview.AlignRight).SetTextColor(theme.Highlight))
 			ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(process.priority)).SetAlign(tview.AlignRight))
 			ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(process.niceness)).SetAlign(tview.AlignRight))
 			ui.TblProcess.SetCell(i+1, 3, tview.NewTableCell(process.state))
@@ -254,8 +253,9 @@ func ShowProcesses(user string) {
 // RefreshMe()
 // ****************************************************************************
 func RefreshMe() {
-	if CurrentView == VIEW_PROCESS {
-		ShowProcesses(currentUser)
+	scr := current()
+	if scr.view == VIEW_PROCESS {
+		ShowProcesses(scr.user)
 	} else {
 		ShowServices()
 	}
@@ -266,6 +266,7 @@ func RefreshMe() {
 // ShowUsers()
 // ****************************************************************************
 func ShowUsers() {
+	scr := current()
 	ui.TblProcUsers.Clear()
 	type uproc struct {
 		user string
@@ -284,12 +285,12 @@ func ShowUsers() {
 	}
 	sort.SliceStable(ups, func(i, j int) bool { return ups[i].proc > ups[j].proc })
 	for i, up := range ups {
-		if up.user == currentUser {
+		if up.user == scr.user {
 			ui.TblProcUsers.SetCell(i, 0, tview.NewTableCell(" ▶ "))
 		} else {
 			ui.TblProcUsers.SetCell(i, 0, tview.NewTableCell("   "))
 		}
-		ui.TblProcUsers.SetCell(i, 1, tview.NewTableCell(up.user).SetTextColor(tcell.ColorYellow))
+		ui.TblProcUsers.SetCell(i, 1, tview.NewTableCell(up.user).SetTextColor(theme.Highlight))
 		ui.TblProcUsers.SetCell(i, 2, tview.NewTableCell(strconv.Itoa(up.proc)+" process").SetAlign(tview.AlignRight))
 		ui.TblProcUsers.SetCell(i, 3, tview.NewTableCell(fmt.Sprintf("%8.2f%%", up.pcpu)).SetAlign(tview.AlignRight))
 	}
@@ -299,13 +300,14 @@ func ShowUsers() {
 // readProcesses()
 // ****************************************************************************
 func readProcesses() map[string][]ProcessColumns {
+	scr := current()
 	var sort = "+user,"
-	if sortOrder == SORT_ASCENDING {
+	if scr.sortOrder == SORT_ASCENDING {
 		sort += "+"
 	} else {
 		sort += "-"
 	}
-	switch sortColumn {
+	switch scr.sortColumn {
 	case SORT_PID:
 		sort += "pid"
 	case SORT_TIME:
@@ -320,7 +322,7 @@ func readProcesses() map[string][]ProcessColumns {
 	cmd := exec.Command("ps", "-eo", "pid,user,s,ppid,pri,ni,pcpu,lstart,time,times,rss,pmem,vsz,cmd", "--sort", sort, "--no-heading", "--date-format", "%Y%m%d-%H%M%S")
 	bOut, err := cmd.Output()
 	if err != nil {
-		fmt.Printf("%s", err.Error())
+		logger.Error("pm.go: %s", err.Error())
 	}
 	out := string(bOut)
 	scanner := bufio.NewScanner(strings.NewReader(out))
@@ -380,7 +382,9 @@ func DoRenice(p any) {
 func confirmRenice(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_OK {
 		cmd := exec.Command("renice", "-n", DlgRenice.Value, "-p", fmt.Sprintf("%d", idx))
-		if err := cmd.Run(); err != nil {
+		err := cmd.Run()
+		audit.Log(audit.ACTION_RENICE, fmt.Sprintf("%s to %s", processTarget(idx), DlgRenice.Value), err)
+		if err != nil {
 			ui.SetStatus(err.Error())
 		} else {
 			ui.SetStatus(fmt.Sprintf("PID %d reniced to value %s", idx, DlgRenice.Value))
@@ -443,9 +447,13 @@ func DoKill(p any) {
 // ****************************************************************************
 func confirmKill(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
-		if sendSignal(idx, "-SIGKILL") == 0 {
+		rc := sendSignal(idx, "-SIGKILL")
+		audit.Log(audit.ACTION_KILL, processTarget(idx), signalError(idx, rc))
+		if rc == 0 {
 			ui.SetStatus(fmt.Sprintf("Killing process %d", idx))
 			showProcessDetails(idx)
+		} else if denied(idx) {
+			signalElevated(audit.ACTION_KILL, idx, "-SIGKILL", fmt.Sprintf("Killing process %d", idx))
 		} else {
 			ui.SetStatus(fmt.Sprintf("Unable to kill process %d", idx))
 			showProcessDetails(idx)
@@ -482,9 +490,13 @@ func DoSendSignal(p any) {
 func confirmSendSignal(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_OK {
 		sig := "-" + strings.Split(DlgSendSignal.Value, ") ")[1]
-		if sendSignal(idx, sig) == 0 {
+		rc := sendSignal(idx, sig)
+		audit.Log(audit.ACTION_SIGNAL, sig+" "+processTarget(idx), signalError(idx, rc))
+		if rc == 0 {
 			ui.SetStatus(fmt.Sprintf("Signal %s sended to PID %d", sig, idx))
 			showProcessDetails(idx)
+		} else if denied(idx) {
+			signalElevated(audit.ACTION_SIGNAL, idx, sig, fmt.Sprintf("Signal %s sended to PID %d", sig, idx))
 		} else {
 			ui.SetStatus(fmt.Sprintf("Unable to send signal %s to PID %d", sig, idx))
 			showProcessDetails(idx)
@@ -496,8 +508,9 @@ func confirmSendSignal(rc dialog.DlgButton, idx int) {
 // ProceedProcessAction()
 // ****************************************************************************
 func ProceedProcessAction() {
+	scr := current()
 	idx, _ := ui.TblProcess.GetSelection()
-	if CurrentView == VIEW_PROCESS {
+	if scr.view == VIEW_PROCESS {
 		if idx > 0 {
 			targetPID, _ := strconv.Atoi(ui.TblProcess.GetCell(idx, 0).Text)
 			showProcessDetails(targetPID)
@@ -550,6 +563,64 @@ func sendSignal(pid int, signal string) int {
 	return rc
 }
 
+// ****************************************************************************
+// signalError()
+// signalError returns the error of kill for the audit log
+// ****************************************************************************
+func signalError(pid int, rc int) error {
+	switch {
+	case rc == 0:
+		return nil
+	case denied(pid):
+		return syscall.EPERM
+	default:
+		return fmt.Errorf("kill returned %d", rc)
+	}
+}
+
+// ****************************************************************************
+// processTarget()
+// processTarget describes a process for the audit log
+// ****************************************************************************
+func processTarget(pid int) string {
+	if p := getProcessInfo(pid); p.command != "" {
+		return fmt.Sprintf("PID %d (%s)", pid, p.command)
+	}
+	return fmt.Sprintf("PID %d", pid)
+}
+
+// ****************************************************************************
+// denied()
+// denied tells if the process exists but belongs to another user
+// ****************************************************************************
+func denied(pid int) bool {
+	return syscall.Kill(pid, 0) == syscall.EPERM
+}
+
+// ****************************************************************************
+// signalElevated()
+// signalElevated sends the signal again with sudo
+// ****************************************************************************
+func signalElevated(action audit.Action, pid int, signal string, msg string) {
+	target := processTarget(pid)
+	if action == audit.ACTION_SIGNAL {
+		target = signal + " " + target
+	}
+	sudo.Elevate(fmt.Sprintf("send %s to process %d", signal, pid),
+		func() error {
+			return sudo.Run("kill", signal, strconv.Itoa(pid))
+		},
+		func(err error) {
+			audit.LogElevated(action, target, err)
+			if err != nil {
+				ui.SetStatus(fmt.Sprintf("Unable to send signal %s to PID %d : %v", signal, pid, err))
+			} else {
+				ui.SetStatus(msg)
+			}
+			showProcessDetails(pid)
+		})
+}
+
 // ****************************************************************************
 // getProcessInfo()
 // ****************************************************************************
@@ -638,146 +709,67 @@ func showServiceDetails(service string) {
 // DoSortCPUA(p any)
 // ****************************************************************************
 func DoSortCPUA(p any) {
-	sortColumn = SORT_PCPU
-	sortOrder = SORT_ASCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", false)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PCPU, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // DoSortCPUD(p any)
 // ****************************************************************************
 func DoSortCPUD(p any) {
-	sortColumn = SORT_PCPU
-	sortOrder = SORT_DESCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", false)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PCPU, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // DoSortMEMA(p any)
 // ****************************************************************************
 func DoSortMEMA(p any) {
-	sortColumn = SORT_PMEM
-	sortOrder = SORT_ASCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", false)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PMEM, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // DoSortMEMD(p any)
 // ****************************************************************************
 func DoSortMEMD(p any) {
-	sortColumn = SORT_PMEM
-	sortOrder = SORT_DESCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", false)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PMEM, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // DoSortPIDA(p any)
 // ****************************************************************************
 func DoSortPIDA(p any) {
-	sortColumn = SORT_PID
-	sortOrder = SORT_ASCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", false)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PID, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // DoSortPIDD(p any)
 // ****************************************************************************
 func DoSortPIDD(p any) {
-	sortColumn = SORT_PID
-	sortOrder = SORT_DESCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", false)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_PID, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // DoSortTimeA(p any)
 // ****************************************************************************
 func DoSortTimeA(p any) {
-	sortColumn = SORT_TIME
-	sortOrder = SORT_ASCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", false)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", true)
-	ShowProcesses(currentUser)
+	setSort(SORT_TIME, SORT_ASCENDING)
 }
 
 // ****************************************************************************
 // DoSortTimeD(p any)
 // ****************************************************************************
 func DoSortTimeD(p any) {
-	sortColumn = SORT_TIME
-	sortOrder = SORT_DESCENDING
-	MnuProcessSort.SetEnabled("mnuSortPCPUA", true)
-	MnuProcessSort.SetEnabled("mnuSortPCPUD", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMA", true)
-	MnuProcessSort.SetEnabled("mnuSortPMEMD", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDA", true)
-	MnuProcessSort.SetEnabled("mnuSortPIDD", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeA", true)
-	MnuProcessSort.SetEnabled("mnuSortTimeD", false)
-	ShowProcesses(currentUser)
+	setSort(SORT_TIME, SORT_DESCENDING)
 }
 
 // ****************************************************************************
 // DoFindProcess(p any)
 // ****************************************************************************
 func DoFindProcess(p any) {
-	if CurrentView == VIEW_PROCESS {
+	scr := current()
+	if scr.view == VIEW_PROCESS {
 		DlgFind = DlgFind.Input("Find Process", // Title
 			"Please, enter a part of the name to find", // Message
-			FindString,
+			scr.find,
 			confirmFind,
 			0,
 			ui.GetCurrentScreen(), ui.TblProcess) // Focus return
@@ -786,7 +778,7 @@ func DoFindProcess(p any) {
 	} else {
 		DlgFind = DlgFind.Input("Find Service", // Title
 			"Please, enter a part of the name to find", // Message
-			FindString,
+			scr.find,
 			confirmFind,
 			0,
 			ui.GetCurrentScreen(), ui.TblProcess) // Focus return
@@ -799,14 +791,15 @@ func DoFindProcess(p any) {
 // confirmFind()
 // ****************************************************************************
 func confirmFind(rc dialog.DlgButton, idx int) {
-	if CurrentView == VIEW_PROCESS {
+	scr := current()
+	if scr.view == VIEW_PROCESS {
 		if rc == dialog.BUTTON_OK {
-			FindString = DlgFind.Value
-			ShowProcesses(currentUser)
+			scr.find = DlgFind.Value
+			ShowProcesses(scr.user)
 		}
 	} else {
 		if rc == dialog.BUTTON_OK {
-			FindString = DlgFind.Value
+			scr.find = DlgFind.Value
 			ShowServices()
 		}
 	}
@@ -816,14 +809,15 @@ func confirmFind(rc dialog.DlgButton, idx int) {
 // SwitchView()
 // ****************************************************************************
 func SwitchView() {
-	if CurrentView == VIEW_PROCESS {
-		CurrentView = VIEW_SERVICES
+	scr := current()
+	if scr.view == VIEW_PROCESS {
+		scr.view = VIEW_SERVICES
 		ui.SetStatus("Switching view to services")
 		ShowServices()
 	} else {
-		CurrentView = VIEW_PROCESS
+		scr.view = VIEW_PROCESS
 		ui.SetStatus("Switching view to process")
-		ShowProcesses(currentUser)
+		ShowProcesses(scr.user)
 	}
 }
 
@@ -831,39 +825,40 @@ func SwitchView() {
 // ShowServices()
 // ****************************************************************************
 func ShowServices() {
+	scr := current()
 	ui.TxtSelection.Clear()
-	ui.TxtProcess.SetText(fmt.Sprintf("Overall CPU usage is [yellow]%.2f%%[white]", utils.CpuUsage))
+	ui.TxtProcess.SetText(fmt.Sprintf("Overall CPU usage is [yellow]%.2f%%[-]", utils.CpuUsage))
 
 	Services = readServices()
 	ui.TblProcess.Clear()
 	ui.TxtFileInfo.Clear()
 
-	if FindString != "" {
-		ui.TblProcess.SetTitle(fmt.Sprintf("[ Services, filtered on \"%s\" ]", FindString))
+	if scr.find != "" {
+		ui.TblProcess.SetTitle(fmt.Sprintf("[ Services, filtered on \"%s\" ]", scr.find))
 	} else {
 		ui.TblProcess.SetTitle("[ Services ]")
 	}
 
 	// Column's Header
-	ui.TblProcess.SetCell(0, 0, tview.NewTableCell("UNIT").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 1, tview.NewTableCell("LOAD").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 2, tview.NewTableCell("ACTIVE").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 3, tview.NewTableCell("SUB").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
-	ui.TblProcess.SetCell(0, 4, tview.NewTableCell("DESCRIPTION").SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
+	ui.TblProcess.SetCell(0, 0, tview.NewTableCell("UNIT").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 1, tview.NewTableCell("LOAD").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 2, tview.NewTableCell("ACTIVE").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 3, tview.NewTableCell("SUB").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
+	ui.TblProcess.SetCell(0, 4, tview.NewTableCell("DESCRIPTION").SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
 
 	// UNIT LOAD ACTIVE SUB DESCRIPTION
 	i := 0
 	for _, service := range Services {
-		if FindString != "" {
-			if strings.Contains(strings.ToUpper(service.unit), strings.ToUpper(FindString)) {
-				ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(service.unit).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorYellow))
+		if scr.find != "" {
+			if strings.Contains(strings.ToUpper(service.unit), strings.ToUpper(scr.find)) {
+				ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(service.unit).SetAlign(tview.AlignLeft).SetTextColor(theme.Highlight))
 				if service.load == "not-found" {
-					ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorRed))
+					ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft).SetTextColor(theme.Error))
 				} else {
 					ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft))
 				}
 				if service.active == "active" {
-					ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorGreen))
+					ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft).SetTextColor(theme.Success))
 				} else {
 					ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft))
 				}
@@ -872,14 +867,14 @@ func ShowServices() {
 				i++
 			}
 		} else {
-			ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(service.unit).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorYellow))
+			ui.TblProcess.SetCell(i+1, 0, tview.NewTableCell(service.unit).SetAlign(tview.AlignLeft).SetTextColor(theme.Highlight))
 			if service.load == "not-found" {
-				ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorRed))
+				ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft).SetTextColor(theme.Error))
 			} else {
 				ui.TblProcess.SetCell(i+1, 1, tview.NewTableCell(service.load).SetAlign(tview.AlignLeft))
 			}
 			if service.active == "active" {
-				ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft).SetTextColor(tcell.ColorGreen))
+				ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft).SetTextColor(theme.Success))
 			} else {
 				ui.TblProcess.SetCell(i+1, 2, tview.NewTableCell(service.active).SetAlign(tview.AlignLeft))
 			}
@@ -902,7 +897,7 @@ func readServices() []ServiceColumns {
 	cmd := exec.Command("systemctl", "list-units", "--type=service", "--all")
 	bOut, err := cmd.Output()
 	if err != nil {
-		fmt.Printf("%s", err.Error())
+		logger.Error("pm.go: %s", err.Error())
 	}
 	out := string(bOut)
 	scanner := bufio.NewScanner(strings.NewReader(out))
@@ -999,14 +994,7 @@ func DoStartService(p any) {
 func confirmStartService(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
 		service := ui.TblProcess.GetCell(idx, 0).Text
-		cmd := exec.Command("sudo", "systemctl", "start", service)
-		if err := cmd.Run(); err == nil {
-			ui.SetStatus(fmt.Sprintf("Service %s started", service))
-			showServiceDetails(service)
-		} else {
-			ui.SetStatus(err.Error())
-			showServiceDetails(service)
-		}
+		serviceAction("start", service, fmt.Sprintf("Service %s started", service))
 	}
 }
 
@@ -1033,14 +1021,7 @@ func DoStopService(p any) {
 func confirmStopService(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
 		service := ui.TblProcess.GetCell(idx, 0).Text
-		cmd := exec.Command("sudo", "systemctl", "stop", service)
-		if err := cmd.Run(); err == nil {
-			ui.SetStatus(fmt.Sprintf("Service %s stopped", service))
-			showServiceDetails(service)
-		} else {
-			ui.SetStatus(err.Error())
-			showServiceDetails(service)
-		}
+		serviceAction("stop", service, fmt.Sprintf("Service %s stopped", service))
 	}
 }
 
@@ -1067,14 +1048,7 @@ func DoRestartService(p any) {
 func confirmRestartService(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
 		service := ui.TblProcess.GetCell(idx, 0).Text
-		cmd := exec.Command("sudo", "systemctl", "restart", service)
-		if err := cmd.Run(); err == nil {
-			ui.SetStatus(fmt.Sprintf("Service %s restarted", service))
-			showServiceDetails(service)
-		} else {
-			ui.SetStatus(err.Error())
-			showServiceDetails(service)
-		}
+		serviceAction("restart", service, fmt.Sprintf("Service %s restarted", service))
 	}
 }
 
@@ -1101,14 +1075,7 @@ func DoEnableService(p any) {
 func confirmEnableService(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
 		service := ui.TblProcess.GetCell(idx, 0).Text
-		cmd := exec.Command("sudo", "systemctl", "enable", service)
-		if err := cmd.Run(); err == nil {
-			ui.SetStatus(fmt.Sprintf("Service %s enabled", service))
-			showServiceDetails(service)
-		} else {
-			ui.SetStatus(err.Error())
-			showServiceDetails(service)
-		}
+		serviceAction("enable", service, fmt.Sprintf("Service %s enabled", service))
 	}
 }
 
@@ -1135,17 +1102,38 @@ func DoDisableService(p any) {
 func confirmDisableService(rc dialog.DlgButton, idx int) {
 	if rc == dialog.BUTTON_YES {
 		service := ui.TblProcess.GetCell(idx, 0).Text
-		cmd := exec.Command("sudo", "systemctl", "disable", service)
-		if err := cmd.Run(); err == nil {
-			ui.SetStatus(fmt.Sprintf("Service %s disabled", service))
-			showServiceDetails(service)
-		} else {
-			ui.SetStatus(err.Error())
-			showServiceDetails(service)
-		}
+		serviceAction("disable", service, fmt.Sprintf("Service %s disabled", service))
 	}
 }
 
+// ****************************************************************************
+// serviceAction()
+// serviceAction runs systemctl action, again with sudo when it is refused
+// ****************************************************************************
+func serviceAction(action string, service string, msg string) {
+	cmd := exec.Command("systemctl", "--no-ask-password", action, service)
+	err := cmd.Run()
+	audit.Log(audit.Service(action), service, err)
+	if err == nil {
+		ui.SetStatus(msg)
+		showServiceDetails(service)
+		return
+	}
+	sudo.Elevate(fmt.Sprintf("%s service %s", action, service),
+		func() error {
+			return sudo.Run("systemctl", action, service)
+		},
+		func(err error) {
+			audit.LogElevated(audit.Service(action), service, err)
+			if err != nil {
+				ui.SetStatus(err.Error())
+			} else {
+				ui.SetStatus(msg)
+			}
+			showServiceDetails(service)
+		})
+}
+
 // ****************************************************************************
 // SelfInit()
 // ****************************************************************************
@@ -0,0 +1,130 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package pm
+
+// ****************************************************************************
+// screen keeps the state of each Process screen : the user, the view, the
+// sort and the filter. All the screens share the tables of ui, filled again
+// when a screen is shown.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/ui"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type screen struct {
+	user       string
+	view       ViewType
+	sortColumn SortColumn
+	sortOrder  int
+	find       string // Filter on the command or the unit
+	row        int    // Cursor while the screen is hidden
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	screens = make(map[string]*screen) // By screen, see ui.GetCurrentScreen
+	cur     *screen
+	shown   *screen // Screen whose cursor is in ui.TblProcess, nil when hidden
+)
+
+// ****************************************************************************
+// current()
+// current returns the state of the Process screen shown
+// ****************************************************************************
+func current() *screen {
+	if cur == nil {
+		cur = &screen{view: VIEW_PROCESS, sortColumn: SORT_PID, sortOrder: SORT_ASCENDING}
+	}
+	return cur
+}
+
+// ****************************************************************************
+// ShowScreen()
+// ShowScreen fills the tables with the state of the Process screen shown,
+// forgetting the screens which are closed
+// ****************************************************************************
+func ShowScreen() {
+	// The cursor of the screen left, whatever the mode of the next one
+	if shown != nil {
+		shown.row, _ = ui.TblProcess.GetSelection()
+		shown = nil
+	}
+	for id := range screens {
+		if !ui.IsScreenOpen(id) {
+			delete(screens, id)
+		}
+	}
+	if ui.CurrentMode != ui.ModeProcess {
+		return
+	}
+	id := ui.GetCurrentScreen()
+	s, ok := screens[id]
+	if !ok {
+		// SelfInit fills the new screen
+		cur = nil
+		screens[id] = current()
+		shown = cur
+		return
+	}
+	cur, shown = s, s
+	SetProcessMenu()
+	checkSort()
+	RefreshMe()
+	if s.row < ui.TblProcess.GetRowCount() {
+		ui.TblProcess.Select(s.row, 0)
+	}
+}
+
+// ****************************************************************************
+// setSort()
+// setSort sorts the processes of the screen shown
+// ****************************************************************************
+func setSort(column SortColumn, order int) {
+	scr := current()
+	scr.sortColumn = column
+	scr.sortOrder = order
+	checkSort()
+	ShowProcesses(scr.user)
+}
+
+// ****************************************************************************
+// checkSort()
+// checkSort disables the sort of the screen shown in the menu
+// ****************************************************************************
+func checkSort() {
+	scr := current()
+	items := []struct {
+		name   string
+		column SortColumn
+		order  int
+	}{
+		{"mnuSortPIDA", SORT_PID, SORT_ASCENDING},
+		{"mnuSortPIDD", SORT_PID, SORT_DESCENDING},
+		{"mnuSortTimeA", SORT_TIME, SORT_ASCENDING},
+		{"mnuSortTimeD", SORT_TIME, SORT_DESCENDING},
+		{"mnuSortPCPUA", SORT_PCPU, SORT_ASCENDING},
+		{"mnuSortPCPUD", SORT_PCPU, SORT_DESCENDING},
+		{"mnuSortPMEMA", SORT_PMEM, SORT_ASCENDING},
+		{"mnuSortPMEMD", SORT_PMEM, SORT_DESCENDING},
+	}
+	for _, item := range items {
+		MnuProcessSort.SetEnabled(item.name, item.column != scr.sortColumn || item.order != scr.sortOrder)
+	}
+}
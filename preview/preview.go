@@ -58,7 +58,11 @@ func DisplayFilePreview(fName string) (string, string) {
 	default:
 		if xmtype.String()[0:4] == "text" {
 			reader := bufio.NewReader(f)
-			characters := make([]byte, conf.FILE_MAX_PREVIEW)
+			max := ui.MyConfig.Files.MaxPreview
+			if max <= 0 {
+				max = conf.FILE_MAX_PREVIEW
+			}
+			characters := make([]byte, max)
 			_, err := reader.Read(characters)
 			if err != nil {
 				ui.SetStatus(err.Error())
@@ -0,0 +1,276 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package settings
+
+// ****************************************************************************
+// screen is the Settings screen, a form with one field per setting of
+// gosh.json, named after its key. The form shows one section at a time, the
+// values being kept while switching from one to another.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"gosh/theme"
+	"gosh/ui"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type field struct {
+	key   string // Like shell.history_size
+	index []int  // Of the field in ui.Config
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	fields   []field
+	edited   ui.Config // The values of the form, not saved yet
+	section  int       // Shown by the form, 0 for the top level settings
+	problems []error   // Found by the last Load
+	screens  = []ui.Mode{ui.ModeShell, ui.ModeFiles, ui.ModeProcess, ui.ModeTextEdit, ui.ModeSQLite3, ui.ModeHexEdit, ui.ModeHelp, ui.ModeAudit, ui.ModeSettings}
+	levels   = []string{"debug", "info", "warning", "error"}
+)
+
+// ****************************************************************************
+// SelfInit()
+// ****************************************************************************
+func SelfInit(a any) {
+	edited = ui.MyConfig
+	section = 0
+	showForm()
+	showProblems(problems, "Problems found at startup :")
+	ui.App.SetFocus(ui.FrmSettings)
+}
+
+// ****************************************************************************
+// Submit()
+// Submit checks the values of the form, then saves and applies them
+// ****************************************************************************
+func Submit() {
+	errs := readForm()
+	c := edited
+	errs = append(errs, Validate(&c)...)
+	if len(errs) > 0 {
+		showProblems(errs, "Not saved :")
+		ui.SetStatus(fmt.Sprintf("%d wrong settings, nothing saved", len(errs)))
+		return
+	}
+	if err := Save(c); err != nil {
+		ui.SetStatus(err.Error())
+		return
+	}
+	Apply(c)
+	problems = nil
+	showProblems(nil, "")
+	ui.SetStatus("Settings saved in " + FileName)
+}
+
+// ****************************************************************************
+// Defaults()
+// Defaults fills the form with the default settings, saved by Submit
+// ****************************************************************************
+func Defaults() {
+	edited = ui.DefaultConfig()
+	showForm()
+	ui.SetStatus("Default settings, Ctrl+S to save them")
+}
+
+// ****************************************************************************
+// Reload()
+// Reload fills the form with the current settings
+// ****************************************************************************
+func Reload() {
+	edited = ui.MyConfig
+	showForm()
+	showProblems(nil, "")
+	ui.SetStatus("Current settings")
+}
+
+// ****************************************************************************
+// showForm()
+// showForm shows the settings of the current section of edited
+// ****************************************************************************
+func showForm() {
+	ui.FrmSettings.Clear(true)
+	fields = nil
+	v := reflect.ValueOf(edited)
+	names := []string{"general"}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Struct {
+			names = append(names, jsonName(v.Type().Field(i)))
+		}
+	}
+	ui.FrmSettings.AddDropDown("section", names, section, func(option string, idx int) {
+		if idx < 0 || idx == section {
+			return
+		}
+		// The form can't be rebuilt while the drop down handles the key
+		go ui.App.QueueUpdateDraw(func() {
+			if errs := readForm(); len(errs) > 0 {
+				showProblems(errs, "Not kept :")
+			}
+			section = idx
+			showForm()
+		})
+	})
+	if section == 0 {
+		addFields(v, "", nil)
+	} else {
+		for i, n := 0, 0; i < v.NumField(); i++ {
+			if v.Field(i).Kind() != reflect.Struct {
+				continue
+			}
+			if n++; n == section {
+				addFields(v.Field(i), names[section]+".", []int{i})
+			}
+		}
+	}
+	ui.FrmSettings.AddButton("Save", Submit)
+	ui.FrmSettings.AddButton("Defaults", Defaults)
+	ui.FrmSettings.AddButton("Reload", Reload)
+	ui.FrmSettings.SetTitle(fmt.Sprintf("Settings (version %d)", conf.CONFIG_VERSION))
+	ui.FrmSettings.SetFocus(0)
+	if ui.CurrentMode == ui.ModeSettings {
+		ui.App.SetFocus(ui.FrmSettings)
+	}
+}
+
+// ****************************************************************************
+// addFields()
+// addFields adds a form item per setting of v, the sections being skipped
+// ****************************************************************************
+func addFields(v reflect.Value, prefix string, index []int) {
+	for i := 0; i < v.NumField(); i++ {
+		key := prefix + jsonName(v.Type().Field(i))
+		idx := append(append([]int{}, index...), i)
+		value := v.Field(i)
+		switch {
+		case key == "version" || value.Kind() == reflect.Struct:
+			continue
+		case value.Type() == reflect.TypeOf(ui.ModeShell):
+			options := make([]string, len(screens))
+			current := 0
+			for n, m := range screens {
+				options[n] = m.String()
+				if m == value.Interface().(ui.Mode) {
+					current = n
+				}
+			}
+			ui.FrmSettings.AddDropDown(key, options, current, nil)
+		case key == "log.level":
+			current := 1
+			for n, l := range levels {
+				if l == value.String() {
+					current = n
+				}
+			}
+			ui.FrmSettings.AddDropDown(key, levels, current, nil)
+		case key == "theme":
+			names := theme.Names()
+			current := 0
+			for n, name := range names {
+				if name == value.String() {
+					current = n
+				}
+			}
+			ui.FrmSettings.AddDropDown(key, names, current, nil)
+		case value.Kind() == reflect.Bool:
+			ui.FrmSettings.AddCheckbox(key, value.Bool(), nil)
+		default:
+			ui.FrmSettings.AddInputField(key, fmt.Sprint(value.Interface()), 0, nil, nil)
+		}
+		fields = append(fields, field{key: key, index: idx})
+	}
+}
+
+// ****************************************************************************
+// readForm()
+// readForm keeps the values of the form into edited, and returns the ones
+// which are not of the expected type
+// ****************************************************************************
+func readForm() []error {
+	v := reflect.ValueOf(&edited).Elem()
+	var errs []error
+	for i, f := range fields {
+		value := v.FieldByIndex(f.index)
+		// The first item is the section
+		switch item := ui.FrmSettings.GetFormItem(i + 1).(type) {
+		case *tview.Checkbox:
+			value.SetBool(item.IsChecked())
+		case *tview.DropDown:
+			n, option := item.GetCurrentOption()
+			if value.Kind() == reflect.String {
+				value.SetString(option)
+			} else if n >= 0 {
+				value.Set(reflect.ValueOf(screens[n]))
+			}
+		case *tview.InputField:
+			text := strings.TrimSpace(item.GetText())
+			if value.Kind() == reflect.String {
+				value.SetString(text)
+				continue
+			}
+			n, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a number", f.key, text))
+				continue
+			}
+			value.SetInt(n)
+		}
+	}
+	return errs
+}
+
+// ****************************************************************************
+// jsonName()
+// ****************************************************************************
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// ****************************************************************************
+// showProblems()
+// ****************************************************************************
+func showProblems(errs []error, title string) {
+	var text strings.Builder
+	fmt.Fprintf(&text, "[yellow]%s[-]\n\n", tview.Escape(FileName))
+	if len(errs) == 0 {
+		text.WriteString("The changes are checked, saved and applied at once by Save (Ctrl+S).\n" +
+			"The colors are names like lightgreen, or #RRGGBB, and replace the ones of the theme when set.")
+	} else {
+		text.WriteString("[red]" + title + "[-]\n")
+		for _, err := range errs {
+			text.WriteString("• " + tview.Escape(err.Error()) + "\n")
+		}
+	}
+	ui.TxtSettings.SetText(text.String())
+}
+
+// ****************************************************************************
+// Problems()
+// Problems returns the problems found by the last Load
+// ****************************************************************************
+func Problems() []error {
+	return problems
+}
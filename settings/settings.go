@@ -0,0 +1,371 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package settings
+
+// ****************************************************************************
+// settings reads gosh.json, migrating the files of an older version and
+// checking each value : a wrong one is reported and replaced by its default.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"gosh/logger"
+	"gosh/theme"
+	"gosh/ui"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	FileName string
+	// OnApply applies the settings of the other modules, set by main
+	OnApply func(c ui.Config)
+	// migrations[v] turns a file of version v into version v+1
+	migrations = map[int]func(raw map[string]any){
+		1: migrateV1,
+		2: migrateV2,
+	}
+)
+
+// ****************************************************************************
+// Load()
+// Load reads fName, returning its settings and the problems found, a new
+// file being written with the defaults when there is none
+// ****************************************************************************
+func Load(fName string) (ui.Config, []error) {
+	c, found := load(fName)
+	problems = found
+	return c, found
+}
+
+// ****************************************************************************
+// load()
+// ****************************************************************************
+func load(fName string) (ui.Config, []error) {
+	FileName = fName
+	c := ui.DefaultConfig()
+	b, err := os.ReadFile(fName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, errorList(Save(c))
+	}
+	if err != nil {
+		return c, []error{err}
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return c, []error{syntaxError(b, err)}
+	}
+
+	var problems []error
+	original := b
+	version := 1
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > conf.CONFIG_VERSION {
+		problems = append(problems, fmt.Errorf("version %d is newer than the one of this gosh (%d), some settings may be ignored", version, conf.CONFIG_VERSION))
+	}
+	from := version
+	migrated := version < conf.CONFIG_VERSION
+	for ; version < conf.CONFIG_VERSION; version++ {
+		migrations[version](raw)
+	}
+	if migrated {
+		raw["version"] = conf.CONFIG_VERSION
+		logger.Info("settings.go: %s migrated to version %d", fName, conf.CONFIG_VERSION)
+	}
+
+	problems = append(problems, unknownKeys(raw, reflect.TypeOf(c), "")...)
+	b, _ = json.Marshal(raw)
+	if err := json.Unmarshal(b, &c); err != nil {
+		problems = append(problems, typeError(err))
+		// The values after the wrong one are decoded all the same
+	}
+	problems = append(problems, Validate(&c)...)
+
+	if migrated {
+		// The old file is kept, for an older gosh
+		if err := os.WriteFile(fmt.Sprintf("%s.v%d", fName, from), original, 0644); err != nil {
+			problems = append(problems, err)
+		}
+		if err := Save(c); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return c, problems
+}
+
+// ****************************************************************************
+// Save()
+// Save writes the settings into gosh.json
+// ****************************************************************************
+func Save(c ui.Config) error {
+	c.Version = conf.CONFIG_VERSION
+	b, err := json.MarshalIndent(c, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(FileName, b, 0644)
+}
+
+// ****************************************************************************
+// Apply()
+// Apply makes c the current settings
+// ****************************************************************************
+func Apply(c ui.Config) {
+	ui.MyConfig = c
+	theme.SetOverrides(c.Colors.Roles())
+	if err := theme.Use(c.Theme); err == nil {
+		ui.ApplyTheme()
+	}
+	level, _ := logger.ParseLevel(c.Log.Level)
+	logger.Configure(level, c.Log.MaxSize, c.Log.MaxFiles)
+	if OnApply != nil {
+		OnApply(c)
+	}
+}
+
+// ****************************************************************************
+// UseTheme()
+// UseTheme switches to the theme name and saves it as the setting
+// ****************************************************************************
+func UseTheme(name string) error {
+	if !theme.Exists(name) {
+		return fmt.Errorf("unknown theme %q (%s)", name, strings.Join(theme.Names(), ", "))
+	}
+	c := ui.MyConfig
+	c.Theme = name
+	if err := Save(c); err != nil {
+		return err
+	}
+	Apply(c)
+	return nil
+}
+
+// ****************************************************************************
+// Validate()
+// Validate checks the settings, a wrong one being reset to its default
+// ****************************************************************************
+func Validate(c *ui.Config) []error {
+	var problems []error
+	d := ui.DefaultConfig()
+	check := func(ok bool, key string, value any, reset func(), rule string) {
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: %v is wrong, %s", key, value, rule))
+			reset()
+		}
+	}
+	check(c.StartupScreen.String() != "?", "startup_screen", int(c.StartupScreen), func() { c.StartupScreen = d.StartupScreen }, "a screen like ModeShell is expected")
+	check(c.FormatDate != "", "format_date", `""`, func() { c.FormatDate = d.FormatDate }, "a Go date layout like 02/01/2006 is expected")
+	check(c.FormatTime != "", "format_time", `""`, func() { c.FormatTime = d.FormatTime }, "a Go time layout like 15:04:05 is expected")
+	check(c.StatusDuration > 0, "status_duration", c.StatusDuration, func() { c.StatusDuration = d.StatusDuration }, "a number of seconds above 0 is expected")
+	check(c.ToastDuration > 0, "toast_duration", c.ToastDuration, func() { c.ToastDuration = d.ToastDuration }, "a number of seconds above 0 is expected")
+	check(c.SudoTimeout > 0, "sudo_timeout", c.SudoTimeout, func() { c.SudoTimeout = d.SudoTimeout }, "a number of minutes above 0 is expected")
+	check(theme.Exists(c.Theme), "theme", c.Theme, func() { c.Theme = d.Theme }, "one of "+strings.Join(theme.Names(), ", ")+" is expected")
+
+	check(c.Shell.Prompt != "", "shell.prompt", `""`, func() { c.Shell.Prompt = d.Shell.Prompt }, "a template like "+conf.PROMPT_TEMPLATE+" is expected")
+	check(c.Shell.HistorySize > 0, "shell.history_size", c.Shell.HistorySize, func() { c.Shell.HistorySize = d.Shell.HistorySize }, "a number of commands above 0 is expected")
+	check(c.Shell.ConsoleMaxLines >= 100, "shell.console_max_lines", c.Shell.ConsoleMaxLines, func() { c.Shell.ConsoleMaxLines = d.Shell.ConsoleMaxLines }, "a number of lines from 100 is expected")
+	check(c.Shell.SSHTimeout > 0, "shell.ssh_timeout", c.Shell.SSHTimeout, func() { c.Shell.SSHTimeout = d.Shell.SSHTimeout }, "a number of seconds above 0 is expected")
+
+	check(c.Files.MaxPreview > 0 && c.Files.MaxPreview <= 1024*1024, "files.max_preview", c.Files.MaxPreview, func() { c.Files.MaxPreview = d.Files.MaxPreview }, "a number of bytes from 1 to 1048576 is expected")
+	check(c.Files.HashThresholdSize > 0, "files.hash_threshold_size", c.Files.HashThresholdSize, func() { c.Files.HashThresholdSize = d.Files.HashThresholdSize }, "a number of bytes above 0 is expected")
+
+	check(c.SQLite3.Database != "", "sqlite3.database", `""`, func() { c.SQLite3.Database = d.SQLite3.Database }, "a file name or :memory: is expected")
+
+	for _, color := range []struct {
+		key   string
+		value *string
+		def   string
+	}{
+		{"colors.folder", &c.Colors.Folder, d.Colors.Folder},
+		{"colors.file", &c.Colors.File, d.Colors.File},
+		{"colors.executable", &c.Colors.Executable, d.Colors.Executable},
+		{"colors.selected", &c.Colors.Selected, d.Colors.Selected},
+	} {
+		if *color.value == "" {
+			continue
+		}
+		if _, err := theme.ParseColor(*color.value); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", color.key, err))
+			*color.value = color.def
+		}
+	}
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Errorf("log.level: %v", err))
+		c.Log.Level = d.Log.Level
+	}
+	check(c.Log.MaxSize >= 0, "log.max_size", c.Log.MaxSize, func() { c.Log.MaxSize = d.Log.MaxSize }, "a number of KB is expected (0 never rotates gosh.log)")
+	check(c.Log.MaxFiles > 0, "log.max_files", c.Log.MaxFiles, func() { c.Log.MaxFiles = d.Log.MaxFiles }, "a number of files above 0 is expected")
+	return problems
+}
+
+// ****************************************************************************
+// migrateV1()
+// migrateV1 moves the flat settings of version 1 into their module
+// ****************************************************************************
+func migrateV1(raw map[string]any) {
+	move := func(old string, section string, key string) {
+		value, ok := raw[old]
+		if !ok {
+			return
+		}
+		delete(raw, old)
+		s, _ := raw[section].(map[string]any)
+		if s == nil {
+			s = make(map[string]any)
+			raw[section] = s
+		}
+		s[key] = value
+	}
+	for _, key := range []string{"prompt", "save_env", "history_size", "console_max_lines", "notify_after", "notify_hook"} {
+		move(key, "shell", key)
+	}
+	// 0 was the default size, < 0 never rotated
+	if size, ok := raw["log_max_size"].(float64); ok {
+		switch {
+		case size == 0:
+			delete(raw, "log_max_size")
+		case size < 0:
+			raw["log_max_size"] = 0
+		}
+	}
+	move("log_level", "log", "level")
+	move("log_max_size", "log", "max_size")
+	move("log_max_files", "log", "max_files")
+	// The screen was written as a number
+	if mode, ok := raw["startup_screen"].(float64); ok {
+		raw["startup_screen"] = ui.Mode(mode).String()
+	}
+	// 0 meant the default, as for the other numbers left out
+	for _, key := range []string{"history_size", "console_max_lines", "notify_after"} {
+		if s, ok := raw["shell"].(map[string]any); ok && s[key] == float64(0) {
+			delete(s, key)
+		}
+	}
+	if raw["sudo_timeout"] == float64(0) {
+		delete(raw, "sudo_timeout")
+	}
+	if s, ok := raw["log"].(map[string]any); ok && s["max_files"] == float64(0) {
+		delete(s, "max_files")
+	}
+}
+
+// ****************************************************************************
+// migrateV2()
+// migrateV2 leaves the colors of version 2 to the theme, unless changed
+// ****************************************************************************
+func migrateV2(raw map[string]any) {
+	colors, ok := raw["colors"].(map[string]any)
+	if !ok {
+		return
+	}
+	// The defaults of version 2
+	for key, value := range map[string]string{"folder": "lightgreen", "file": "yellow", "executable": "lightyellow", "selected": "red"} {
+		if colors[key] == value {
+			delete(colors, key)
+		}
+	}
+}
+
+// ****************************************************************************
+// unknownKeys()
+// unknownKeys reports the keys of raw which are not in the schema t
+// ****************************************************************************
+func unknownKeys(raw map[string]any, t reflect.Type, prefix string) []error {
+	known := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		known[jsonName(t.Field(i))] = t.Field(i).Type
+	}
+	var keys []string
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var problems []error
+	for _, key := range keys {
+		ft, ok := known[key]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Errorf("%s%s: unknown setting, ignored", prefix, key))
+		case ft.Kind() == reflect.Struct:
+			if section, ok := raw[key].(map[string]any); ok {
+				problems = append(problems, unknownKeys(section, ft, prefix+key+".")...)
+			}
+		}
+	}
+	return problems
+}
+
+// ****************************************************************************
+// syntaxError()
+// syntaxError tells where the JSON is wrong
+// ****************************************************************************
+func syntaxError(b []byte, err error) error {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		line := bytes.Count(b[:se.Offset], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %v, the default settings are used", line, err)
+	}
+	return fmt.Errorf("%v, the default settings are used", err)
+}
+
+// ****************************************************************************
+// typeError()
+// typeError names the setting of the wrong type
+// ****************************************************************************
+func typeError(err error) error {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return fmt.Errorf("%s: a %s is expected, not a %s", te.Field, typeName(te.Type), te.Value)
+	}
+	return err
+}
+
+// ****************************************************************************
+// typeName()
+// ****************************************************************************
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		return "number"
+	case reflect.Bool:
+		return "boolean (true or false)"
+	case reflect.Struct:
+		return "section { … }"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// ****************************************************************************
+// errorList()
+// ****************************************************************************
+func errorList(err error) []error {
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
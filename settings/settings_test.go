@@ -0,0 +1,177 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package settings
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"encoding/json"
+	"fmt"
+	"gosh/conf"
+	"gosh/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ****************************************************************************
+// TestLoad()
+// ****************************************************************************
+func TestLoad(t *testing.T) {
+	d := ui.DefaultConfig()
+	tests := []struct {
+		name     string
+		json     string
+		check    func(c ui.Config) string // What is wrong, "" when all is right
+		problems []string                 // Found in the problems, in order
+	}{
+		{"v1 flat settings", `{"prompt":"$ ","save_env":true,"history_size":50,"console_max_lines":500,"notify_hook":"beep","log_level":"debug","log_max_files":5}`,
+			func(c ui.Config) string {
+				if c.Shell.Prompt != "$ " || !c.Shell.SaveEnv || c.Shell.HistorySize != 50 || c.Shell.ConsoleMaxLines != 500 || c.Shell.NotifyHook != "beep" {
+					return fmt.Sprintf("shell %+v", c.Shell)
+				}
+				if c.Log.Level != "debug" || c.Log.MaxSize != d.Log.MaxSize || c.Log.MaxFiles != 5 {
+					return fmt.Sprintf("log %+v", c.Log)
+				}
+				return ""
+			}, nil},
+		{"v1 zeros for the defaults", `{"history_size":0,"console_max_lines":0,"notify_after":0,"sudo_timeout":0,"log_max_size":0,"log_max_files":0}`,
+			func(c ui.Config) string {
+				if c.Shell.HistorySize != d.Shell.HistorySize || c.Shell.ConsoleMaxLines != d.Shell.ConsoleMaxLines || c.Shell.NotifyAfter != d.Shell.NotifyAfter {
+					return fmt.Sprintf("shell %+v", c.Shell)
+				}
+				if c.SudoTimeout != d.SudoTimeout || c.Log.MaxSize != d.Log.MaxSize || c.Log.MaxFiles != d.Log.MaxFiles {
+					return fmt.Sprintf("sudo %d, log %+v", c.SudoTimeout, c.Log)
+				}
+				return ""
+			}, nil},
+		{"v1 log never rotated", `{"log_max_size":-1}`,
+			func(c ui.Config) string {
+				if c.Log.MaxSize != 0 {
+					return fmt.Sprintf("log.max_size %d", c.Log.MaxSize)
+				}
+				return ""
+			}, nil},
+		{"v1 log size", `{"log_max_size":2048}`,
+			func(c ui.Config) string {
+				if c.Log.MaxSize != 2048 {
+					return fmt.Sprintf("log.max_size %d", c.Log.MaxSize)
+				}
+				return ""
+			}, nil},
+		{"v1 startup screen number", fmt.Sprintf(`{"startup_screen":%d}`, int(ui.ModeFiles)),
+			func(c ui.Config) string {
+				if c.StartupScreen != ui.ModeFiles {
+					return fmt.Sprintf("startup_screen %v", c.StartupScreen)
+				}
+				return ""
+			}, nil},
+		{"v1 unknown key", `{"prompt":"$ ","colour":"red"}`, nil,
+			[]string{"colour: unknown setting"}},
+		{"v2 default colors", `{"version":2,"colors":{"folder":"lightgreen","file":"blue","executable":"lightyellow","selected":"#FF0000"}}`,
+			func(c ui.Config) string {
+				want := ui.ColorsConfig{File: "blue", Selected: "#FF0000"}
+				if c.Colors != want {
+					return fmt.Sprintf("colors %+v", c.Colors)
+				}
+				return ""
+			}, nil},
+		{"v2 without colors", `{"version":2,"theme":"` + d.Theme + `"}`, nil, nil},
+		{"wrong values", `{"version":3,"status_duration":0,"theme":"nope","shell":{"history_size":-1,"console_max_lines":10},"colors":{"folder":"nocolor"},"log":{"level":"loud","max_files":0}}`,
+			func(c ui.Config) string {
+				if c.StatusDuration != d.StatusDuration || c.Theme != d.Theme {
+					return fmt.Sprintf("status_duration %d, theme %q", c.StatusDuration, c.Theme)
+				}
+				if c.Shell.HistorySize != d.Shell.HistorySize || c.Shell.ConsoleMaxLines != d.Shell.ConsoleMaxLines {
+					return fmt.Sprintf("shell %+v", c.Shell)
+				}
+				if c.Colors.Folder != d.Colors.Folder || c.Log != d.Log {
+					return fmt.Sprintf("colors %+v, log %+v", c.Colors, c.Log)
+				}
+				return ""
+			}, []string{"status_duration: 0 is wrong", "theme: nope is wrong", "shell.history_size: -1 is wrong", "shell.console_max_lines: 10 is wrong", "colors.folder", "log.level", "log.max_files: 0 is wrong"}},
+		{"wrong type", `{"version":3,"shell":{"history_size":"many","prompt":"> "}}`,
+			func(c ui.Config) string {
+				if c.Shell.Prompt != "> " || c.Shell.HistorySize != d.Shell.HistorySize {
+					return fmt.Sprintf("shell %+v", c.Shell)
+				}
+				return ""
+			}, []string{"history_size: a number is expected, not a string"}},
+		{"wrong section", `{"version":3,"log":"debug"}`, nil,
+			[]string{"log: a section { … } is expected"}},
+		{"newer version", `{"version":99}`, nil,
+			[]string{"version 99 is newer"}},
+		{"syntax error", "{\n\"prompt\": \"$ \",\n}", nil,
+			[]string{"line 3:"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fName := filepath.Join(t.TempDir(), conf.FILE_CONFIG)
+			if err := os.WriteFile(fName, []byte(test.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c, problems := load(fName)
+			if test.check != nil {
+				if wrong := test.check(c); wrong != "" {
+					t.Errorf("%s", wrong)
+				}
+			}
+			if len(problems) != len(test.problems) {
+				t.Fatalf("problems %v, want %q", problems, test.problems)
+			}
+			for i, p := range problems {
+				if !strings.Contains(p.Error(), test.problems[i]) {
+					t.Errorf("problem %q, want %q", p.Error(), test.problems[i])
+				}
+			}
+		})
+	}
+}
+
+// ****************************************************************************
+// TestMigratedFile()
+// TestMigratedFile checks that an old file is kept, and replaced by the new
+// version which loads without migration
+// ****************************************************************************
+func TestMigratedFile(t *testing.T) {
+	fName := filepath.Join(t.TempDir(), conf.FILE_CONFIG)
+	old := `{"prompt":"$ ","log_level":"warning"}`
+	if err := os.WriteFile(fName, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, problems := load(fName); problems != nil {
+		t.Fatalf("problems %v", problems)
+	}
+	if b, err := os.ReadFile(fName + ".v1"); err != nil || string(b) != old {
+		t.Errorf("old file %q, %v", b, err)
+	}
+
+	b, err := os.ReadFile(fName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["version"] != float64(conf.CONFIG_VERSION) || raw["prompt"] != nil {
+		t.Errorf("new file %s", b)
+	}
+	c, problems := load(fName)
+	if problems != nil || c.Shell.Prompt != "$ " || c.Log.Level != "warning" {
+		t.Errorf("loaded again: %+v %v", c, problems)
+	}
+	if _, err := os.Stat(fName + ".v3"); err == nil {
+		t.Error("a file of the current version was migrated")
+	}
+}
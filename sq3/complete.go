@@ -0,0 +1,129 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package sq3
+
+// ****************************************************************************
+// complete finds the candidates for the completion of the word typed in the
+// prompt of the SQLite3 Manager
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	dotCommands = []string{".CLOSE", ".COLUMNS", ".DATABASE", ".OPEN", ".SCHEMA", ".TABLE"}
+)
+
+// ****************************************************************************
+// Complete()
+// Complete returns the candidates for the word before the cursor, each one
+// replacing line[start:cursor]
+// ****************************************************************************
+func Complete(line string, cursor int) (start int, candidates []string) {
+	start = cursor
+	for start > 0 && isIdentChar(line[start-1]) {
+		start--
+	}
+	word := line[start:cursor]
+	if strings.HasPrefix(word, ".") && strings.TrimSpace(line[:start]) == "" {
+		// .command at the beginning of the line
+		return start, matchFold(dotCommands, word)
+	}
+	if CurrentDB == nil {
+		return start, nil
+	}
+	tables := getTables()
+	if table, column, found := strings.Cut(word, "."); found {
+		// table.column
+		for _, c := range matchFold(getColumns(table), column) {
+			candidates = append(candidates, table+"."+c)
+		}
+		return start, candidates
+	}
+	candidates = matchFold(tables, word)
+	if !strings.HasPrefix(strings.TrimSpace(line[:start]), ".") {
+		// The dot commands only take a table, the statements take the columns
+		// of the tables they use, or of all the tables
+		used := map[string]bool{}
+		for _, token := range strings.FieldsFunc(line, func(r rune) bool { return r > 127 || r == '.' || !isIdentChar(byte(r)) }) {
+			for _, t := range tables {
+				if strings.EqualFold(token, t) {
+					used[t] = true
+				}
+			}
+		}
+		for _, t := range tables {
+			if used[t] || len(used) == 0 {
+				candidates = append(candidates, matchFold(getColumns(t), word)...)
+			}
+		}
+	}
+	sort.Strings(candidates)
+	var unique []string
+	for i, c := range candidates {
+		if i == 0 || c != candidates[i-1] {
+			unique = append(unique, c)
+		}
+	}
+	return start, unique
+}
+
+// ****************************************************************************
+// getColumns()
+// ****************************************************************************
+func getColumns(table string) []string {
+	var columns []string
+	rows, err := CurrentDB.Query(fmt.Sprintf("PRAGMA table_info(\"%s\");", strings.ReplaceAll(table, "\"", "\"\"")))
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var cid, notNull, pk int
+			var name, colType string
+			var dflt sql.NullString
+			err = rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk)
+			if err == nil {
+				columns = append(columns, name)
+			}
+		}
+	}
+	return columns
+}
+
+// ****************************************************************************
+// isIdentChar()
+// ****************************************************************************
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// ****************************************************************************
+// matchFold()
+// matchFold returns the words beginning with prefix, ignoring the case
+// ****************************************************************************
+func matchFold(words []string, prefix string) []string {
+	var matches []string
+	for _, w := range words {
+		if len(w) >= len(prefix) && strings.EqualFold(w[:len(prefix)], prefix) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
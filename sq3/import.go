@@ -0,0 +1,310 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package sq3
+
+// ****************************************************************************
+// import creates a table in the current database from the text output of a
+// command, made of columns separated by spaces, of CSV or of JSON
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gosh/ui"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type ImportFormat int
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	IMPORT_COLUMNS ImportFormat = iota // Separated by spaces, the last one takes the rest of the line
+	IMPORT_CSV
+	IMPORT_JSON
+)
+
+// ****************************************************************************
+// ImportTable()
+// ImportTable creates the table name with the rows of text, its first line
+// giving the names of the columns (the keys of the objects for JSON). It
+// returns the number of rows inserted.
+// ****************************************************************************
+func ImportTable(name string, text string, format ImportFormat) (int, error) {
+	var header []string
+	var rows [][]string
+	var err error
+	switch format {
+	case IMPORT_COLUMNS:
+		header, rows = splitColumns(text)
+	case IMPORT_CSV:
+		header, rows, err = splitCSV(text)
+	case IMPORT_JSON:
+		header, rows, err = splitJSON(text)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(header) == 0 {
+		return 0, errors.New("nothing to import")
+	}
+	if CurrentDB == nil {
+		if err := OpenDB(CurrentDatabaseName); err != nil {
+			return 0, err
+		}
+	}
+
+	columns := columnNames(header)
+	var defs []string
+	for i, c := range columns {
+		defs = append(defs, fmt.Sprintf("%s %s", quoteName(c), columnType(rows, i)))
+	}
+	tx, err := CurrentDB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s);", quoteName(name), strings.Join(defs, ", "))); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s);", quoteName(name), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	values := make([]any, len(columns))
+	for _, row := range rows {
+		for i := range values {
+			values[i] = nil
+			if i < len(row) && row[i] != "" {
+				values[i] = row[i]
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	showTreeDB()
+	return len(rows), nil
+}
+
+// ****************************************************************************
+// ShowTable()
+// ShowTable displays the rows of a table in the SQLite3 Manager
+// ****************************************************************************
+func ShowTable(name string) {
+	ui.AddNewScreen(ui.ModeSQLite3, nil, nil)
+	doSelect(fmt.Sprintf("SELECT * FROM %s;", quoteName(name)))
+}
+
+// ****************************************************************************
+// FreeTableName()
+// FreeTableName returns base, or base followed by a number when there is
+// already a table with this name
+// ****************************************************************************
+func FreeTableName(base string) string {
+	if CurrentDB == nil {
+		return base
+	}
+	used := map[string]bool{}
+	for _, t := range getTables() {
+		used[strings.ToLower(t)] = true
+	}
+	name := base
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+// ****************************************************************************
+// splitColumns()
+// ****************************************************************************
+func splitColumns(text string) ([]string, [][]string) {
+	var header []string
+	var rows [][]string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if header == nil {
+			header = strings.Fields(line)
+			continue
+		}
+		rows = append(rows, splitFields(line, len(header)))
+	}
+	return header, rows
+}
+
+// ****************************************************************************
+// splitFields()
+// splitFields splits line in n fields at most, the last one keeping the
+// spaces of the rest of the line
+// ****************************************************************************
+func splitFields(line string, n int) []string {
+	var fields []string
+	rest := strings.TrimSpace(line)
+	for len(fields) < n-1 && rest != "" {
+		i := strings.IndexAny(rest, " \t")
+		if i < 0 {
+			break
+		}
+		fields = append(fields, rest[:i])
+		rest = strings.TrimLeft(rest[i:], " \t")
+	}
+	if rest != "" {
+		fields = append(fields, rest)
+	}
+	return fields
+}
+
+// ****************************************************************************
+// splitCSV()
+// ****************************************************************************
+func splitCSV(text string) ([]string, [][]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+// ****************************************************************************
+// splitJSON()
+// splitJSON reads an array of objects, or one object per line, the values
+// which are not strings being kept as JSON
+// ****************************************************************************
+func splitJSON(text string) ([]string, [][]string, error) {
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &objects); err != nil {
+		objects = nil
+		dec := json.NewDecoder(strings.NewReader(text))
+		for {
+			var o map[string]json.RawMessage
+			if err := dec.Decode(&o); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, nil, fmt.Errorf("JSON objects expected : %v", err)
+			}
+			objects = append(objects, o)
+		}
+	}
+	seen := map[string]bool{}
+	var header []string
+	for _, o := range objects {
+		var keys []string
+		for k := range o {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		header = append(header, keys...)
+	}
+	var rows [][]string
+	for _, o := range objects {
+		row := make([]string, len(header))
+		for i, k := range header {
+			raw, ok := o[k]
+			if !ok || string(raw) == "null" {
+				continue
+			}
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				row[i] = s
+			} else {
+				row[i] = string(raw)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// ****************************************************************************
+// columnNames()
+// columnNames makes unique names for the columns, the empty ones being named
+// after their position
+// ****************************************************************************
+func columnNames(header []string) []string {
+	used := map[string]bool{}
+	var names []string
+	for i, h := range header {
+		name := strings.TrimSpace(h)
+		if name == "" {
+			name = fmt.Sprintf("col%d", i+1)
+		}
+		base := name
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// ****************************************************************************
+// columnType()
+// columnType returns INTEGER or REAL when all the values of the column are
+// numbers, TEXT otherwise
+// ****************************************************************************
+func columnType(rows [][]string, col int) string {
+	colType := "INTEGER"
+	found := false
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+		found = true
+		if _, err := strconv.ParseInt(row[col], 10, 64); err == nil {
+			continue
+		}
+		if _, err := strconv.ParseFloat(row[col], 64); err == nil {
+			colType = "REAL"
+			continue
+		}
+		return "TEXT"
+	}
+	if !found {
+		return "TEXT"
+	}
+	return colType
+}
+
+// ****************************************************************************
+// quoteName()
+// ****************************************************************************
+func quoteName(name string) string {
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,132 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package sq3
+
+// ****************************************************************************
+// screen keeps the database of each SQLite3 screen, with its tree and its
+// last request. CurrentDB and CurrentDatabaseName are the ones of the SQLite3
+// screen shown last : a new screen goes on with them, and the console
+// imports into them.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"database/sql"
+	"fmt"
+	"gosh/theme"
+	"gosh/ui"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type database struct {
+	name  string
+	db    *sql.DB
+	root  *tview.TreeNode
+	query string // Last request shown, run again with the screen
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	screens = make(map[string]*database) // By screen, see ui.GetCurrentScreen
+	cur     *database                    // SQLite3 screen shown, nil for another one
+)
+
+// ****************************************************************************
+// ShowScreen()
+// ShowScreen gives its database back to the SQLite3 screen shown, closing the
+// ones of the closed screens when no other screen uses them
+// ****************************************************************************
+func ShowScreen() {
+	if cur != nil {
+		cur.name, cur.db, cur.root = CurrentDatabaseName, CurrentDB, root
+		cur = nil
+	}
+	for id, d := range screens {
+		if ui.IsScreenOpen(id) {
+			continue
+		}
+		delete(screens, id)
+		if d.db != nil && d.db != CurrentDB && !inUse(d.db) {
+			d.db.Close()
+		}
+	}
+	if ui.CurrentMode != ui.ModeSQLite3 {
+		return
+	}
+	id := ui.GetCurrentScreen()
+	d, ok := screens[id]
+	if !ok {
+		d = &database{name: CurrentDatabaseName, db: CurrentDB}
+		screens[id] = d
+	}
+	cur = d
+	CurrentDatabaseName, CurrentDB = d.name, d.db
+	ui.TblSQLOutput.Clear()
+	ui.TblSQLTables.Clear()
+	if CurrentDB == nil {
+		ui.TxtSQLName.SetText("")
+		root = tview.NewTreeNode("")
+		ui.TrvSQLDatabase.SetRoot(root).SetCurrentNode(root)
+		return
+	}
+	ui.TxtSQLName.SetText(fmt.Sprintf("Database [yellow]%s", CurrentDatabaseName))
+	if d.root != nil {
+		root = d.root
+		ui.TrvSQLDatabase.SetRoot(root).SetCurrentNode(root)
+	} else {
+		showTreeDB()
+	}
+	if d.query != "" {
+		doSelect(d.query)
+	}
+}
+
+// ****************************************************************************
+// ApplyTheme()
+// ApplyTheme gives the text color of the current theme to the trees of the
+// databases, which the screens keep
+// ****************************************************************************
+func ApplyTheme() {
+	roots := []*tview.TreeNode{root}
+	for _, d := range screens {
+		roots = append(roots, d.root)
+	}
+	for _, r := range roots {
+		if r == nil {
+			continue
+		}
+		r.Walk(func(node, parent *tview.TreeNode) bool {
+			node.SetColor(theme.Text)
+			return true
+		})
+	}
+}
+
+// ****************************************************************************
+// inUse()
+// inUse tells if another SQLite3 screen than the one shown uses db
+// ****************************************************************************
+func inUse(db *sql.DB) bool {
+	for _, d := range screens {
+		if d != cur && d.db == db {
+			return true
+		}
+	}
+	return false
+}
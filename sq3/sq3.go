@@ -14,10 +14,13 @@ import (
 	"bufio"
 	"database/sql"
 	"fmt"
+	"gosh/audit"
 	"gosh/conf"
 	"gosh/dialog"
 	"gosh/edit"
+	"gosh/logger"
 	"gosh/menu"
+	"gosh/theme"
 	"gosh/ui"
 	"gosh/utils"
 	"os"
@@ -26,7 +29,6 @@ import (
 	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
-	"github.com/gdamore/tcell/v2"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rivo/tview"
 )
@@ -45,8 +47,6 @@ var (
 	root                *tview.TreeNode
 	MnuSQL              *menu.Menu
 )
-var headerBackgroundColor = tcell.ColorDarkGreen
-var headerTextColor = tcell.ColorYellow
 
 // ****************************************************************************
 // Xeq()
@@ -128,7 +128,9 @@ func Xeq(c string) {
 // ****************************************************************************
 func DoExec(cmd string) {
 	_, err := CurrentDB.Exec(cmd)
+	audit.Log(audit.ACTION_SQL_EXEC, fmt.Sprintf("%s : %s", CurrentDatabaseName, cmd), err)
 	if err != nil {
+		logger.Warning("sq3.go: %s: %v", cmd, err)
 		ui.SetStatus(err.Error())
 	} else {
 		ui.SetStatus(fmt.Sprintf("Executing %s", cmd))
@@ -141,11 +143,17 @@ func DoExec(cmd string) {
 func OpenDB(fName string) error {
 	db, err := sql.Open("sqlite3", fName)
 	if err == nil {
+		if fName == ":memory:" {
+			// Each connection would have its own database
+			db.SetMaxOpenConns(1)
+		}
 		CurrentDB = db
 		ui.TxtSQLName.SetText(fmt.Sprintf("Database [yellow]%s", fName))
 		CurrentDatabaseName = fName
 		showTreeDB()
 		ui.SetStatus(fmt.Sprintf("Database %s open successfully", fName))
+	} else {
+		logger.Error("sq3.go: Can't open %s: %v", fName, err)
 	}
 	return err
 }
@@ -154,7 +162,10 @@ func OpenDB(fName string) error {
 // CloseDB()
 // ****************************************************************************
 func CloseDB(db *sql.DB) {
-	db.Close()
+	// Another screen can go on with it
+	if !inUse(db) {
+		db.Close()
+	}
 	ui.SetStatus("Database closed")
 	ui.TxtSQLName.SetText("")
 	ui.TblSQLOutput.Clear()
@@ -328,10 +339,15 @@ func doSelect(q string) {
 	if CurrentDB != nil {
 		ui.TblSQLOutput.Clear()
 		var myMap = make(map[string]interface{})
+		logger.Debug("sq3.go: Query %s", q)
 		rows, err := CurrentDB.Query(q)
 		if err != nil {
+			logger.Warning("sq3.go: %s: %v", q, err)
 			ui.SetStatus(err.Error())
 		} else {
+			if cur != nil {
+				cur.query = q
+			}
 			defer rows.Close()
 			colNames, err := rows.Columns()
 			if err != nil {
@@ -344,7 +360,7 @@ func doSelect(q string) {
 				}
 				// Header of fields names
 				for k, colName := range colNames {
-					ui.TblSQLOutput.SetCell(0, k, tview.NewTableCell(tview.Escape("["+colName+"]")).SetAlign(tview.AlignLeft).SetTextColor(headerTextColor).SetBackgroundColor(headerBackgroundColor))
+					ui.TblSQLOutput.SetCell(0, k, tview.NewTableCell(tview.Escape("["+colName+"]")).SetAlign(tview.AlignLeft).SetTextColor(theme.TableHeader).SetBackgroundColor(theme.TableHeaderBackground))
 				}
 				i := 1
 				for rows.Next() {
@@ -505,8 +521,7 @@ func SwitchToSQLite3() {
 // ****************************************************************************
 func SelfInit(a any) {
 	if ui.CurrentMode == ui.ModeFiles {
-		idx, _ := ui.TblFiles.GetSelection()
-		fName := filepath.Join(conf.Cwd, strings.TrimSpace(ui.TblFiles.GetCell(idx, 2).Text))
+		fName := ui.SelectedFile()
 		xtype, _ := mimetype.DetectFile(fName)
 		if strings.HasSuffix(xtype.String(), "sqlite3") {
 			// Is there an open database ?
@@ -520,7 +535,7 @@ func SelfInit(a any) {
 				} else {
 					ui.CurrentMode = ui.ModeSQLite3
 					ui.SetTitle("SQLite3")
-					ui.LblKeys.SetText(conf.FKEY_LABELS + "\nCtrl+O=Open Ctrl+S=Save")
+					ui.ShowKeys(ui.ModeSQLite3, "")
 					ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
 					ui.App.SetFocus(ui.TxtPrompt)
 					ui.SetStatus(err.Error())
@@ -530,21 +545,21 @@ func SelfInit(a any) {
 				DoExec(fmt.Sprintf("attach database '%s' as %s", fName, utils.FilenameWithoutExtension(filepath.Base(fName))))
 				ui.CurrentMode = ui.ModeSQLite3
 				ui.SetTitle("SQLite3")
-				ui.LblKeys.SetText(conf.FKEY_LABELS + "\nCtrl+O=Open Ctrl+S=Save")
+				ui.ShowKeys(ui.ModeSQLite3, "")
 				ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
 				ui.App.SetFocus(ui.TxtPrompt)
 			}
 		} else {
 			ui.CurrentMode = ui.ModeSQLite3
 			ui.SetTitle("SQLite3")
-			ui.LblKeys.SetText(conf.FKEY_LABELS + "\nCtrl+O=Open Ctrl+S=Save")
+			ui.ShowKeys(ui.ModeSQLite3, "")
 			ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
 			ui.App.SetFocus(ui.TxtPrompt)
 		}
 	} else {
 		ui.CurrentMode = ui.ModeSQLite3
 		ui.SetTitle("SQLite3")
-		ui.LblKeys.SetText(conf.FKEY_LABELS + "\nCtrl+O=Open Ctrl+S=Save")
+		ui.ShowKeys(ui.ModeSQLite3, "")
 		ui.PgsApp.SwitchToPage(ui.GetCurrentScreen())
 		ui.App.SetFocus(ui.TxtPrompt)
 	}
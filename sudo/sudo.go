@@ -0,0 +1,206 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package sudo
+
+// ****************************************************************************
+// sudo runs the built-in actions which failed for lack of rights with sudo.
+// The password is asked once and checked by sudo, which remembers it has been
+// given : gosh keeps no password, only the end of the elevation, and makes
+// sudo forget it after sudo_timeout minutes. A sudo which remembers it for
+// less time asks for it again.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gosh/conf"
+	"gosh/dialog"
+	"gosh/logger"
+	"gosh/ui"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	DlgPassword *dialog.Dialog
+	mutex       sync.Mutex
+	expires     time.Time // End of the elevation, zero when not elevated
+	timer       *time.Timer
+)
+
+// ****************************************************************************
+// Elevated()
+// Elevated tells if the actions run with the rights of root, gosh being run
+// by root or the password having been given to sudo
+// ****************************************************************************
+func Elevated() bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	return time.Now().Before(expires)
+}
+
+// ****************************************************************************
+// Command()
+// Command returns the command run by sudo, which must not ask for the
+// password, or run as it is by root
+// ****************************************************************************
+func Command(name string, args ...string) *exec.Cmd {
+	if os.Geteuid() == 0 {
+		return exec.Command(name, args...)
+	}
+	return exec.Command("sudo", append([]string{"-n", "--", name}, args...)...)
+}
+
+// ****************************************************************************
+// Run()
+// Run runs a command with Command and returns its error, with what it wrote
+// on stderr if any
+// ****************************************************************************
+func Run(name string, args ...string) error {
+	xCmd := Command(name, args...)
+	var stderr bytes.Buffer
+	xCmd.Stderr = &stderr
+	if err := xCmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		// sudo forgot the password before gosh did
+		if strings.Contains(msg, "password is required") {
+			mutex.Lock()
+			expires = time.Time{}
+			mutex.Unlock()
+			return errors.New("sudo: the elevation has expired, please try again")
+		}
+		if msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// ****************************************************************************
+// Elevate()
+// Elevate runs op elevated, asking for the password when none is cached,
+// then calls done with the error of op. Called on the UI thread, done too.
+// ****************************************************************************
+func Elevate(what string, op func() error, done func(err error)) {
+	if Elevated() {
+		go func() {
+			err := op()
+			ui.App.QueueUpdateDraw(func() {
+				ui.SetElevated(Elevated())
+				done(err)
+			})
+		}()
+		return
+	}
+	focus := ui.App.GetFocus()
+	DlgPassword = DlgPassword.Password("sudo", // Title
+		fmt.Sprintf("Permission denied to %s.\nPlease, enter the password of %s :", what, currentUser()), // Message
+		func(button dialog.DlgButton, idx int) {
+			if button != dialog.BUTTON_OK {
+				ui.SetStatus("Elevation cancelled")
+				return
+			}
+			pwd := DlgPassword.Value
+			DlgPassword.Value = ""
+			ui.PleaseWait()
+			go func() {
+				err := validate(pwd)
+				if err == nil {
+					err = op()
+				}
+				ui.App.QueueUpdateDraw(func() {
+					ui.JobsDone()
+					ui.SetElevated(Elevated())
+					done(err)
+				})
+			}()
+		},
+		0,
+		ui.GetCurrentScreen(), focus) // Focus return
+	ui.PgsApp.AddPage("dlgSudoPassword", DlgPassword.Popup(), true, false)
+	ui.PgsApp.ShowPage("dlgSudoPassword")
+}
+
+// ****************************************************************************
+// Forget()
+// Forget ends the elevation, sudo asking for the password again, called on
+// the UI thread
+// ****************************************************************************
+func Forget() {
+	mutex.Lock()
+	expires = time.Time{}
+	if timer != nil {
+		timer.Stop()
+	}
+	mutex.Unlock()
+	if os.Geteuid() != 0 {
+		if err := exec.Command("sudo", "-k").Run(); err != nil {
+			logger.Warning("sudo.go: Can't drop the elevation: %v", err)
+		}
+	}
+	ui.SetElevated(Elevated())
+}
+
+// ****************************************************************************
+// validate()
+// validate gives the password to sudo, which remembers it has been given
+// ****************************************************************************
+func validate(pwd string) error {
+	// -k : the password is checked, even when sudo remembers an older one
+	xCmd := exec.Command("sudo", "-S", "-k", "-v", "-p", "")
+	xCmd.Stdin = strings.NewReader(pwd + "\n")
+	if out, err := xCmd.CombinedOutput(); err != nil {
+		logger.Warning("sudo.go: Validation failed: %v %s", err, out)
+		return errors.New("sudo: wrong password, or not allowed to run sudo")
+	}
+	minutes := ui.MyConfig.SudoTimeout
+	if minutes <= 0 {
+		minutes = conf.SUDO_TIMEOUT
+	}
+	duration := time.Duration(minutes) * time.Minute
+	mutex.Lock()
+	defer mutex.Unlock()
+	expires = time.Now().Add(duration)
+	if timer != nil {
+		timer.Stop()
+	}
+	timer = time.AfterFunc(duration, func() {
+		ui.App.QueueUpdateDraw(Forget)
+	})
+	logger.Info("sudo.go: Elevated for %s", duration)
+	return nil
+}
+
+// ****************************************************************************
+// currentUser()
+// ****************************************************************************
+func currentUser() string {
+	if u := os.Getenv("USER"); u != "" {
+		return tview.Escape(u)
+	}
+	return "the user"
+}
@@ -0,0 +1,170 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package theme
+
+// ****************************************************************************
+// builtin holds the themes shipped with gosh. dark is the historical look,
+// and gives its colors to the roles the other themes leave out.
+// ****************************************************************************
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var builtins = []Theme{
+	{
+		Name:   "dark",
+		Editor: "monokai",
+		Colors: map[string]string{
+			"background":              "black",
+			"contrast":                "blue",
+			"more_contrast":           "green",
+			"border":                  "white",
+			"title":                   "white",
+			"graphics":                "white",
+			"text":                    "white",
+			"secondary_text":          "yellow",
+			"tertiary_text":           "green",
+			"inverse_text":            "blue",
+			"contrast_secondary_text": "navy",
+			"header":                  "green",
+			"header_background":       "black",
+			"keys":                    "lightblue",
+			"status_text":             "wheat",
+			"status_background":       "darkgreen",
+			"hostname":                "black",
+			"table_header":            "yellow",
+			"table_header_background": "darkgreen",
+			"menu":                    "blue",
+			"highlight":               "yellow",
+			"dimmed":                  "gray",
+			"error":                   "red",
+			"error_background":        "darkred",
+			"success":                 "green",
+			"success_background":      "darkgreen",
+			"elevated":                "darkred",
+			"folder":                  "lightgreen",
+			"file":                    "yellow",
+			"executable":              "lightyellow",
+			"selected":                "red",
+		},
+	},
+	{
+		Name:   "light",
+		Editor: "cmc-paper",
+		Colors: map[string]string{
+			"background":              "white",
+			"contrast":                "gainsboro",
+			"more_contrast":           "silver",
+			"border":                  "gray",
+			"title":                   "black",
+			"graphics":                "gray",
+			"text":                    "black",
+			"secondary_text":          "navy",
+			"tertiary_text":           "darkgreen",
+			"inverse_text":            "white",
+			"contrast_secondary_text": "navy",
+			"header":                  "darkgreen",
+			"header_background":       "white",
+			"keys":                    "navy",
+			"status_text":             "black",
+			"status_background":       "silver",
+			"hostname":                "navy",
+			"table_header":            "white",
+			"table_header_background": "teal",
+			"menu":                    "gainsboro",
+			"highlight":               "navy",
+			"dimmed":                  "gray",
+			"error":                   "firebrick",
+			"error_background":        "firebrick",
+			"success":                 "darkgreen",
+			"success_background":      "darkgreen",
+			"elevated":                "firebrick",
+			"folder":                  "darkgreen",
+			"file":                    "navy",
+			"executable":              "darkorange",
+			"selected":                "red",
+		},
+	},
+	{
+		Name:   "high-contrast",
+		Editor: "simple",
+		Colors: map[string]string{
+			"background":              "black",
+			"contrast":                "white",
+			"more_contrast":           "yellow",
+			"border":                  "white",
+			"title":                   "yellow",
+			"graphics":                "white",
+			"text":                    "white",
+			"secondary_text":          "yellow",
+			"tertiary_text":           "aqua",
+			"inverse_text":            "black",
+			"contrast_secondary_text": "black",
+			"header":                  "yellow",
+			"header_background":       "black",
+			"keys":                    "aqua",
+			"status_text":             "black",
+			"status_background":       "yellow",
+			"hostname":                "black",
+			"table_header":            "black",
+			"table_header_background": "yellow",
+			"menu":                    "black",
+			"highlight":               "yellow",
+			"dimmed":                  "silver",
+			"error":                   "red",
+			"error_background":        "red",
+			"success":                 "lime",
+			"success_background":      "green",
+			"elevated":                "red",
+			"folder":                  "aqua",
+			"file":                    "white",
+			"executable":              "lime",
+			"selected":                "fuchsia",
+		},
+	},
+	{
+		Name:   "solarized",
+		Editor: "solarized-tc",
+		Colors: map[string]string{
+			"background":              "#002b36",
+			"contrast":                "#073642",
+			"more_contrast":           "#586e75",
+			"border":                  "#586e75",
+			"title":                   "#93a1a1",
+			"graphics":                "#586e75",
+			"text":                    "#839496",
+			"secondary_text":          "#b58900",
+			"tertiary_text":           "#859900",
+			"inverse_text":            "#002b36",
+			"contrast_secondary_text": "#2aa198",
+			"header":                  "#859900",
+			"header_background":       "#002b36",
+			"keys":                    "#268bd2",
+			"status_text":             "#eee8d5",
+			"status_background":       "#073642",
+			"hostname":                "#2aa198",
+			"table_header":            "#fdf6e3",
+			"table_header_background": "#268bd2",
+			"menu":                    "#073642",
+			"highlight":               "#b58900",
+			"dimmed":                  "#586e75",
+			"error":                   "#dc322f",
+			"error_background":        "#dc322f",
+			"success":                 "#859900",
+			"success_background":      "#859900",
+			"elevated":                "#cb4b16",
+			"folder":                  "#2aa198",
+			"file":                    "#839496",
+			"executable":              "#b58900",
+			"selected":                "#d33682",
+		},
+	},
+}
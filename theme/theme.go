@@ -0,0 +1,343 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package theme
+
+// ****************************************************************************
+// theme holds the colors of the screens. A theme is a JSON file of
+// ~/.gosh/themes giving a color to each role, the missing ones taking the
+// color of the dark theme. The built-in themes are written there at startup
+// when they are missing, to be copied and changed.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Theme struct {
+	Name   string            `json:"name"`
+	Editor string            `json:"editor"` // Colorscheme of the editor, like monokai
+	Colors map[string]string `json:"colors"` // Role -> name like yellow, or #RRGGBB
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	THEME_DEFAULT = "dark"
+	FILE_EXT      = ".json"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	// The colors of the current theme, by role
+	Background            tcell.Color // Of all the screens
+	Contrast              tcell.Color // Dialogs, input fields
+	MoreContrast          tcell.Color
+	Border                tcell.Color
+	Title                 tcell.Color
+	Graphics              tcell.Color
+	Text                  tcell.Color
+	SecondaryText         tcell.Color // Labels
+	TertiaryText          tcell.Color
+	InverseText           tcell.Color
+	ContrastSecondaryText tcell.Color
+	Header                tcell.Color // Title of the screen
+	HeaderBackground      tcell.Color
+	Keys                  tcell.Color // Function keys
+	StatusText            tcell.Color
+	StatusBackground      tcell.Color
+	Hostname              tcell.Color
+	TableHeader           tcell.Color
+	TableHeaderBackground tcell.Color
+	Menu                  tcell.Color // Background of the menus
+	Highlight             tcell.Color // Menu items, PIDs, tree roots
+	Dimmed                tcell.Color // Disabled menu items
+	Error                 tcell.Color
+	ErrorBackground       tcell.Color
+	Success               tcell.Color
+	SuccessBackground     tcell.Color
+	Elevated              tcell.Color // Background of the sudo badge
+	Folder                tcell.Color
+	File                  tcell.Color
+	Executable            tcell.Color
+	Selected              tcell.Color
+
+	roles = map[string]*tcell.Color{
+		"background":              &Background,
+		"contrast":                &Contrast,
+		"more_contrast":           &MoreContrast,
+		"border":                  &Border,
+		"title":                   &Title,
+		"graphics":                &Graphics,
+		"text":                    &Text,
+		"secondary_text":          &SecondaryText,
+		"tertiary_text":           &TertiaryText,
+		"inverse_text":            &InverseText,
+		"contrast_secondary_text": &ContrastSecondaryText,
+		"header":                  &Header,
+		"header_background":       &HeaderBackground,
+		"keys":                    &Keys,
+		"status_text":             &StatusText,
+		"status_background":       &StatusBackground,
+		"hostname":                &Hostname,
+		"table_header":            &TableHeader,
+		"table_header_background": &TableHeaderBackground,
+		"menu":                    &Menu,
+		"highlight":               &Highlight,
+		"dimmed":                  &Dimmed,
+		"error":                   &Error,
+		"error_background":        &ErrorBackground,
+		"success":                 &Success,
+		"success_background":      &SuccessBackground,
+		"elevated":                &Elevated,
+		"folder":                  &Folder,
+		"file":                    &File,
+		"executable":              &Executable,
+		"selected":                &Selected,
+	}
+
+	mutex      sync.Mutex
+	themes     = make(map[string]Theme)
+	current    string
+	overrides  map[string]string
+	generation int
+)
+
+// ****************************************************************************
+// init()
+// ****************************************************************************
+func init() {
+	for _, t := range builtins {
+		themes[t.Name] = t
+	}
+	Use(THEME_DEFAULT)
+}
+
+// ****************************************************************************
+// Load()
+// Load reads the themes of dir, writing the built-in ones which are missing,
+// and returns the problems found
+// ****************************************************************************
+func Load(dir string) []error {
+	var problems []error
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return []error{err}
+	}
+	for _, t := range builtins {
+		fName := filepath.Join(dir, t.Name+FILE_EXT)
+		if _, err := os.Stat(fName); errors.Is(err, fs.ErrNotExist) {
+			b, _ := json.MarshalIndent(t, "", " ")
+			if err := os.WriteFile(fName, b, 0644); err != nil {
+				problems = append(problems, err)
+			}
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+FILE_EXT))
+	if err != nil {
+		return append(problems, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, fName := range files {
+		t, err := readTheme(fName)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", filepath.Base(fName), err))
+			continue
+		}
+		themes[t.Name] = t
+	}
+	return problems
+}
+
+// ****************************************************************************
+// readTheme()
+// ****************************************************************************
+func readTheme(fName string) (Theme, error) {
+	var t Theme
+	b, err := os.ReadFile(fName)
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, err
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(fName), FILE_EXT)
+	}
+	for role, name := range t.Colors {
+		if _, ok := roles[role]; !ok {
+			return t, fmt.Errorf("unknown role %q", role)
+		}
+		if _, err := ParseColor(name); err != nil {
+			return t, fmt.Errorf("%s: %v", role, err)
+		}
+	}
+	return t, nil
+}
+
+// ****************************************************************************
+// Names()
+// Names returns the names of the themes, sorted
+// ****************************************************************************
+func Names() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return namesLocked()
+}
+
+// ****************************************************************************
+// Exists()
+// ****************************************************************************
+func Exists(name string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	_, ok := themes[name]
+	return ok
+}
+
+// ****************************************************************************
+// Current()
+// Current returns the name of the theme in use
+// ****************************************************************************
+func Current() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current
+}
+
+// ****************************************************************************
+// Editor()
+// Editor returns the colorscheme of the editor for the theme in use
+// ****************************************************************************
+func Editor() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if e := themes[current].Editor; e != "" {
+		return e
+	}
+	return themes[THEME_DEFAULT].Editor
+}
+
+// ****************************************************************************
+// Generation()
+// Generation changes each time the colors change, for the widgets styled
+// when they are drawn
+// ****************************************************************************
+func Generation() int {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return generation
+}
+
+// ****************************************************************************
+// SetOverrides()
+// SetOverrides sets the colors which replace the ones of any theme, by role,
+// applied by the next Use
+// ****************************************************************************
+func SetOverrides(colors map[string]string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	overrides = colors
+}
+
+// ****************************************************************************
+// Use()
+// Use makes name the current theme. What is already displayed is restyled by
+// the modules, each color being given again from its role.
+// ****************************************************************************
+func Use(name string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (%s)", name, strings.Join(namesLocked(), ", "))
+	}
+	base := themes[THEME_DEFAULT]
+	for role, c := range roles {
+		*c = tcell.GetColor(base.Colors[role])
+		if v, ok := t.Colors[role]; ok {
+			*c = tcell.GetColor(v)
+		}
+		if v, ok := overrides[role]; ok && v != "" {
+			if color, err := ParseColor(v); err == nil {
+				*c = color
+			}
+		}
+	}
+	current = name
+	generation++
+	setStyles()
+	return nil
+}
+
+// ****************************************************************************
+// ParseColor()
+// ParseColor returns the color named name (see tcell.ColorNames) or written
+// #RRGGBB
+// ****************************************************************************
+func ParseColor(name string) (tcell.Color, error) {
+	c := tcell.GetColor(name)
+	if c == tcell.ColorDefault && name != "default" {
+		return c, fmt.Errorf("unknown color %q (a name like lightgreen, or #RRGGBB)", name)
+	}
+	return c, nil
+}
+
+// ****************************************************************************
+// setStyles()
+// setStyles gives the colors to the widgets created from now on
+// ****************************************************************************
+func setStyles() {
+	tview.Styles = tview.Theme{
+		PrimitiveBackgroundColor:    Background,
+		ContrastBackgroundColor:     Contrast,
+		MoreContrastBackgroundColor: MoreContrast,
+		BorderColor:                 Border,
+		TitleColor:                  Title,
+		GraphicsColor:               Graphics,
+		PrimaryTextColor:            Text,
+		SecondaryTextColor:          SecondaryText,
+		TertiaryTextColor:           TertiaryText,
+		InverseTextColor:            InverseText,
+		ContrastSecondaryTextColor:  ContrastSecondaryText,
+	}
+}
+
+// ****************************************************************************
+// namesLocked()
+// ****************************************************************************
+func namesLocked() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,232 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package trash
+
+// ****************************************************************************
+// trash follows the freedesktop.org Trash specification : a trashed file is
+// kept in ~/.local/share/Trash/files, with a .trashinfo file of the same name
+// in ~/.local/share/Trash/info telling where it came from and when. The file
+// managers of the desktop share it.
+// trash only takes care of the .trashinfo files : the files themselves are
+// moved by the caller, which can copy them across file systems.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Item struct {
+	Name    string // In the files folder of the trash
+	Path    string // Where it was deleted from
+	Deleted time.Time
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+const (
+	INFO_HEADER = "[Trash Info]"
+	INFO_EXT    = ".trashinfo"
+	DATE_FORMAT = "2006-01-02T15:04:05"
+)
+
+// ****************************************************************************
+// Dir()
+// Dir returns the home trash, in $XDG_DATA_HOME or ~/.local/share
+// ****************************************************************************
+func Dir() string {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, _ := os.UserHomeDir()
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "Trash")
+}
+
+// ****************************************************************************
+// File() Item
+// File returns where the item is kept in the trash
+// ****************************************************************************
+func (i Item) File() string {
+	return filepath.Join(Dir(), "files", i.Name)
+}
+
+// ****************************************************************************
+// info() Item
+// ****************************************************************************
+func (i Item) info() string {
+	return filepath.Join(Dir(), "info", i.Name+INFO_EXT)
+}
+
+// ****************************************************************************
+// Reserve()
+// Reserve writes the .trashinfo of fName under a name free in the trash,
+// before the file is moved to the File of the item
+// ****************************************************************************
+func Reserve(fName string) (Item, error) {
+	path, err := filepath.Abs(fName)
+	if err != nil {
+		return Item{}, err
+	}
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(Dir(), dir), 0700); err != nil {
+			return Item{}, err
+		}
+	}
+	item := Item{Path: path, Deleted: time.Now()}
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	if ext == base {
+		ext = ""
+	}
+	for n := 1; ; n++ {
+		item.Name = base
+		if n > 1 {
+			item.Name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		if _, err := os.Lstat(item.File()); err == nil {
+			continue
+		}
+		// Creating the .trashinfo alone reserves the name
+		f, err := os.OpenFile(item.info(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		_, err = fmt.Fprintf(f, "%s\nPath=%s\nDeletionDate=%s\n", INFO_HEADER, escape(path), item.Deleted.Format(DATE_FORMAT))
+		if errClose := f.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			os.Remove(item.info())
+			return Item{}, err
+		}
+		return item, nil
+	}
+}
+
+// ****************************************************************************
+// Forget()
+// Forget removes the .trashinfo of an item restored, purged, or which could
+// not be moved into the trash
+// ****************************************************************************
+func Forget(item Item) error {
+	err := os.Remove(item.info())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ****************************************************************************
+// List()
+// List returns the items of the trash, the last deleted first, and the
+// .trashinfo files which can't be read
+// ****************************************************************************
+func List() ([]Item, []error) {
+	entries, err := os.ReadDir(filepath.Join(Dir(), "info"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+	var items []Item
+	var problems []error
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), INFO_EXT)
+		if !ok || e.IsDir() {
+			continue
+		}
+		item, err := read(name)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", e.Name(), err))
+			continue
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].Deleted.After(items[b].Deleted)
+	})
+	return items, problems
+}
+
+// ****************************************************************************
+// read()
+// ****************************************************************************
+func read(name string) (Item, error) {
+	item := Item{Name: name}
+	f, err := os.Open(item.info())
+	if err != nil {
+		return item, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	header := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			header = line == INFO_HEADER
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !header || !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if item.Path, err = url.PathUnescape(value); err != nil {
+				return item, err
+			}
+		case "DeletionDate":
+			item.Deleted, _ = time.ParseInLocation(DATE_FORMAT, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return item, err
+	}
+	if item.Path == "" {
+		return item, errors.New("no Path")
+	}
+	if _, err := os.Lstat(item.File()); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// ****************************************************************************
+// escape()
+// escape encodes a path like an URL, the slashes kept
+// ****************************************************************************
+func escape(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
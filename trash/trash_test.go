@@ -0,0 +1,202 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package trash
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ****************************************************************************
+// writeInfo()
+// writeInfo puts the .trashinfo name in the trash, with its file when kept
+// ****************************************************************************
+func writeInfo(t *testing.T, name string, content string, kept bool) {
+	t.Helper()
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(Dir(), dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	item := Item{Name: name}
+	if err := os.WriteFile(item.info(), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if kept {
+		if err := os.WriteFile(item.File(), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestReserve()
+// ****************************************************************************
+func TestReserve(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	src := t.TempDir()
+
+	// A file of the trash without .trashinfo takes its name as well
+	if err := os.MkdirAll(filepath.Join(Dir(), "files"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Item{Name: "orphan"}.File(), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fName string
+		want  string
+	}{
+		{"a.txt", "a.txt"},
+		{"a.txt", "a.2.txt"},
+		{"a.txt", "a.3.txt"},
+		{"arch.tar.gz", "arch.tar.gz"},
+		{"arch.tar.gz", "arch.tar.2.gz"},
+		{"noext", "noext"},
+		{"noext", "noext.2"},
+		{".bashrc", ".bashrc"},
+		{".bashrc", ".bashrc.2"},
+		{"orphan", "orphan.2"},
+	}
+	for _, test := range tests {
+		item, err := Reserve(filepath.Join(src, test.fName))
+		if err != nil {
+			t.Fatalf("%s: %v", test.fName, err)
+		}
+		if item.Name != test.want {
+			t.Errorf("%s: reserved %q, want %q", test.fName, item.Name, test.want)
+		}
+		if item.Path != filepath.Join(src, test.fName) {
+			t.Errorf("%s: path %q", test.fName, item.Path)
+		}
+		if _, err := os.Stat(item.info()); err != nil {
+			t.Errorf("%s: %v", test.fName, err)
+		}
+	}
+
+	// Forget frees the name, even twice
+	item := Item{Name: "a.2.txt"}
+	for i := 0; i < 2; i++ {
+		if err := Forget(item); err != nil {
+			t.Errorf("Forget: %v", err)
+		}
+	}
+	if item, _ := Reserve(filepath.Join(src, "a.txt")); item.Name != "a.2.txt" {
+		t.Errorf("after Forget: reserved %q", item.Name)
+	}
+}
+
+// ****************************************************************************
+// TestEscape()
+// ****************************************************************************
+func TestEscape(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tmp/plain", "/tmp/plain"},
+		{"/tmp/with space", "/tmp/with%20space"},
+		{"/tmp/100%", "/tmp/100%25"},
+		{"/tmp/a?b#c", "/tmp/a%3Fb%23c"},
+		{"/tmp/été", "/tmp/%C3%A9t%C3%A9"},
+	}
+	for _, test := range tests {
+		got := escape(test.path)
+		if got != test.want {
+			t.Errorf("escape(%q) = %q, want %q", test.path, got, test.want)
+		}
+		back, err := url.PathUnescape(got)
+		if err != nil || back != test.path {
+			t.Errorf("unescape(%q) = %q, %v", got, back, err)
+		}
+
+		// What Reserve writes, List reads back
+		item, err := Reserve(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(item.File(), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		read, err := read(item.Name)
+		if err != nil || read.Path != test.path {
+			t.Errorf("%s: read %q, %v", test.path, read.Path, err)
+		}
+	}
+}
+
+// ****************************************************************************
+// TestList()
+// ****************************************************************************
+func TestList(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if items, problems := List(); items != nil || problems != nil {
+		t.Fatalf("no trash: %v %v", items, problems)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		kept    bool
+		problem bool
+	}{
+		{"old", "[Trash Info]\nPath=/tmp/old\nDeletionDate=2023-01-01T10:00:00\n", true, false},
+		{"new", "[Trash Info]\nPath=/tmp/new\nDeletionDate=2023-06-01T10:00:00\n", true, false},
+		{"middle", "[Trash Info]\nDeletionDate=2023-03-01T10:00:00\nPath=/tmp/a%20b\n", true, false},
+		{"spaces", "  [Trash Info]  \n  Path=/tmp/spaces  \n\nDeletionDate=2022-01-01T10:00:00\n", true, false},
+		{"orphan", "[Trash Info]\nPath=/tmp/orphan\nDeletionDate=2023-01-01T10:00:00\n", false, true},
+		{"nopath", "[Trash Info]\nDeletionDate=2023-01-01T10:00:00\n", true, true},
+		{"noheader", "Path=/tmp/noheader\nDeletionDate=2023-01-01T10:00:00\n", true, true},
+		{"other", "[Other]\nPath=/tmp/other\n", true, true},
+		{"badescape", "[Trash Info]\nPath=/tmp/%zz\n", true, true},
+	}
+	var problems int
+	for _, test := range tests {
+		writeInfo(t, test.name, test.content, test.kept)
+		if test.problem {
+			problems++
+		}
+	}
+	// Not a .trashinfo
+	if err := os.WriteFile(filepath.Join(Dir(), "info", "README"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, errs := List()
+	var names, paths []string
+	for _, item := range items {
+		names = append(names, item.Name)
+		paths = append(paths, item.Path)
+	}
+	if want := []string{"new", "middle", "old", "spaces"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("items %v, want %v", names, want)
+	}
+	if want := []string{"/tmp/new", "/tmp/a b", "/tmp/old", "/tmp/spaces"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths %v, want %v", paths, want)
+	}
+	if len(errs) != problems {
+		t.Errorf("%d problems, want %d: %v", len(errs), problems, errs)
+	}
+	for _, err := range errs {
+		if !strings.Contains(err.Error(), INFO_EXT) {
+			t.Errorf("problem without its file: %v", err)
+		}
+	}
+}
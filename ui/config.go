@@ -0,0 +1,127 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package ui
+
+// ****************************************************************************
+// config is the schema of gosh.json, one section per module. The version
+// tells how to migrate the files written by an older gosh.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/conf"
+	"gosh/theme"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Config struct {
+	Version        int           `json:"version"`
+	StartupScreen  Mode          `json:"startup_screen"`
+	FormatDate     string        `json:"format_date"`
+	FormatTime     string        `json:"format_time"`
+	StatusDuration int           `json:"status_duration"` // Seconds a status message is shown
+	ToastDuration  int           `json:"toast_duration"`  // Seconds a notification is shown
+	SudoTimeout    int           `json:"sudo_timeout"`    // Minutes an elevation with sudo lasts
+	Theme          string        `json:"theme"`           // Name of a file of ~/.gosh/themes
+	Shell          ShellConfig   `json:"shell"`
+	Files          FilesConfig   `json:"files"`
+	SQLite3        SQLite3Config `json:"sqlite3"`
+	Colors         ColorsConfig  `json:"colors"`
+	Log            LogConfig     `json:"log"`
+}
+
+type ShellConfig struct {
+	Prompt          string `json:"prompt"`
+	SaveEnv         bool   `json:"save_env"`
+	HistorySize     int    `json:"history_size"`
+	ConsoleMaxLines int    `json:"console_max_lines"`
+	NotifyAfter     int    `json:"notify_after"` // Seconds, 0 for the default, < 0 never
+	NotifyHook      string `json:"notify_hook"`
+	SSHTimeout      int    `json:"ssh_timeout"` // Seconds
+}
+
+type FilesConfig struct {
+	ShowHidden        bool  `json:"show_hidden"`
+	DualPane          bool  `json:"dual_pane"`           // New Files screens show two panes
+	MaxPreview        int   `json:"max_preview"`         // Bytes of a text file previewed
+	HashThresholdSize int64 `json:"hash_threshold_size"` // Bytes beyond which a file is not previewed
+}
+
+type SQLite3Config struct {
+	Database string `json:"database"` // Used until another one is open
+}
+
+type ColorsConfig struct {
+	Folder     string `json:"folder"` // A name like lightgreen, or #RRGGBB, empty for the theme's one
+	File       string `json:"file"`
+	Executable string `json:"executable"`
+	Selected   string `json:"selected"`
+}
+
+type LogConfig struct {
+	Level    string `json:"level"`    // debug, info, warning or error
+	MaxSize  int    `json:"max_size"` // KB before gosh.log is rotated, 0 never
+	MaxFiles int    `json:"max_files"`
+}
+
+// ****************************************************************************
+// DefaultConfig()
+// DefaultConfig returns the settings of a new gosh.json
+// (Sorry, default time and date formats are the French way ;)
+// ****************************************************************************
+func DefaultConfig() Config {
+	return Config{
+		Version:        conf.CONFIG_VERSION,
+		StartupScreen:  ModeShell,
+		FormatDate:     "02/01/2006",
+		FormatTime:     "15:04:05",
+		StatusDuration: conf.STATUS_MESSAGE_DURATION,
+		ToastDuration:  conf.TOAST_DURATION,
+		SudoTimeout:    conf.SUDO_TIMEOUT,
+		Theme:          theme.THEME_DEFAULT,
+		Shell: ShellConfig{
+			Prompt:          conf.PROMPT_TEMPLATE,
+			HistorySize:     conf.HISTORY_MAX_SIZE,
+			ConsoleMaxLines: conf.CONSOLE_MAX_LINES,
+			NotifyAfter:     conf.NOTIFY_AFTER,
+			SSHTimeout:      conf.SSH_TIMEOUT,
+		},
+		Files: FilesConfig{
+			MaxPreview:        conf.FILE_MAX_PREVIEW,
+			HashThresholdSize: conf.HASH_THRESHOLD_SIZE,
+		},
+		SQLite3: SQLite3Config{
+			Database: ":memory:",
+		},
+		Log: LogConfig{
+			Level:    conf.LOG_LEVEL,
+			MaxSize:  conf.LOG_MAX_SIZE,
+			MaxFiles: conf.LOG_MAX_FILES,
+		},
+	}
+}
+
+// ****************************************************************************
+// Roles()
+// Roles returns the colors of c which replace the ones of the theme, by role
+// ****************************************************************************
+func (c ColorsConfig) Roles() map[string]string {
+	return map[string]string{
+		"folder":     c.Folder,
+		"file":       c.File,
+		"executable": c.Executable,
+		"selected":   c.Selected,
+	}
+}
@@ -0,0 +1,495 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package ui
+
+// ****************************************************************************
+// console keeps the text of the console as blocks, one for each command with
+// its output. The new lines are appended to the view, the whole text is only
+// rendered again when the blocks are folded, searched or trimmed.
+// All these functions run on the UI thread.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"fmt"
+	"gosh/conf"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type consoleLine struct {
+	text   string // With the color tags
+	stderr bool
+}
+
+type Block struct {
+	id        int
+	Cmd       string
+	lines     []consoleLine
+	dropped   int // Lines removed by the lines limit
+	RC        int
+	PID       int // Of the external command, 0 for a builtin
+	Runtime   float64
+	Done      bool
+	Collapsed bool
+}
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	blocks        []*Block
+	lastBlockID   int
+	consoleLines  int // Lines kept in all the blocks
+	selectedBlock = -1
+	search        *regexp.Regexp
+	searchCount   int // Matches rendered
+	searchIdx     int // Current match
+	reTags        = regexp.MustCompile(`\[([a-zA-Z0-9_,;: \-\."#]+)\[(\[*)\]|\[([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([a-zA-Z]+|#[0-9a-zA-Z]{6}|\-)?(:([bdilrsu]+|\-)?)?)?\]|\["[a-zA-Z0-9_,;: \-\.]*"\]`)
+)
+
+// ****************************************************************************
+// HeaderConsole()
+// HeaderConsole starts a new block for cmd
+// ****************************************************************************
+func HeaderConsole(cmd string) {
+	lastBlockID++
+	b := &Block{id: lastBlockID, Cmd: cmd}
+	blocks = append(blocks, b)
+	if selectedBlock >= 0 {
+		selectedBlock = -1
+		TxtConsole.Highlight()
+	}
+	TxtConsole.Write([]byte(renderHeader(b)))
+	TxtConsole.ScrollToEnd()
+}
+
+// ****************************************************************************
+// OutConsole()
+// OutConsole adds the lines of out to the last block
+// ****************************************************************************
+func OutConsole(out string) {
+	for _, line := range strings.Split(out, "\n") {
+		addLine(consoleLine{text: line})
+	}
+	TxtConsole.ScrollToEnd()
+}
+
+// ****************************************************************************
+// WriteConsole()
+// WriteConsole adds a line written by a command to the last block
+// ****************************************************************************
+func WriteConsole(line string, stderr bool) {
+	addLine(consoleLine{text: line, stderr: stderr})
+	TxtConsole.ScrollToEnd()
+}
+
+// ****************************************************************************
+// EndBlock()
+// EndBlock records the result of the command of the last block, shown after
+// its output but not part of it
+// ****************************************************************************
+func EndBlock(rc int, pid int, runtime float64) {
+	if len(blocks) == 0 {
+		return
+	}
+	b := blocks[len(blocks)-1]
+	b.RC = rc
+	b.PID = pid
+	b.Runtime = runtime
+	b.Done = true
+	if !b.Collapsed {
+		TxtConsole.Write([]byte(renderFooter(b)))
+		TxtConsole.ScrollToEnd()
+	}
+}
+
+// ****************************************************************************
+// ClearConsole()
+// ****************************************************************************
+func ClearConsole() {
+	blocks = nil
+	consoleLines = 0
+	selectedBlock = -1
+	TxtConsole.Highlight()
+	TxtConsole.SetText("")
+}
+
+// ****************************************************************************
+// addLine()
+// ****************************************************************************
+func addLine(l consoleLine) {
+	if len(blocks) == 0 || blocks[len(blocks)-1].Done {
+		// The output of a job or of gosh itself, out of any command
+		lastBlockID++
+		blocks = append(blocks, &Block{id: lastBlockID})
+	}
+	b := blocks[len(blocks)-1]
+	b.lines = append(b.lines, l)
+	consoleLines++
+	max := MyConfig.Shell.ConsoleMaxLines
+	if max <= 0 {
+		max = conf.CONSOLE_MAX_LINES
+	}
+	// Some slack, not to render everything for each new line
+	if consoleLines > max+max/10 {
+		trimConsole(max)
+		refreshConsole()
+		return
+	}
+	if b.Collapsed {
+		refreshConsole()
+		return
+	}
+	TxtConsole.Write([]byte(renderLine(l)))
+}
+
+// ****************************************************************************
+// trimConsole()
+// trimConsole removes the oldest lines to keep max lines
+// ****************************************************************************
+func trimConsole(max int) {
+	for consoleLines > max && len(blocks) > 0 {
+		b := blocks[0]
+		n := consoleLines - max
+		if n >= len(b.lines) && len(blocks) > 1 {
+			consoleLines -= len(b.lines)
+			blocks = blocks[1:]
+			if selectedBlock >= 0 {
+				selectedBlock--
+			}
+			continue
+		}
+		if n > len(b.lines) {
+			n = len(b.lines)
+		}
+		b.lines = b.lines[n:]
+		b.dropped += n
+		consoleLines -= n
+	}
+}
+
+// ****************************************************************************
+// refreshConsole()
+// refreshConsole renders all the blocks again
+// ****************************************************************************
+func refreshConsole() {
+	var sb strings.Builder
+	searchCount = 0
+	for _, b := range blocks {
+		sb.WriteString(renderHeader(b))
+		if b.Collapsed {
+			continue
+		}
+		if b.dropped > 0 {
+			sb.WriteString(fmt.Sprintf("[gray]… %d older lines dropped[-]\n", b.dropped))
+		}
+		for _, l := range b.lines {
+			sb.WriteString(renderLine(l))
+		}
+		sb.WriteString(renderFooter(b))
+	}
+	TxtConsole.SetText(sb.String())
+	switch {
+	case selectedBlock >= 0:
+		TxtConsole.Highlight(fmt.Sprintf("b%d", blocks[selectedBlock].id)).ScrollToHighlight()
+	case search != nil && searchCount > 0:
+		TxtConsole.Highlight(fmt.Sprintf("m%d", searchIdx)).ScrollToHighlight()
+	default:
+		TxtConsole.ScrollToEnd()
+	}
+}
+
+// ****************************************************************************
+// renderHeader()
+// ****************************************************************************
+func renderHeader(b *Block) string {
+	if b.Cmd == "" {
+		return ""
+	}
+	header := fmt.Sprintf("\n[\"b%d\"][red]⯈ %s:[-][\"\"]", b.id, tview.Escape(b.Cmd))
+	if b.Collapsed {
+		header += fmt.Sprintf(" [gray]▸ %d lines[-]", len(b.lines))
+	}
+	return header + "\n"
+}
+
+// ****************************************************************************
+// renderFooter()
+// ****************************************************************************
+func renderFooter(b *Block) string {
+	if !b.Done || b.PID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n[yellow]Runtime for PID %d is %f seconds. Return Code: %d[-]\n", b.PID, b.Runtime, b.RC)
+}
+
+// ****************************************************************************
+// renderLine()
+// renderLine returns the line for the view, the matches of the search being
+// highlighted without the colors of the line
+// ****************************************************************************
+func renderLine(l consoleLine) string {
+	text := l.text
+	if search != nil {
+		plain := stripTags(l.text)
+		if matches := search.FindAllStringIndex(plain, -1); matches != nil {
+			var sb strings.Builder
+			last := 0
+			for _, m := range matches {
+				sb.WriteString(tview.Escape(plain[last:m[0]]))
+				sb.WriteString(fmt.Sprintf("[\"m%d\"][black:yellow]%s[-:-][\"\"]", searchCount, tview.Escape(plain[m[0]:m[1]])))
+				searchCount++
+				last = m[1]
+			}
+			sb.WriteString(tview.Escape(plain[last:]))
+			text = sb.String()
+		}
+	}
+	if l.stderr {
+		return "[yellow]" + text + "[-]\n"
+	}
+	return text + "\n"
+}
+
+// ****************************************************************************
+// stripTags()
+// stripTags returns the text of a line without its color tags
+// ****************************************************************************
+func stripTags(text string) string {
+	return reTags.ReplaceAllStringFunc(text, func(tag string) string {
+		if m := reTags.FindStringSubmatch(tag); m[1] != "" {
+			// An escaped [tag[]
+			return "[" + m[1] + m[2] + "]"
+		}
+		return ""
+	})
+}
+
+// ****************************************************************************
+// CurrentBlock()
+// CurrentBlock returns the block selected in the console, or the last one of
+// a command
+// ****************************************************************************
+func CurrentBlock() *Block {
+	if selectedBlock >= 0 {
+		return blocks[selectedBlock]
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].Cmd != "" {
+			return blocks[i]
+		}
+	}
+	return blocks[len(blocks)-1]
+}
+
+// ****************************************************************************
+// Text()
+// Text returns the output of the block, without the colors
+// ****************************************************************************
+func (b *Block) Text() string {
+	var sb strings.Builder
+	for _, l := range b.lines {
+		sb.WriteString(stripTags(l.text) + "\n")
+	}
+	return sb.String()
+}
+
+// ****************************************************************************
+// SelectBlock()
+// SelectBlock moves the selection to the previous (-1) or next (1) block
+// ****************************************************************************
+func SelectBlock(delta int) {
+	var candidates []int
+	for i, b := range blocks {
+		if b.Cmd != "" {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	pos := len(candidates)
+	for i, c := range candidates {
+		if c == selectedBlock {
+			pos = i
+		}
+	}
+	pos += delta
+	if pos < 0 {
+		pos = 0
+	}
+	if pos >= len(candidates) {
+		// After the last block, back to the end of the console
+		selectedBlock = -1
+		TxtConsole.Highlight().ScrollToEnd()
+		return
+	}
+	selectedBlock = candidates[pos]
+	TxtConsole.Highlight(fmt.Sprintf("b%d", blocks[selectedBlock].id)).ScrollToHighlight()
+}
+
+// ****************************************************************************
+// ToggleBlock()
+// ToggleBlock collapses or expands the current block
+// ****************************************************************************
+func ToggleBlock() {
+	if b := CurrentBlock(); b != nil && b.Cmd != "" {
+		b.Collapsed = !b.Collapsed
+		refreshConsole()
+	}
+}
+
+// ****************************************************************************
+// CollapseAllBlocks()
+// ****************************************************************************
+func CollapseAllBlocks(collapsed bool) {
+	for _, b := range blocks {
+		b.Collapsed = collapsed && b.Cmd != ""
+	}
+	refreshConsole()
+}
+
+// ****************************************************************************
+// CopyBlock()
+// CopyBlock copies the output of the current block to the clipboard
+// ****************************************************************************
+func CopyBlock() {
+	b := CurrentBlock()
+	if b == nil {
+		return
+	}
+	if err := clipboard.WriteAll(b.Text()); err != nil {
+		SetStatus(fmt.Sprintf("Can't copy to the clipboard : %v", err))
+		return
+	}
+	SetStatus(fmt.Sprintf("%d lines copied to the clipboard", len(b.lines)))
+}
+
+// ****************************************************************************
+// SaveBlock()
+// SaveBlock writes the output of the current block to the file fName
+// ****************************************************************************
+func SaveBlock(fName string) error {
+	b := CurrentBlock()
+	if b == nil {
+		return nil
+	}
+	return os.WriteFile(fName, []byte(b.Text()), 0644)
+}
+
+// ****************************************************************************
+// SearchConsole()
+// SearchConsole highlights the matches of text in the console, ignoring the
+// case, and returns their number. The folded blocks are not searched.
+// ****************************************************************************
+func SearchConsole(text string) int {
+	search = nil
+	if text != "" {
+		search = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+	}
+	searchIdx = 0
+	selectedBlock = -1
+	refreshConsole()
+	if search == nil {
+		TxtConsole.Highlight().ScrollToEnd()
+	}
+	return searchCount
+}
+
+// ****************************************************************************
+// NextMatch()
+// NextMatch goes to the next (1) or previous (-1) match of the search
+// ****************************************************************************
+func NextMatch(delta int) {
+	if search == nil || searchCount == 0 {
+		return
+	}
+	searchIdx = (searchIdx + delta + searchCount) % searchCount
+	TxtConsole.Highlight(fmt.Sprintf("m%d", searchIdx)).ScrollToHighlight()
+	SetStatus(fmt.Sprintf("Match %d of %d", searchIdx+1, searchCount))
+}
+
+// ****************************************************************************
+// ShowConsoleSearch()
+// ShowConsoleSearch opens the search field of the console, the matches are
+// highlighted while typing. Enter keeps them, n and N go to the next and
+// previous one in the console, Esc clears them.
+// ****************************************************************************
+func ShowConsoleSearch() {
+	input := tview.NewInputField().SetLabel("Find : ")
+	if search != nil {
+		input.SetText(strings.TrimPrefix(search.String(), "(?i)"))
+	}
+	input.SetChangedFunc(func(text string) {
+		n := SearchConsole(text)
+		if text != "" {
+			SetStatus(fmt.Sprintf("%d matches", n))
+		}
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyDown:
+			NextMatch(1)
+			return nil
+		case tcell.KeyUp:
+			NextMatch(-1)
+			return nil
+		case tcell.KeyEnter:
+			PgsApp.RemovePage("dlgSearch")
+			App.SetFocus(TxtConsole)
+			return nil
+		case tcell.KeyEsc:
+			PgsApp.RemovePage("dlgSearch")
+			SearchConsole("")
+			App.SetFocus(TxtPrompt)
+			return nil
+		}
+		return event
+	})
+	input.SetBorder(true).SetTitle(" Search the console ")
+
+	popup := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 50, 0, true).
+		AddItem(nil, 0, 1, false)
+	PgsApp.AddPage("dlgSearch", popup, true, true)
+	App.SetFocus(input)
+}
+
+// ****************************************************************************
+// ClearConsoleSearch()
+// ClearConsoleSearch removes the highlighting of the search, it tells if
+// there was one
+// ****************************************************************************
+func ClearConsoleSearch() bool {
+	if search == nil {
+		return false
+	}
+	SearchConsole("")
+	return true
+}
@@ -0,0 +1,235 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package ui
+
+// ****************************************************************************
+// Terminal is a widget displaying a VT100/xterm emulator and sending the
+// keystrokes to the program running in it
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/hinshun/vt10x"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type Terminal struct {
+	*tview.Box
+	vt     vt10x.Terminal
+	input  func([]byte)
+	resize func(cols int, rows int)
+}
+
+// ****************************************************************************
+// CONSTANTS
+// ****************************************************************************
+// Attributes of the glyphs, as defined by vt10x
+const (
+	glyphReverse = 1 << iota
+	glyphUnderline
+	glyphBold
+	glyphGfx
+	glyphItalic
+	glyphBlink
+)
+
+// ****************************************************************************
+// NewTerminal()
+// ****************************************************************************
+func NewTerminal() *Terminal {
+	return &Terminal{Box: tview.NewBox()}
+}
+
+// ****************************************************************************
+// SetTerminal()
+// SetTerminal attaches an emulator to the widget, input receives the bytes
+// typed by the user and resize is called when the widget changes its size
+// ****************************************************************************
+func (t *Terminal) SetTerminal(vt vt10x.Terminal, input func([]byte), resize func(cols int, rows int)) *Terminal {
+	t.vt = vt
+	t.input = input
+	t.resize = resize
+	return t
+}
+
+// ****************************************************************************
+// Draw()
+// ****************************************************************************
+func (t *Terminal) Draw(screen tcell.Screen) {
+	t.DrawForSubclass(screen, t)
+	if t.vt == nil {
+		return
+	}
+	x, y, width, height := t.GetInnerRect()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if cols, rows := t.vt.Size(); cols != width || rows != height {
+		t.vt.Resize(width, height)
+		if t.resize != nil {
+			t.resize(width, height)
+		}
+	}
+
+	t.vt.Lock()
+	defer t.vt.Unlock()
+	if title := t.vt.Title(); title != "" {
+		t.SetTitle(" " + title + " ")
+	}
+	cols, rows := t.vt.Size()
+	for row := 0; row < rows && row < height; row++ {
+		for col := 0; col < cols && col < width; col++ {
+			glyph := t.vt.Cell(col, row)
+			ch := glyph.Char
+			if ch == 0 {
+				ch = ' '
+			}
+			screen.SetContent(x+col, y+row, ch, nil, glyphStyle(glyph))
+		}
+	}
+	if t.HasFocus() && t.vt.CursorVisible() {
+		cursor := t.vt.Cursor()
+		if cursor.X < width && cursor.Y < height {
+			screen.ShowCursor(x+cursor.X, y+cursor.Y)
+		}
+	}
+}
+
+// ****************************************************************************
+// glyphStyle()
+// ****************************************************************************
+func glyphStyle(glyph vt10x.Glyph) tcell.Style {
+	style := tcell.StyleDefault.
+		Foreground(vtColor(glyph.FG)).
+		Background(vtColor(glyph.BG))
+	if glyph.Mode&glyphReverse != 0 {
+		style = style.Reverse(true)
+	}
+	if glyph.Mode&glyphUnderline != 0 {
+		style = style.Underline(true)
+	}
+	if glyph.Mode&glyphBold != 0 {
+		style = style.Bold(true)
+	}
+	if glyph.Mode&glyphItalic != 0 {
+		style = style.Italic(true)
+	}
+	if glyph.Mode&glyphBlink != 0 {
+		style = style.Blink(true)
+	}
+	return style
+}
+
+// ****************************************************************************
+// vtColor()
+// ****************************************************************************
+func vtColor(c vt10x.Color) tcell.Color {
+	switch {
+	case c < 256:
+		return tcell.PaletteColor(int(c))
+	case c < 1<<24:
+		return tcell.NewHexColor(int32(c))
+	}
+	return tcell.ColorDefault
+}
+
+// ****************************************************************************
+// InputHandler()
+// ****************************************************************************
+func (t *Terminal) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return t.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if t.input == nil || t.vt == nil {
+			return
+		}
+		if b := keyBytes(event, t.vt.Mode()&vt10x.ModeAppCursor != 0); b != nil {
+			t.input(b)
+		}
+	})
+}
+
+// ****************************************************************************
+// keyBytes()
+// keyBytes returns the sequence sent by an xterm for a key
+// ****************************************************************************
+func keyBytes(event *tcell.EventKey, appCursor bool) []byte {
+	cursor := func(c string) []byte {
+		if appCursor {
+			return []byte("\x1bO" + c)
+		}
+		return []byte("\x1b[" + c)
+	}
+	var b []byte
+	switch event.Key() {
+	case tcell.KeyRune:
+		b = []byte(string(event.Rune()))
+	case tcell.KeyUp:
+		b = cursor("A")
+	case tcell.KeyDown:
+		b = cursor("B")
+	case tcell.KeyRight:
+		b = cursor("C")
+	case tcell.KeyLeft:
+		b = cursor("D")
+	case tcell.KeyHome:
+		b = cursor("H")
+	case tcell.KeyEnd:
+		b = cursor("F")
+	case tcell.KeyBacktab:
+		b = []byte("\x1b[Z")
+	case tcell.KeyInsert:
+		b = []byte("\x1b[2~")
+	case tcell.KeyDelete:
+		b = []byte("\x1b[3~")
+	case tcell.KeyPgUp:
+		b = []byte("\x1b[5~")
+	case tcell.KeyPgDn:
+		b = []byte("\x1b[6~")
+	case tcell.KeyF1:
+		b = []byte("\x1bOP")
+	case tcell.KeyF2:
+		b = []byte("\x1bOQ")
+	case tcell.KeyF3:
+		b = []byte("\x1bOR")
+	case tcell.KeyF4:
+		b = []byte("\x1bOS")
+	case tcell.KeyF5:
+		b = []byte("\x1b[15~")
+	case tcell.KeyF6:
+		b = []byte("\x1b[17~")
+	case tcell.KeyF7:
+		b = []byte("\x1b[18~")
+	case tcell.KeyF8:
+		b = []byte("\x1b[19~")
+	case tcell.KeyF9:
+		b = []byte("\x1b[20~")
+	case tcell.KeyF10:
+		b = []byte("\x1b[21~")
+	case tcell.KeyF11:
+		b = []byte("\x1b[23~")
+	case tcell.KeyF12:
+		b = []byte("\x1b[24~")
+	default:
+		// The control keys are their ASCII code
+		if event.Key() <= tcell.KeyDEL {
+			b = []byte{byte(event.Key())}
+		}
+	}
+	if b != nil && event.Modifiers()&tcell.ModAlt != 0 {
+		b = append([]byte{0x1b}, b...)
+	}
+	return b
+}
@@ -0,0 +1,114 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package ui
+
+// ****************************************************************************
+// theme restyles the widgets already created when the theme changes, the new
+// ones taking its colors from tview.Styles. The cells of the tables and the
+// nodes of the trees are rendered again by their modules, as only they know
+// the role of each color : two roles may share a color in a theme and not in
+// another one.
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/theme"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// TYPES
+// ****************************************************************************
+type boxed interface {
+	SetBackgroundColor(color tcell.Color) *tview.Box
+	SetBorderColor(color tcell.Color) *tview.Box
+	SetTitleColor(color tcell.Color) *tview.Box
+}
+
+// ****************************************************************************
+// ApplyTheme()
+// ApplyTheme gives the colors of the current theme to all the layouts
+// ****************************************************************************
+func ApplyTheme() {
+	for _, flx := range []*tview.Flex{FlxShell, FlxHelp, FlxFiles, FlxProcess, FlxEditor, FlxSQL, FlxHexEdit, FlxAudit, FlxSettings} {
+		restyle(flx)
+	}
+	// The pages of the console and the hidden pane are not in the layouts
+	restyle(flxFilesPane2)
+	restyle(TxtConsole)
+	restyle(TrmConsole)
+	DlgQuit.SetBackgroundColor(theme.Contrast).
+		SetTextColor(theme.Text).
+		SetButtonBackgroundColor(theme.Background).
+		SetButtonTextColor(theme.Text)
+	setLabelColors()
+}
+
+// ****************************************************************************
+// setLabelColors()
+// setLabelColors colors the header, the keys and the status bar
+// ****************************************************************************
+func setLabelColors() {
+	LblKeys.SetBackgroundColor(theme.Background)
+	LblKeys.SetTextColor(theme.Keys)
+	for _, lbl := range []*tview.TextView{lblDate, lblTime} {
+		lbl.SetBackgroundColor(theme.HeaderBackground)
+		lbl.SetTextColor(theme.Text)
+	}
+	lblTitle.SetBackgroundColor(theme.HeaderBackground)
+	lblTitle.SetTextColor(theme.Header)
+	for _, lbl := range []*tview.TextView{lblStatus, LblScreen, LblPID, LblRC, LblJobs, LblHourglass} {
+		lbl.SetBackgroundColor(theme.StatusBackground)
+		lbl.SetTextColor(theme.StatusText)
+	}
+	LblElevated.SetBackgroundColor(theme.Elevated)
+	LblElevated.SetTextColor(theme.Text)
+	LblHostname.SetBackgroundColor(theme.StatusBackground)
+	LblHostname.SetTextColor(theme.Hostname)
+}
+
+// ****************************************************************************
+// restyle()
+// restyle colors p and its items
+// ****************************************************************************
+func restyle(p tview.Primitive) {
+	if b, ok := p.(boxed); ok {
+		b.SetBackgroundColor(theme.Background)
+		b.SetBorderColor(theme.Border)
+		b.SetTitleColor(theme.Title)
+	}
+	switch w := p.(type) {
+	case *tview.Flex:
+		for i := 0; i < w.GetItemCount(); i++ {
+			restyle(w.GetItem(i))
+		}
+	case *tview.TextView:
+		w.SetTextColor(theme.Text)
+	case *tview.TextArea:
+		w.SetTextStyle(tcell.StyleDefault.Background(theme.Background).Foreground(theme.Text))
+		w.SetPlaceholderStyle(tcell.StyleDefault.Background(theme.Background).Foreground(theme.TertiaryText))
+		w.SetSelectedStyle(tcell.StyleDefault.Background(theme.Text).Foreground(theme.Background))
+	case *tview.Table:
+		w.SetBordersColor(theme.Graphics)
+	case *tview.TreeView:
+		w.SetGraphicsColor(theme.Graphics)
+	case *tview.Form:
+		w.SetLabelColor(theme.SecondaryText).
+			SetFieldBackgroundColor(theme.Contrast).
+			SetFieldTextColor(theme.Text).
+			SetButtonStyle(tcell.StyleDefault.Background(theme.Contrast).Foreground(theme.Text)).
+			SetButtonActivatedStyle(tcell.StyleDefault.Background(theme.Text).Foreground(theme.Contrast))
+	}
+}
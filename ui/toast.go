@@ -0,0 +1,94 @@
+// ****************************************************************************
+//
+//	 _____ _____ _____ _____
+//	|   __|     |   __|  |  |
+//	|  |  |  |  |__   |     |
+//	|_____|_____|_____|__|__|
+//
+// ****************************************************************************
+// G O S H   -   Copyright © JPL 2023
+// ****************************************************************************
+package ui
+
+// ****************************************************************************
+// toast displays short notifications in the corner of any screen, without
+// taking the focus
+// ****************************************************************************
+
+// ****************************************************************************
+// IMPORTS
+// ****************************************************************************
+import (
+	"gosh/conf"
+	"gosh/theme"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ****************************************************************************
+// GLOBALS
+// ****************************************************************************
+var (
+	screen  tcell.Screen // Known after the first draw
+	toastID int
+)
+
+// ****************************************************************************
+// ShowToast()
+// ShowToast displays text in the top right corner for a few seconds, called
+// on the UI thread
+// ****************************************************************************
+func ShowToast(title string, text string, failed bool) {
+	txt := tview.NewTextView().SetText(text).SetTextAlign(tview.AlignCenter)
+	txt.SetBorder(true).SetTitle(" " + title + " ")
+	if failed {
+		txt.SetBackgroundColor(theme.ErrorBackground)
+	} else {
+		txt.SetBackgroundColor(theme.SuccessBackground)
+	}
+	txt.SetTextColor(theme.Text)
+	width := tview.TaggedStringWidth(text) + 4
+	if w := tview.TaggedStringWidth(title) + 6; w > width {
+		width = w
+	}
+	popup := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 1, 0, false).
+			AddItem(txt, 3, 0, false).
+			AddItem(nil, 0, 1, false), width, 0, false).
+		AddItem(nil, 1, 0, false)
+
+	// The toast must not take the focus of the screen
+	focus := App.GetFocus()
+	PgsApp.AddPage("toast", popup, true, true)
+	App.SetFocus(focus)
+	toastID++
+	id := toastID
+	seconds := MyConfig.ToastDuration
+	if seconds <= 0 {
+		seconds = conf.TOAST_DURATION
+	}
+	time.AfterFunc(time.Duration(seconds)*time.Second, func() {
+		App.QueueUpdateDraw(func() {
+			// A newer toast stays
+			if id == toastID {
+				focus := App.GetFocus()
+				PgsApp.RemovePage("toast")
+				App.SetFocus(focus)
+			}
+		})
+	})
+}
+
+// ****************************************************************************
+// Bell()
+// Bell rings the bell of the terminal
+// ****************************************************************************
+func Bell() {
+	if screen != nil {
+		screen.Beep()
+	}
+}
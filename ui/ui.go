@@ -18,12 +18,18 @@ import (
 	"bytes"
 	"fmt"
 	"gosh/conf"
+	"gosh/keymap"
+	"gosh/logger"
+	"gosh/theme"
 	"gosh/utils"
+	"io"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/hinshun/vt10x"
 	"github.com/pgavlin/femto"
 	"github.com/rivo/tview"
 )
@@ -45,12 +51,6 @@ type MyScreen struct {
 	Param any
 }
 
-type Config struct {
-	StartupScreen Mode   `json:"startup_screen"`
-	FormatDate    string `json:"format_date"`
-	FormatTime    string `json:"format_time"`
-}
-
 // ****************************************************************************
 // CONSTANTS
 // ****************************************************************************
@@ -63,6 +63,8 @@ const (
 	ModeProcess
 	ModeNetwork
 	ModeSQLite3
+	ModeAudit
+	ModeSettings
 )
 
 // ****************************************************************************
@@ -84,8 +86,12 @@ var (
 	FlxEditor      *tview.Flex
 	FlxSQL         *tview.Flex
 	FlxHexEdit     *tview.Flex
+	FlxAudit       *tview.Flex
+	FlxSettings    *tview.Flex
 	TxtPrompt      *tview.TextArea
 	TxtConsole     *tview.TextView
+	TrmConsole     *Terminal
+	PgsConsole     *tview.Pages
 	TxtFileInfo    *tview.TextView
 	TxtProcInfo    *tview.TextView
 	TxtHelp        *tview.TextView
@@ -95,12 +101,17 @@ var (
 	LblScreen      *tview.TextView
 	LblPID         *tview.TextView
 	LblRC          *tview.TextView
+	LblJobs        *tview.TextView
 	LblHourglass   *tview.TextView
+	LblElevated    *tview.TextView
 	PgsApp         *tview.Pages
 	DlgQuit        *tview.Modal
 	TblFiles       *tview.Table
+	TblFiles2      *tview.Table // Right pane of the dual-pane layout
+	TblFilesActive *tview.Table // The files pane in use, showing conf.Cwd
 	TblProcess     *tview.Table
 	TxtPath        *tview.TextView
+	TxtPath2       *tview.TextView
 	TxtProcess     *tview.TextView
 	FrmFileInfo    *tview.TextView
 	TblProcUsers   *tview.Table
@@ -116,10 +127,22 @@ var (
 	TrvSQLDatabase *tview.TreeView
 	TxtHexName     *tview.TextView
 	TblHexEdit     *tview.Table
+	TblAudit       *tview.Table
+	TblTrash       *tview.Table // Shown over the File Manager
+	FrmSettings    *tview.Form
+	TxtSettings    *tview.TextView
 	CmdOutput      string
 	CmdOutputOld   string
 	ScanCmd        *bufio.Scanner
 	MyConfig       Config
+	statusBars     []*tview.Flex
+	headers        []*tview.Flex
+	OnShowScreen   Fn // Called each time a screen is displayed
+	flxFilesPanes  *tview.Flex
+	flxFilesPane1  *tview.Flex
+	flxFilesPane2  *tview.Flex
+	flxFilesInfo   *tview.Flex
+	filesDual      bool
 )
 
 // ****************************************************************************
@@ -145,11 +168,24 @@ func (m *Mode) UnmarshalText(b []byte) error {
 		*m = ModeNetwork
 	case str == "ModeSQLite3":
 		*m = ModeSQLite3
+	case str == "ModeAudit":
+		*m = ModeAudit
+	case str == "ModeSettings":
+		*m = ModeSettings
+	default:
+		return fmt.Errorf("unknown screen %q", str)
 	}
 
 	return nil
 }
 
+// ****************************************************************************
+// MarshalText() Mode
+// ****************************************************************************
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
 // ****************************************************************************
 // String() Mode
 // ****************************************************************************
@@ -172,6 +208,10 @@ func (m Mode) String() string {
 		return "ModeNetwork"
 	case ModeSQLite3:
 		return "ModeSQLite3"
+	case ModeAudit:
+		return "ModeAudit"
+	case ModeSettings:
+		return "ModeSettings"
 	}
 	return "?"
 }
@@ -191,46 +231,38 @@ func SetUI(fQuit Fn, hostname string) {
 
 	LblKeys = tview.NewTextView()
 	LblKeys.SetBorder(false)
-	LblKeys.SetBackgroundColor(tcell.ColorBlack)
-	LblKeys.SetTextColor(tcell.ColorLightBlue)
 
 	lblTitle = tview.NewTextView()
 	lblTitle.SetBorder(false)
-	lblTitle.SetBackgroundColor(tcell.ColorBlack)
-	lblTitle.SetTextColor(tcell.ColorGreen)
-	lblTitle.SetBorderColor(tcell.ColorDarkGreen)
 	lblTitle.SetTextAlign(tview.AlignCenter)
 
 	lblStatus = tview.NewTextView()
 	lblStatus.SetBorder(false)
-	lblStatus.SetBackgroundColor(tcell.ColorDarkGreen)
-	lblStatus.SetTextColor(tcell.ColorWheat)
 
 	LblScreen = tview.NewTextView()
 	LblScreen.SetBorder(false)
-	LblScreen.SetBackgroundColor(tcell.ColorDarkGreen)
-	LblScreen.SetTextColor(tcell.ColorWheat)
 
 	LblPID = tview.NewTextView()
 	LblPID.SetBorder(false)
-	LblPID.SetBackgroundColor(tcell.ColorDarkGreen)
-	LblPID.SetTextColor(tcell.ColorWheat)
 
 	LblRC = tview.NewTextView()
 	LblRC.SetDynamicColors(true)
 	LblRC.SetBorder(false)
-	LblRC.SetBackgroundColor(tcell.ColorDarkGreen)
-	LblRC.SetTextColor(tcell.ColorWheat)
+
+	LblJobs = tview.NewTextView()
+	LblJobs.SetBorder(false)
 
 	LblHourglass = tview.NewTextView()
 	LblHourglass.SetBorder(false)
-	LblHourglass.SetBackgroundColor(tcell.ColorDarkGreen)
-	LblHourglass.SetTextColor(tcell.ColorWheat)
+
+	LblElevated = tview.NewTextView()
+	LblElevated.SetBorder(false)
+	LblElevated.SetTextAlign(tview.AlignCenter)
 
 	LblHostname = tview.NewTextView()
 	LblHostname.SetBorder(false)
-	LblHostname.SetBackgroundColor(tcell.ColorDarkGreen)
-	LblHostname.SetTextColor(tcell.ColorBlack)
+	LblHostname.SetDynamicColors(true)
+	setLabelColors()
 
 	TxtPrompt = tview.NewTextArea().SetPlaceholder("Command to run")
 	TxtPrompt.SetBorder(false)
@@ -242,6 +274,14 @@ func SetUI(fQuit Fn, hostname string) {
 	TxtConsole = tview.NewTextView().Clear()
 	TxtConsole.SetBorder(true)
 	TxtConsole.SetDynamicColors(true)
+	TxtConsole.SetRegions(true)
+
+	TrmConsole = NewTerminal()
+	TrmConsole.SetBorder(true)
+
+	PgsConsole = tview.NewPages()
+	PgsConsole.AddPage("console", TxtConsole, true, true)
+	PgsConsole.AddPage("terminal", TrmConsole, true, false)
 
 	FrmFileInfo = tview.NewTextView()
 	FrmFileInfo.SetBorder(true)
@@ -268,6 +308,15 @@ func SetUI(fQuit Fn, hostname string) {
 	TxtPath.Clear()
 	TxtPath.SetBorder(true)
 
+	TblFiles2 = tview.NewTable()
+	TblFiles2.SetBorder(true)
+	TblFiles2.SetSelectable(true, false)
+
+	TxtPath2 = tview.NewTextView()
+	TxtPath2.Clear()
+	TxtPath2.SetBorder(true)
+	TblFilesActive = TblFiles
+
 	TblProcUsers = tview.NewTable()
 	TblProcUsers.SetBorder(true)
 	TblProcUsers.SetTitle("Users")
@@ -328,15 +377,40 @@ func SetUI(fQuit Fn, hostname string) {
 	TblHexEdit.SetSelectable(true, true)
 	TblHexEdit.SetTitle("Hexa View")
 
+	TblAudit = tview.NewTable()
+	TblAudit.SetBorder(true)
+	TblAudit.SetSelectable(true, false)
+	TblAudit.SetFixed(1, 0)
+	TblAudit.SetTitle("Audit Log")
+
+	TblTrash = tview.NewTable()
+	TblTrash.SetBorder(true)
+	TblTrash.SetSelectable(true, false)
+	TblTrash.SetFixed(1, 0)
+	TblTrash.SetTitle("Trash")
+
+	FrmSettings = tview.NewForm()
+	FrmSettings.SetBorder(true)
+	FrmSettings.SetTitle("Settings")
+	FrmSettings.SetItemPadding(0)
+	FrmSettings.SetButtonsAlign(tview.AlignCenter)
+
+	TxtSettings = tview.NewTextView()
+	TxtSettings.SetBorder(true)
+	TxtSettings.SetDynamicColors(true)
+	TxtSettings.SetWordWrap(true)
+	TxtSettings.SetTitle("Check")
+
 	//*************************************************************************
 	// Main Layout (Shell)
 	//*************************************************************************
 	FlxShell = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
-		AddItem(TxtConsole, 0, 1, false).
+		AddItem(PgsConsole, 0, 1, false).
 		AddItem(TxtPath, 3, 0, false).
 		AddItem(LblKeys, 2, 1, false).
 		AddItem(TxtPrompt, 2, 1, true).
@@ -345,6 +419,7 @@ func SetUI(fQuit Fn, hostname string) {
 			AddItem(lblStatus, 0, 1, false).
 			AddItem(LblPID, 12, 0, false).
 			AddItem(LblRC, 8, 0, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
@@ -354,6 +429,7 @@ func SetUI(fQuit Fn, hostname string) {
 	FlxHelp = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
 		AddItem(TxtHelp, 0, 1, false).
@@ -362,41 +438,52 @@ func SetUI(fQuit Fn, hostname string) {
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
 	//*************************************************************************
 	// Files Layout
 	//*************************************************************************
+	flxFilesPane1 = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(TxtPath, 3, 0, false).
+		AddItem(TblFiles, 0, 1, true)
+	flxFilesPane2 = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(TxtPath2, 3, 0, false).
+		AddItem(TblFiles2, 0, 1, true)
+	flxFilesInfo = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(FrmFileInfo, 9, 0, false).
+		AddItem(TxtFileInfo, 0, 1, false).
+		AddItem(TxtSelection, 5, 0, false)
+	flxFilesPanes = tview.NewFlex()
+	SetFilesLayout(false)
 	FlxFiles = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
-		AddItem(tview.NewFlex().
-			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-				AddItem(TxtPath, 3, 0, false).
-				AddItem(TblFiles, 0, 1, true), 0, 2, true).
-			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-				AddItem(FrmFileInfo, 9, 0, false).
-				AddItem(TxtFileInfo, 0, 1, false).
-				AddItem(TxtSelection, 5, 0, false), 0, 1, false), 0, 1, false).
+		AddItem(flxFilesPanes, 0, 1, false).
 		AddItem(LblKeys, 2, 1, false).
 		AddItem(TxtPrompt, 2, 1, true).
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
-	TblFiles.Select(0, 0).SetFixed(1, 1).SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyEnter {
-			TblFiles.SetSelectable(true, true)
-		}
-	}).SetSelectedFunc(func(row int, column int) {
-		TblFiles.GetCell(row, column).SetTextColor(tcell.ColorRed)
-		TblFiles.SetSelectable(false, false)
-	})
+	for _, tbl := range []*tview.Table{TblFiles, TblFiles2} {
+		tbl := tbl
+		tbl.Select(0, 0).SetFixed(1, 1).SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter {
+				tbl.SetSelectable(true, true)
+			}
+		}).SetSelectedFunc(func(row int, column int) {
+			tbl.GetCell(row, column).SetTextColor(theme.Selected)
+			tbl.SetSelectable(false, false)
+		})
+	}
 
 	//*************************************************************************
 	// Process Layout
@@ -404,6 +491,7 @@ func SetUI(fQuit Fn, hostname string) {
 	FlxProcess = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
 		AddItem(tview.NewFlex().
@@ -418,6 +506,7 @@ func SetUI(fQuit Fn, hostname string) {
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
@@ -427,6 +516,7 @@ func SetUI(fQuit Fn, hostname string) {
 	FlxEditor = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
 		AddItem(tview.NewFlex().
@@ -441,6 +531,7 @@ func SetUI(fQuit Fn, hostname string) {
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
@@ -450,6 +541,7 @@ func SetUI(fQuit Fn, hostname string) {
 	FlxSQL = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
 		AddItem(tview.NewFlex().
@@ -464,6 +556,7 @@ func SetUI(fQuit Fn, hostname string) {
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
@@ -473,6 +566,7 @@ func SetUI(fQuit Fn, hostname string) {
 	FlxHexEdit = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
 			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
 			AddItem(lblTitle, 0, 1, false).
 			AddItem(lblTime, 8, 0, false), 1, 0, false).
 		AddItem(tview.NewFlex().
@@ -487,6 +581,47 @@ func SetUI(fQuit Fn, hostname string) {
 		AddItem(tview.NewFlex().
 			AddItem(LblHostname, len(hostname)+3, 0, false).
 			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
+			AddItem(LblScreen, 5, 0, false).
+			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
+
+	//*************************************************************************
+	// Audit Layout
+	//*************************************************************************
+	FlxAudit = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
+			AddItem(lblTitle, 0, 1, false).
+			AddItem(lblTime, 8, 0, false), 1, 0, false).
+		AddItem(TblAudit, 0, 1, true).
+		AddItem(LblKeys, 2, 1, false).
+		AddItem(TxtPrompt, 2, 1, false).
+		AddItem(tview.NewFlex().
+			AddItem(LblHostname, len(hostname)+3, 0, false).
+			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
+			AddItem(LblScreen, 5, 0, false).
+			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
+
+	//*************************************************************************
+	// Settings Layout
+	//*************************************************************************
+	FlxSettings = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(lblDate, 10, 0, false).
+			AddItem(LblElevated, 0, 0, false).
+			AddItem(lblTitle, 0, 1, false).
+			AddItem(lblTime, 8, 0, false), 1, 0, false).
+		AddItem(tview.NewFlex().
+			AddItem(FrmSettings, 0, 2, true).
+			AddItem(TxtSettings, 0, 1, false), 0, 1, true).
+		AddItem(LblKeys, 2, 1, false).
+		AddItem(TxtPrompt, 2, 1, false).
+		AddItem(tview.NewFlex().
+			AddItem(LblHostname, len(hostname)+3, 0, false).
+			AddItem(lblStatus, 0, 1, false).
+			AddItem(LblJobs, 5, 0, false).
 			AddItem(LblScreen, 5, 0, false).
 			AddItem(LblHourglass, 2, 0, false), 1, 0, false)
 
@@ -504,6 +639,46 @@ func SetUI(fQuit Fn, hostname string) {
 			}
 		})
 	IdxScreens = -1
+
+	// The screen is needed to ring the bell
+	App.SetAfterDrawFunc(func(s tcell.Screen) {
+		screen = s
+	})
+
+	// The header is the first line of each layout, the status bar the last one
+	for _, flx := range []*tview.Flex{FlxShell, FlxHelp, FlxFiles, FlxProcess, FlxEditor, FlxSQL, FlxHexEdit, FlxAudit, FlxSettings} {
+		statusBars = append(statusBars, flx.GetItem(flx.GetItemCount()-1).(*tview.Flex))
+		headers = append(headers, flx.GetItem(0).(*tview.Flex))
+	}
+}
+
+// ****************************************************************************
+// SetPrompt()
+// SetPrompt displays the prompt in the status bar of all the screens, its
+// label taking the width of the text
+// ****************************************************************************
+func SetPrompt(text string) {
+	LblHostname.SetText(text)
+	width := tview.TaggedStringWidth(text) + 1
+	for _, flx := range statusBars {
+		flx.ResizeItem(LblHostname, width, 0)
+	}
+}
+
+// ****************************************************************************
+// SetElevated()
+// SetElevated shows the badge of the header while the actions run as root
+// ****************************************************************************
+func SetElevated(on bool) {
+	text, width := "", 0
+	if on {
+		text = conf.ELEVATED_BADGE
+		width = tview.TaggedStringWidth(text)
+	}
+	LblElevated.SetText(text)
+	for _, flx := range headers {
+		flx.ResizeItem(LblElevated, width, 0)
+	}
 }
 
 // ****************************************************************************
@@ -569,30 +744,25 @@ func GetTitle() string {
 // ****************************************************************************
 func SetStatus(txt string) {
 	lblStatus.SetText(txt)
-	DurationOfTime := time.Duration(conf.STATUS_MESSAGE_DURATION) * time.Second
+	seconds := MyConfig.StatusDuration
+	if seconds <= 0 {
+		seconds = conf.STATUS_MESSAGE_DURATION
+	}
+	DurationOfTime := time.Duration(seconds) * time.Second
 	f := func() {
 		lblStatus.SetText("")
 	}
 	time.AfterFunc(DurationOfTime, f)
-	current := time.Now()
-	conf.LogFile.WriteString(fmt.Sprintf("%s [%s] : %s\n", current.Format("20060102-150405"), SessionID, txt))
-}
-
-// ****************************************************************************
-// HeaderConsole()
-// ****************************************************************************
-func HeaderConsole(cmd string) {
-	TxtConsole.SetText(TxtConsole.GetText(false) + "\n[red]⯈ " + cmd + ":\n[white]")
-	TxtConsole.ScrollToEnd()
+	logger.Info("%s", txt)
 }
 
 // ****************************************************************************
-// outConsole()
+// SetProgress()
+// SetProgress shows the progress of a long operation in the status bar, not
+// logged nor cleared : its end is given to SetStatus
 // ****************************************************************************
-func OutConsole(out string) {
-	TxtConsole.SetText(TxtConsole.GetText(false) + "[white]" + out + "\n")
-	TxtConsole.ScrollToEnd()
-	App.Sync()
+func SetProgress(txt string) {
+	lblStatus.SetText(txt)
 }
 
 // ****************************************************************************
@@ -618,7 +788,7 @@ func DisplayMap(tv *tview.TextView, m map[string]string) {
 
 	// iterate by sorted keys
 	for _, field := range fields {
-		out = out + "[red]" + field[2:] + strings.Repeat(" ", maxi-len(field)) + "[white]  " + m[field] + "\n"
+		out = out + "[red]" + field[2:] + strings.Repeat(" ", maxi-len(field)) + "[-]  " + m[field] + "\n"
 	}
 	tv.SetText(out)
 }
@@ -637,6 +807,36 @@ func RemoveScreen(s []MyScreen, i int) []MyScreen {
 	return append(s[:i], s[i+1:]...)
 }
 
+// ****************************************************************************
+// SetFilesLayout()
+// SetFilesLayout shows one files pane, or two side by side when dual
+// ****************************************************************************
+func SetFilesLayout(dual bool) {
+	filesDual = dual
+	flxFilesPanes.Clear()
+	if dual {
+		flxFilesPanes.AddItem(flxFilesPane1, 0, 2, true).
+			AddItem(flxFilesPane2, 0, 2, false).
+			AddItem(flxFilesInfo, 0, 1, false)
+	} else {
+		flxFilesPanes.AddItem(flxFilesPane1, 0, 2, true).
+			AddItem(flxFilesInfo, 0, 1, false)
+	}
+	if CurrentMode == ModeFiles && LblKeys != nil {
+		ShowKeys(ModeFiles, "")
+	}
+}
+
+// ****************************************************************************
+// SelectedFile()
+// SelectedFile returns the path of the file under the cursor of the files
+// pane in use
+// ****************************************************************************
+func SelectedFile() string {
+	idx, _ := TblFilesActive.GetSelection()
+	return filepath.Join(conf.Cwd, strings.TrimSpace(TblFilesActive.GetCell(idx, 2).Text))
+}
+
 // ****************************************************************************
 // GetCurrentScreen()
 // ****************************************************************************
@@ -644,10 +844,28 @@ func GetCurrentScreen() string {
 	return (ArrScreens[IdxScreens].Title + "_" + ArrScreens[IdxScreens].ID)
 }
 
+// ****************************************************************************
+// IsScreenOpen()
+// IsScreenOpen tells if the screen of page id is still open, for the modules
+// which keep a state by screen
+// ****************************************************************************
+func IsScreenOpen(id string) bool {
+	for _, s := range ArrScreens {
+		if s.Title+"_"+s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // ****************************************************************************
 // CloseCurrentScreen()
 // ****************************************************************************
 func CloseCurrentScreen() {
+	// A screen can own a connection
+	if c, ok := ArrScreens[IdxScreens].Param.(io.Closer); ok {
+		c.Close()
+	}
 	PgsApp.RemovePage(ArrScreens[IdxScreens].Title + "_" + ArrScreens[IdxScreens].ID)
 	ArrScreens = RemoveScreen(ArrScreens, IdxScreens)
 	if len(ArrScreens) == 0 {
@@ -695,14 +913,18 @@ func ShowScreen(idx int) {
 	var screen MyScreen = ArrScreens[idx]
 	SetTitle(fmt.Sprintf("%s (%s)", screen.Title, screen.ID))
 	CurrentMode = screen.Mode
-	LblKeys.SetText(conf.FKEY_LABELS + "\n" + screen.Keys)
+	ShowKeys(screen.Mode, screen.Keys)
 	PgsApp.SwitchToPage(screen.Title + "_" + screen.ID)
 	IdxScreens = idx
 	LblScreen.SetText(fmt.Sprintf("%d/%d", IdxScreens+1, len(ArrScreens)))
 
 	switch CurrentMode {
 	case ModeShell:
-		App.SetFocus(TxtPrompt)
+		if TerminalActive() {
+			App.SetFocus(TrmConsole)
+		} else {
+			App.SetFocus(TxtPrompt)
+		}
 	case ModeFiles:
 		App.SetFocus(TblFiles)
 	case ModeProcess:
@@ -715,7 +937,51 @@ func ShowScreen(idx int) {
 		App.SetFocus(TblHexEdit)
 	case ModeHelp:
 		App.SetFocus(TxtHelp)
+	case ModeAudit:
+		App.SetFocus(TblAudit)
+	case ModeSettings:
+		App.SetFocus(FrmSettings)
+	}
+	if OnShowScreen != nil {
+		OnShowScreen()
+	}
+}
+
+// ****************************************************************************
+// ShowKeys()
+// ShowKeys displays the global keys, then hint and the keys of mode
+// ****************************************************************************
+func ShowKeys(mode Mode, hint string) {
+	var scopes []string
+	switch mode {
+	case ModeShell:
+		scopes = []string{keymap.SCOPE_CONSOLE}
+	case ModeFiles:
+		if filesDual {
+			scopes = []string{keymap.SCOPE_PANES, keymap.SCOPE_FILES}
+		} else {
+			scopes = []string{keymap.SCOPE_FILES}
+		}
+	case ModeProcess:
+		scopes = []string{keymap.SCOPE_PROCESS}
+	case ModeTextEdit:
+		scopes = []string{keymap.SCOPE_EDITOR}
+	case ModeSQLite3:
+		scopes = []string{keymap.SCOPE_SQLITE3}
+	case ModeHexEdit:
+		scopes = []string{keymap.SCOPE_HEXEDIT}
+	case ModeAudit:
+		scopes = []string{keymap.SCOPE_AUDIT}
+	case ModeSettings:
+		scopes = []string{keymap.SCOPE_SETTINGS}
 	}
+	keys := keymap.Labels(scopes...)
+	if hint != "" && keys != "" {
+		keys = hint + " " + keys
+	} else if hint != "" {
+		keys = hint
+	}
+	LblKeys.SetText(keymap.Labels(keymap.SCOPE_GLOBAL) + "\n" + keys)
 }
 
 // ****************************************************************************
@@ -731,20 +997,20 @@ func AddNewScreen(mode Mode, selfInit FnAny, param any) {
 	switch mode {
 	case ModeFiles:
 		screen.Title = "Files"
-		screen.Keys = "Del=Delete Ins=Select Ctrl+A=Select/Unselect All Ctrl+C=Copy Ctrl+X=Cut Ctrl+V=Paste Ctrl+S=Sort"
+		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxFiles, true, true)
 		App.SetFocus(TblFiles)
 	case ModeHexEdit:
 		screen.Title = "Hexedit"
-		screen.Keys = "Ctrl+O=Open Ctrl+S=Save Ctrl+F=Find Ctrl+G=Go"
+		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxHexEdit, true, true)
 	case ModeProcess:
 		screen.Title = "Process"
-		screen.Keys = "Ctrl+F=Find Ctrl+S=Sort Ctrl+V=Switch View"
+		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxProcess, true, true)
 	case ModeSQLite3:
 		screen.Title = "SQLite3"
-		screen.Keys = "Ctrl+O=Open Ctrl+S=Save"
+		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxSQL, true, true)
 	case ModeShell:
 		screen.Title = "Shell"
@@ -752,12 +1018,20 @@ func AddNewScreen(mode Mode, selfInit FnAny, param any) {
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxShell, true, true)
 	case ModeTextEdit:
 		screen.Title = "Editor"
-		screen.Keys = "Ctrl+S=Save Alt+S=Save as… Ctrl+N=New Ctrl+T=Close"
+		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxEditor, true, true)
 	case ModeHelp:
 		screen.Title = "Help"
 		screen.Keys = ""
 		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxHelp, true, true)
+	case ModeAudit:
+		screen.Title = "Audit"
+		screen.Keys = "Prompt=Filter (action:kill user:bob result:failed text…)"
+		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxAudit, true, true)
+	case ModeSettings:
+		screen.Title = "Settings"
+		screen.Keys = "Tab=Next Field"
+		PgsApp.AddPage(screen.Title+"_"+screen.ID, FlxSettings, true, true)
 	}
 	ArrScreens = append(ArrScreens, screen)
 	IdxScreens = len(ArrScreens) - 1 // Set IdxScreens to the newly added screen
@@ -768,6 +1042,40 @@ func AddNewScreen(mode Mode, selfInit FnAny, param any) {
 	SetStatus(fmt.Sprintf("New screen [%s-%s]", screen.Title, strings.ToUpper(screen.ID)))
 }
 
+// ****************************************************************************
+// ShowTerminal()
+// ShowTerminal displays the terminal emulator in place of the console
+// ****************************************************************************
+func ShowTerminal(vt vt10x.Terminal, title string, input func([]byte), resize func(cols int, rows int)) {
+	TrmConsole.SetTerminal(vt, input, resize)
+	TrmConsole.SetTitle(" " + title + " ")
+	PgsConsole.SwitchToPage("terminal")
+	if CurrentMode == ModeShell {
+		App.SetFocus(TrmConsole)
+	}
+}
+
+// ****************************************************************************
+// HideTerminal()
+// HideTerminal goes back to the console when the program has exited
+// ****************************************************************************
+func HideTerminal() {
+	focused := TrmConsole.HasFocus()
+	TrmConsole.SetTerminal(nil, nil, nil)
+	PgsConsole.SwitchToPage("console")
+	if focused {
+		App.SetFocus(TxtPrompt)
+	}
+}
+
+// ****************************************************************************
+// TerminalActive()
+// ****************************************************************************
+func TerminalActive() bool {
+	name, _ := PgsConsole.GetFrontPage()
+	return name == "terminal"
+}
+
 func PleaseWait() {
 	SetStatus("Running...")
 	LblHourglass.SetText("⌛")